@@ -3,20 +3,26 @@ package cli
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/miekg/dns"
+	"github.com/prionis/dns-server/internal/config"
 	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/internal/metrics"
 	"github.com/prionis/dns-server/internal/server"
+	"github.com/prionis/dns-server/internal/tracing"
 	"github.com/prionis/dns-server/proto/crud/genproto/crudpb"
 	"google.golang.org/protobuf/proto"
 
@@ -94,9 +100,17 @@ func DelRR(id int64, addr, port string) {
 	}
 }
 
-func StartServer(logPath string) {
+// shutdownTimeout limit waiting for in-flight requests when the server is stopped.
+const shutdownTimeout = 15 * time.Second
+
+func StartServer(loader config.Loader) {
 	server.LoadEnvs()
-	logFile, err := os.OpenFile(logPath, os.O_RDWR|os.O_CREATE, 0644)
+	conf, err := loader.Load()
+	if err != nil {
+		printError("incorrect configuration\n" + err.Error())
+		return
+	}
+	logFile, err := os.OpenFile(conf.Log.File, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
 		printError("can't open log file\n" + err.Error())
 		return
@@ -104,22 +118,40 @@ func StartServer(logPath string) {
 
 	ws := server.NewWSWriter()
 	writer := io.MultiWriter(logFile, os.Stdout, ws)
-	logger := slog.New(slog.NewJSONHandler(writer, nil))
+	level := &slog.LevelVar{}
+	logger := slog.New(slog.NewJSONHandler(writer, &slog.HandlerOptions{Level: level}))
 	slog.SetDefault(logger)
 
+	shutdownTracing, err := tracing.Setup(context.Background(), conf.Tracing.Exporter)
+	if err != nil {
+		printError("can't set up tracing\n" + err.Error())
+		return
+	}
+	defer shutdownTracing(context.Background())
+
+	m := metrics.New()
 	slog.Info("connecting to database")
-	db, err := database.NewPostgres("")
+	db, err := database.NewPostgres(conf.Database.URL, m.QueryTracer(), tracing.QueryTracer())
 	if err != nil {
 		printError("can't connect to database\n" + err.Error())
 		return
 	}
 	logger.Info("connection with database established")
 
-	config := []server.Option{
-		server.SetDNSPort(":53"),
+	// Configuration is read again on every reload, so changes of the file are applied.
+	s, err := server.NewServer(
 		server.WithDB(db),
-	}
-	s, err := server.NewServer(config...)
+		server.WithMetrics(m),
+		server.WithLogStream(ws),
+		server.WithLevelVar(level),
+		server.WithReloadSource(func() ([]server.Option, error) {
+			conf, err := loader.Load()
+			if err != nil {
+				return nil, err
+			}
+			return conf.Options(), nil
+		}),
+	)
 	if err != nil {
 		printError(fmt.Sprintf("can't create new server\n%s", err.Error()))
 		return
@@ -127,9 +159,48 @@ func StartServer(logPath string) {
 	logger.Info("server created")
 
 	logger.Info("starting server")
-	if err := s.Start(ws); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := s.Start(ctx); err != nil {
+		db.Close()
 		printError(fmt.Sprintf("can't start server\n%s", err.Error()))
+		return
+	}
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+wait:
+	for {
+		select {
+		case <-reload:
+			if err := s.Reload(ctx); err != nil {
+				logger.Error("can't reload configuration, previous one is kept: " + err.Error())
+			}
+		case <-ctx.Done():
+			logger.Info("signal received, terminating")
+			break wait
+		case err := <-s.Errors():
+			logger.Error("server failed, terminating: " + err.Error())
+			break wait
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		printError(fmt.Sprintf("can't shut down server gracefully\n%s", err.Error()))
+	}
+}
+
+// CheckConfig validate the configuration and print found errors.
+func CheckConfig(loader config.Loader) {
+	server.LoadEnvs()
+	if _, err := loader.Load(); err != nil {
+		printError("incorrect configuration\n" + err.Error() + "\n")
+		os.Exit(1)
 	}
+	printSuccess("configuration is correct\n")
 }
 
 type log struct {
@@ -0,0 +1,126 @@
+// Package acl contain access control list of the DNS clients.
+package acl
+
+import (
+	"net/netip"
+	"sync"
+
+	"github.com/miekg/dns"
+
+	"github.com/prionis/dns-server/internal/database"
+)
+
+// Action is the kind of request that client make.
+type Action int
+
+// Actions that are allowed by rules separately.
+const (
+	Query Action = iota
+	Transfer
+	Update
+)
+
+func (a Action) String() string {
+	switch a {
+	case Query:
+		return "query"
+	case Transfer:
+		return "transfer"
+	case Update:
+		return "update"
+	}
+	return "unknown"
+}
+
+// DefaultRule is used for the clients that are not in the network of any rule.
+// Such clients can query records of the server, other actions are denied.
+var DefaultRule = database.ACLRule{AllowQuery: true}
+
+// List is an access control list. Rule with the most specific network
+// that contain address of the client decide which actions are allowed.
+type List struct {
+	mx sync.RWMutex
+	// static rules stay in the list after every load.
+	static []database.ACLRule
+	loaded []database.ACLRule
+	rules  []database.ACLRule
+}
+
+// New create list with static rules, e.g. from the configuration.
+func New(static []database.ACLRule) *List {
+	l := &List{}
+	l.SetStatic(static)
+	return l
+}
+
+// SetStatic replace static rules of the list, loaded rules are kept.
+func (l *List) SetStatic(static []database.ACLRule) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.static = nil
+	for _, rule := range static {
+		rule.Network = rule.Network.Masked()
+		l.static = append(l.static, rule)
+	}
+	l.merge()
+}
+
+// Load replace rules of the list, static rules are kept.
+// Loaded rule replace static rule with the same network.
+func (l *List) Load(rules []database.ACLRule) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.loaded = rules
+	l.merge()
+}
+
+// merge combine static and loaded rules, loaded rules are after static ones,
+// so they win for the same network.
+func (l *List) merge() {
+	l.rules = append(append([]database.ACLRule{}, l.static...), l.loaded...)
+}
+
+// Rule return rule for the client with provided address.
+func (l *List) Rule(addr netip.Addr) database.ACLRule {
+	addr = addr.Unmap()
+
+	l.mx.RLock()
+	defer l.mx.RUnlock()
+
+	rule := DefaultRule
+	bits := -1
+	for _, r := range l.rules {
+		if r.Network.Bits() >= bits && r.Network.Contains(addr) {
+			rule = r
+			bits = r.Network.Bits()
+		}
+	}
+	return rule
+}
+
+// Allowed report whether the client with provided address can make the action.
+func (l *List) Allowed(addr netip.Addr, action Action) bool {
+	rule := l.Rule(addr)
+	switch action {
+	case Query:
+		return rule.AllowQuery
+	case Transfer:
+		return rule.AllowTransfer
+	case Update:
+		return rule.AllowUpdate
+	}
+	return false
+}
+
+// ActionOf return action of the request.
+func ActionOf(msg *dns.Msg) Action {
+	if msg.Opcode == dns.OpcodeUpdate {
+		return Update
+	}
+	for _, question := range msg.Question {
+		if question.Qtype == dns.TypeAXFR || question.Qtype == dns.TypeIXFR {
+			return Transfer
+		}
+	}
+	return Query
+}
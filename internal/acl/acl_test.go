@@ -0,0 +1,55 @@
+package acl
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"github.com/prionis/dns-server/internal/database"
+)
+
+func TestList(t *testing.T) {
+	l := New([]database.ACLRule{
+		{Network: netip.MustParsePrefix("0.0.0.0/0")},
+		{Network: netip.MustParsePrefix("10.0.0.0/8"), AllowQuery: true, AllowTransfer: true},
+	})
+	l.Load([]database.ACLRule{
+		{ID: 1, Network: netip.MustParsePrefix("10.1.0.0/16"), AllowQuery: true, AllowTransfer: true},
+		{ID: 2, Network: netip.MustParsePrefix("10.0.0.0/8"), AllowQuery: true},
+	})
+
+	tests := []struct {
+		addr    string
+		action  Action
+		allowed bool
+	}{
+		{"203.0.113.1", Query, false},
+		{"10.2.0.1", Query, true},
+		// Loaded rule replace static rule with the same network.
+		{"10.2.0.1", Transfer, false},
+		{"10.1.0.1", Transfer, true},
+		{"::ffff:10.1.0.1", Transfer, true},
+		{"10.1.0.1", Update, false},
+		// Default rule allow only queries.
+		{"2001:db8::1", Query, true},
+		{"2001:db8::1", Update, false},
+	}
+	for _, tt := range tests {
+		if got := l.Allowed(netip.MustParseAddr(tt.addr), tt.action); got != tt.allowed {
+			t.Errorf("%s from %s: expected %t, got %t", tt.action, tt.addr, tt.allowed, got)
+		}
+	}
+}
+
+func TestActionOf(t *testing.T) {
+	if action := ActionOf(new(dns.Msg).SetQuestion("example.com.", dns.TypeA)); action != Query {
+		t.Errorf("expected query, got %s", action)
+	}
+	if action := ActionOf(new(dns.Msg).SetAxfr("example.com.")); action != Transfer {
+		t.Errorf("expected transfer, got %s", action)
+	}
+	if action := ActionOf(new(dns.Msg).SetUpdate("example.com.")); action != Update {
+		t.Errorf("expected update, got %s", action)
+	}
+}
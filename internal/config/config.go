@@ -0,0 +1,540 @@
+// Package config read configuration of the server from the YAML or TOML file.
+//
+// Values are taken from, in order of precedence: command line flags, environment
+// variables, the configuration file and defaults.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/internal/rrl"
+	"github.com/prionis/dns-server/internal/server"
+	"github.com/prionis/dns-server/internal/tracing"
+)
+
+// Config is the configuration of the server.
+type Config struct {
+	Listen   Listen   `yaml:"listen" toml:"listen"`
+	Database Database `yaml:"database" toml:"database"`
+	Auth     Auth     `yaml:"auth" toml:"auth"`
+	Log      Log      `yaml:"log" toml:"log"`
+	Tracing  Tracing  `yaml:"tracing" toml:"tracing"`
+	DNS      DNS      `yaml:"dns" toml:"dns"`
+	Stats    Storage  `yaml:"stats" toml:"stats"`
+	QueryLog Storage  `yaml:"query_log" toml:"query_log"`
+}
+
+// Listen contain addresses of the listeners.
+type Listen struct {
+	// DNS is the addresses of the UDP and TCP DNS listeners, host may be the name of the interface.
+	DNS Addresses `yaml:"dns" toml:"dns"`
+	// HTTP is the addresses of the HTTP API, "unix:/path" is the Unix socket.
+	HTTP Addresses `yaml:"http" toml:"http"`
+	// ReusePort is the number of UDP sockets bound for every DNS address with SO_REUSEPORT.
+	ReusePort int `yaml:"reuse_port" toml:"reuse_port"`
+	// SocketActivation enable use of the sockets passed by systemd.
+	SocketActivation bool `yaml:"socket_activation" toml:"socket_activation"`
+}
+
+// Addresses is the list of the listen addresses. Single address may be written as the string,
+// in environment variables and flags addresses are separated by commas.
+type Addresses []string
+
+// ParseAddresses return addresses separated by commas.
+func ParseAddresses(s string) Addresses {
+	var addrs Addresses
+	for _, addr := range strings.Split(s, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// UnmarshalYAML accept the string or the list of strings.
+func (a *Addresses) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*a = Addresses{node.Value}
+		return nil
+	}
+	var addrs []string
+	if err := node.Decode(&addrs); err != nil {
+		return err
+	}
+	*a = addrs
+	return nil
+}
+
+// UnmarshalTOML accept the string or the array of strings.
+func (a *Addresses) UnmarshalTOML(data any) error {
+	switch v := data.(type) {
+	case string:
+		*a = Addresses{v}
+	case []any:
+		addrs := make(Addresses, 0, len(v))
+		for _, item := range v {
+			addr, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("address must be the string, got %v", item)
+			}
+			addrs = append(addrs, addr)
+		}
+		*a = addrs
+	default:
+		return fmt.Errorf("addresses must be the string or the array of strings, got %v", data)
+	}
+	return nil
+}
+
+// Database contain connection settings of the database.
+type Database struct {
+	// Backend is the kind of the database, only postgres is supported.
+	Backend string `yaml:"backend" toml:"backend"`
+	// URL is the connection string. If it is empty, it is built from
+	// POSTGRES_USER, POSTGRES_PASSWORD, POSTGRES_ADDR and POSTGRES_DB variables.
+	URL string `yaml:"url" toml:"url"`
+}
+
+// Auth contain settings of the API authentication.
+type Auth struct {
+	// JWTSecret is the key for signing of the tokens.
+	JWTSecret string `yaml:"jwt_secret" toml:"jwt_secret"`
+	// PreviousJWTSecrets are the keys replaced by JWTSecret, tokens signed with them
+	// are accepted until they expire.
+	PreviousJWTSecrets []string `yaml:"previous_jwt_secrets" toml:"previous_jwt_secrets"`
+	AccessTokenTTL     Duration `yaml:"access_token_ttl" toml:"access_token_ttl"`
+	RefreshTokenTTL    Duration `yaml:"refresh_token_ttl" toml:"refresh_token_ttl"`
+	// AdminPassword is the password of the admin created on the first start,
+	// random password is generated and written to stderr if it is empty.
+	AdminPassword string `yaml:"admin_password" toml:"admin_password"`
+	// OIDC enable login through the OpenID Connect provider if the issuer is set.
+	OIDC OIDC `yaml:"oidc" toml:"oidc"`
+}
+
+// OIDC contain settings of the login through the OpenID Connect provider, see server.OIDCConfig.
+type OIDC struct {
+	Issuer       string     `yaml:"issuer" toml:"issuer"`
+	ClientID     string     `yaml:"client_id" toml:"client_id"`
+	ClientSecret string     `yaml:"client_secret" toml:"client_secret"`
+	RedirectURL  string     `yaml:"redirect_url" toml:"redirect_url"`
+	Scopes       []string   `yaml:"scopes" toml:"scopes"`
+	LoginClaim   string     `yaml:"login_claim" toml:"login_claim"`
+	RolesClaim   string     `yaml:"roles_claim" toml:"roles_claim"`
+	Roles        []OIDCRole `yaml:"roles" toml:"roles"`
+	DefaultRole  string     `yaml:"default_role" toml:"default_role"`
+}
+
+// OIDCRole map value of the roles claim to the local role.
+type OIDCRole struct {
+	Value string `yaml:"value" toml:"value"`
+	Role  string `yaml:"role" toml:"role"`
+}
+
+// Log contain settings of the application log.
+type Log struct {
+	// Level is the minimal level of the messages: debug, info, warn or error.
+	Level string `yaml:"level" toml:"level"`
+	// File is the path of the log file.
+	File string `yaml:"file" toml:"file"`
+}
+
+// Tracing contain settings of the OpenTelemetry tracing.
+type Tracing struct {
+	// Exporter of the spans: none, stdout or otlp.
+	Exporter string `yaml:"exporter" toml:"exporter"`
+}
+
+// DNS contain settings of answering DNS queries.
+type DNS struct {
+	// ClientSubnet is the networks of the trusted resolvers, EDNS Client Subnet option
+	// of their queries select the view. Option of other clients is ignored.
+	ClientSubnet []string `yaml:"client_subnet" toml:"client_subnet"`
+	// ACL is the static access control list, rules from the database replace rules with the same network.
+	ACL []ACLRule `yaml:"acl" toml:"acl"`
+	// RateLimit enable response rate limiting of the UDP answers.
+	RateLimit *RateLimit `yaml:"rate_limit" toml:"rate_limit"`
+	// ViewRateLimits set rate limiting for views by name.
+	ViewRateLimits map[string]RateLimit `yaml:"view_rate_limits" toml:"view_rate_limits"`
+}
+
+// ACLRule allow actions for the clients from the network.
+type ACLRule struct {
+	Network    string `yaml:"network" toml:"network"`
+	AllowQuery bool   `yaml:"allow_query" toml:"allow_query"`
+	// AllowRecursion is kept with the rule, the server doesn't recurse and doesn't check it.
+	AllowRecursion bool `yaml:"allow_recursion" toml:"allow_recursion"`
+	AllowTransfer  bool `yaml:"allow_transfer" toml:"allow_transfer"`
+	AllowUpdate    bool `yaml:"allow_update" toml:"allow_update"`
+	// RateLimit set rate limiting for the clients from the network instead of the limit of their view.
+	RateLimit *RateLimit `yaml:"rate_limit" toml:"rate_limit"`
+}
+
+// RateLimit is the configuration of the response rate limiting, see rrl.Config.
+// Omitted window, slip and prefix lengths are taken from rrl.DefaultConfig.
+type RateLimit struct {
+	ResponsesPerSecond int      `yaml:"responses_per_second" toml:"responses_per_second"`
+	NXDomainsPerSecond int      `yaml:"nxdomains_per_second" toml:"nxdomains_per_second"`
+	ErrorsPerSecond    int      `yaml:"errors_per_second" toml:"errors_per_second"`
+	Window             Duration `yaml:"window" toml:"window"`
+	Slip               *int     `yaml:"slip" toml:"slip"`
+	IPv4PrefixLen      int      `yaml:"ipv4_prefix_len" toml:"ipv4_prefix_len"`
+	IPv6PrefixLen      int      `yaml:"ipv6_prefix_len" toml:"ipv6_prefix_len"`
+}
+
+// Storage contain settings of the data stored in the database.
+type Storage struct {
+	// Retention is how long the data is stored.
+	Retention Duration `yaml:"retention" toml:"retention"`
+}
+
+// Duration is the time.Duration written as the string, e.g. "15s" or "7d".
+type Duration time.Duration
+
+// UnmarshalText parse duration with time.ParseDuration, "d" suffix is also accepted for days.
+func (d *Duration) UnmarshalText(text []byte) error {
+	s := string(text)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return fmt.Errorf("incorrect duration %q", s)
+		}
+		*d = Duration(n * float64(24*time.Hour))
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText write duration as the string.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Default return configuration with default values.
+func Default() Config {
+	return Config{
+		Listen:   Listen{DNS: Addresses{":53"}, HTTP: Addresses{":8083"}},
+		Database: Database{Backend: "postgres"},
+		Auth:     Auth{AccessTokenTTL: Duration(15 * time.Minute), RefreshTokenTTL: Duration(14 * 24 * time.Hour)},
+		Log:      Log{Level: "info", File: "DNSServer.log"},
+		Tracing:  Tracing{Exporter: tracing.ExporterNone},
+		Stats:    Storage{Retention: Duration(7 * 24 * time.Hour)},
+		QueryLog: Storage{Retention: Duration(7 * 24 * time.Hour)},
+	}
+}
+
+// Loader read the configuration from all sources.
+type Loader struct {
+	// Path of the configuration file, format is chosen by extension: .yaml, .yml or .toml.
+	// Empty path means there is no file.
+	Path string
+	// Flags override values set by other sources, it may be nil.
+	Flags func(*Config)
+}
+
+// Load return default configuration overridden by the file, environment variables
+// and flags, in this order. Returned configuration is validated.
+func (l Loader) Load() (Config, error) {
+	conf := Default()
+	if l.Path != "" {
+		if err := conf.readFile(l.Path); err != nil {
+			return conf, err
+		}
+	}
+	conf.applyEnv(os.LookupEnv)
+	if l.Flags != nil {
+		l.Flags(&conf)
+	}
+	return conf, conf.Validate()
+}
+
+// readFile override configuration by values from the file. Unknown keys are errors,
+// so misspelled settings are not silently ignored.
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("can't read configuration file: %w", err)
+	}
+
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("can't parse configuration file %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("can't parse configuration file %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) != 0 {
+			return fmt.Errorf("unknown keys in configuration file %s: %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("unknown format of configuration file %s, expected .yaml, .yml or .toml", path)
+	}
+	return nil
+}
+
+// envVars map environment variables to the configuration values they override.
+var envVars = []struct {
+	name string
+	set  func(c *Config, value string)
+}{
+	{"DNS_SERVER_DNS_ADDR", func(c *Config, v string) { c.Listen.DNS = ParseAddresses(v) }},
+	{"DNS_SERVER_HTTP_ADDR", func(c *Config, v string) { c.Listen.HTTP = ParseAddresses(v) }},
+	{"DNS_SERVER_DATABASE_URL", func(c *Config, v string) { c.Database.URL = v }},
+	{"JWT_SECRET", func(c *Config, v string) { c.Auth.JWTSecret = v }},
+	{"DNS_SERVER_ADMIN_PASSWORD", func(c *Config, v string) { c.Auth.AdminPassword = v }},
+	{"DNS_SERVER_OIDC_CLIENT_SECRET", func(c *Config, v string) { c.Auth.OIDC.ClientSecret = v }},
+	{"DNS_SERVER_LOG_LEVEL", func(c *Config, v string) { c.Log.Level = v }},
+	{"DNS_SERVER_LOG_FILE", func(c *Config, v string) { c.Log.File = v }},
+	{"OTEL_TRACES_EXPORTER", func(c *Config, v string) { c.Tracing.Exporter = v }},
+}
+
+// applyEnv override configuration by the set environment variables.
+func (c *Config) applyEnv(lookup func(string) (string, bool)) {
+	for _, env := range envVars {
+		if value, ok := lookup(env.name); ok && value != "" {
+			env.set(c, value)
+		}
+	}
+}
+
+// Validate check the whole configuration and return all found errors.
+func (c Config) Validate() error {
+	var errs []error
+	errs = append(errs, c.Listen.DNS.validate("listen.dns", false, c.Listen.SocketActivation))
+	errs = append(errs, c.Listen.HTTP.validate("listen.http", true, c.Listen.SocketActivation))
+	if c.Listen.ReusePort < 0 {
+		errs = append(errs, errors.New("listen.reuse_port: must not be negative"))
+	}
+	if c.Database.Backend != "postgres" {
+		errs = append(errs, fmt.Errorf("database.backend: unknown backend %q, only postgres is supported", c.Database.Backend))
+	}
+	if _, err := c.logLevel(); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+	switch c.Tracing.Exporter {
+	case "", tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter: unknown exporter %q", c.Tracing.Exporter))
+	}
+	if _, err := c.aclRules(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := c.clientSubnet(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.DNS.RateLimit != nil {
+		if err := c.DNS.RateLimit.config().Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("dns.rate_limit: %w", err))
+		}
+	}
+	for view, limit := range c.DNS.ViewRateLimits {
+		if err := limit.config().Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("dns.view_rate_limits.%s: %w", view, err))
+		}
+	}
+	for i, rule := range c.DNS.ACL {
+		if rule.RateLimit == nil {
+			continue
+		}
+		if err := rule.RateLimit.config().Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("dns.acl[%d].rate_limit: %w", i, err))
+		}
+	}
+	if c.Auth.AccessTokenTTL <= 0 {
+		errs = append(errs, errors.New("auth.access_token_ttl: must be positive"))
+	}
+	if c.Auth.RefreshTokenTTL < c.Auth.AccessTokenTTL {
+		errs = append(errs, errors.New("auth.refresh_token_ttl: must not be shorter than auth.access_token_ttl"))
+	}
+	errs = append(errs, c.Auth.OIDC.validate())
+	if c.Stats.Retention <= 0 {
+		errs = append(errs, errors.New("stats.retention: must be positive"))
+	}
+	if c.QueryLog.Retention <= 0 {
+		errs = append(errs, errors.New("query_log.retention: must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// Options return server options from the validated configuration. Database, log file
+// and tracing are not server options, they are used by the caller directly.
+func (c Config) Options() []server.Option {
+	level, _ := c.logLevel()
+	rules, _ := c.aclRules()
+	resolvers, _ := c.clientSubnet()
+
+	opts := []server.Option{
+		server.WithDNSAddrs(c.Listen.DNS...),
+		server.WithHTTPAddrs(c.Listen.HTTP...),
+		server.WithReusePort(c.Listen.ReusePort),
+		server.WithSocketActivation(c.Listen.SocketActivation),
+		server.WithJWTSecret(c.Auth.JWTSecret),
+		server.WithPreviousJWTSecrets(c.Auth.PreviousJWTSecrets...),
+		server.WithTokenTTL(time.Duration(c.Auth.AccessTokenTTL), time.Duration(c.Auth.RefreshTokenTTL)),
+		server.WithAdminPassword(c.Auth.AdminPassword),
+		server.WithLogLevel(level),
+		server.WithClientSubnet(resolvers...),
+		server.WithACLRules(rules...),
+		server.WithStatsRetention(time.Duration(c.Stats.Retention)),
+		server.WithQueryLogRetention(time.Duration(c.QueryLog.Retention)),
+	}
+	if c.DNS.RateLimit != nil {
+		opts = append(opts, server.WithRateLimit(c.DNS.RateLimit.config()))
+	}
+	for view, limit := range c.DNS.ViewRateLimits {
+		opts = append(opts, server.WithViewRateLimit(view, limit.config()))
+	}
+	for i, rule := range c.DNS.ACL {
+		if rule.RateLimit != nil {
+			opts = append(opts, server.WithACLRateLimit(rules[i].Network, rule.RateLimit.config()))
+		}
+	}
+	if c.Auth.OIDC.Issuer != "" {
+		opts = append(opts, server.WithOIDC(c.Auth.OIDC.config()))
+	}
+	return opts
+}
+
+func (o OIDC) validate() error {
+	if o.Issuer == "" {
+		if o.ClientID != "" || o.RedirectURL != "" || len(o.Roles) > 0 {
+			return errors.New("auth.oidc.issuer: required when OIDC is configured")
+		}
+		return nil
+	}
+
+	var errs []error
+	if o.ClientID == "" {
+		errs = append(errs, errors.New("auth.oidc.client_id: required"))
+	}
+	if o.RedirectURL == "" {
+		errs = append(errs, errors.New("auth.oidc.redirect_url: required"))
+	}
+	for i, role := range o.Roles {
+		if role.Value == "" || role.Role == "" {
+			errs = append(errs, fmt.Errorf("auth.oidc.roles[%d]: value and role are required", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (o OIDC) config() server.OIDCConfig {
+	roles := make([]server.OIDCRole, len(o.Roles))
+	for i, role := range o.Roles {
+		roles[i] = server.OIDCRole{Value: role.Value, Role: role.Role}
+	}
+	return server.OIDCConfig{
+		Issuer:       o.Issuer,
+		ClientID:     o.ClientID,
+		ClientSecret: o.ClientSecret,
+		RedirectURL:  o.RedirectURL,
+		Scopes:       o.Scopes,
+		LoginClaim:   o.LoginClaim,
+		RolesClaim:   o.RolesClaim,
+		Roles:        roles,
+		DefaultRole:  o.DefaultRole,
+	}
+}
+
+func (a Addresses) validate(key string, unixAllowed, activation bool) error {
+	if len(a) == 0 && !activation {
+		return fmt.Errorf("%s: at least one address is required", key)
+	}
+	var errs []error
+	for _, addr := range a {
+		if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+			if !unixAllowed || path == "" {
+				errs = append(errs, fmt.Errorf("%s: incorrect address %q", key, addr))
+			}
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			errs = append(errs, fmt.Errorf("%s: incorrect address %q", key, addr))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c Config) logLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(c.Log.Level))
+	return level, err
+}
+
+func (c Config) aclRules() ([]database.ACLRule, error) {
+	var rules []database.ACLRule
+	var errs []error
+	for i, rule := range c.DNS.ACL {
+		network, err := netip.ParsePrefix(rule.Network)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("dns.acl[%d]: incorrect network %q", i, rule.Network))
+			continue
+		}
+		rules = append(rules, database.ACLRule{
+			Network:        network,
+			AllowQuery:     rule.AllowQuery,
+			AllowRecursion: rule.AllowRecursion,
+			AllowTransfer:  rule.AllowTransfer,
+			AllowUpdate:    rule.AllowUpdate,
+		})
+	}
+	return rules, errors.Join(errs...)
+}
+
+func (c Config) clientSubnet() ([]netip.Prefix, error) {
+	var resolvers []netip.Prefix
+	var errs []error
+	for i, network := range c.DNS.ClientSubnet {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("dns.client_subnet[%d]: incorrect network %q", i, network))
+			continue
+		}
+		resolvers = append(resolvers, prefix)
+	}
+	return resolvers, errors.Join(errs...)
+}
+
+func (r RateLimit) config() rrl.Config {
+	config := rrl.DefaultConfig
+	config.ResponsesPerSecond = r.ResponsesPerSecond
+	config.NXDomainsPerSecond = r.NXDomainsPerSecond
+	config.ErrorsPerSecond = r.ErrorsPerSecond
+	if r.Window != 0 {
+		config.Window = time.Duration(r.Window)
+	}
+	if r.Slip != nil {
+		config.Slip = *r.Slip
+	}
+	if r.IPv4PrefixLen != 0 {
+		config.IPv4PrefixLen = r.IPv4PrefixLen
+	}
+	if r.IPv6PrefixLen != 0 {
+		config.IPv6PrefixLen = r.IPv6PrefixLen
+	}
+	return config
+}
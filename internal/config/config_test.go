@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	yamlPath := writeFile(t, "config.yaml", `
+listen:
+  dns: ":5353"
+  http: [":9000"]
+log:
+  level: debug
+dns:
+  acl:
+    - network: 10.0.0.0/8
+      allow_query: true
+      rate_limit:
+        responses_per_second: 50
+  rate_limit:
+    responses_per_second: 10
+    slip: 0
+stats:
+  retention: 2d
+`)
+	tomlPath := writeFile(t, "config.toml", `
+[listen]
+dns = ":5353"
+http = [":9000"]
+
+[log]
+level = "debug"
+
+[[dns.acl]]
+network = "10.0.0.0/8"
+allow_query = true
+rate_limit = { responses_per_second = 50 }
+
+[dns.rate_limit]
+responses_per_second = 10
+slip = 0
+
+[stats]
+retention = "2d"
+`)
+
+	for _, path := range []string{yamlPath, tomlPath} {
+		t.Run(filepath.Ext(path), func(t *testing.T) {
+			t.Setenv("DNS_SERVER_HTTP_ADDR", ":9001, unix:/run/dns-server.sock")
+			conf, err := Loader{Path: path, Flags: func(c *Config) { c.Log.Level = "warn" }}.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(conf.Listen.DNS, Addresses{":5353"}) ||
+				!slices.Equal(conf.Listen.HTTP, Addresses{":9001", "unix:/run/dns-server.sock"}) ||
+				conf.Log.Level != "warn" {
+				t.Fatalf("unexpected precedence of the sources: %+v", conf)
+			}
+			if time.Duration(conf.Stats.Retention) != 48*time.Hour || time.Duration(conf.QueryLog.Retention) != 7*24*time.Hour {
+				t.Fatalf("unexpected retentions %v and %v", conf.Stats.Retention, conf.QueryLog.Retention)
+			}
+			if limit := conf.DNS.RateLimit.config(); limit.ResponsesPerSecond != 10 || limit.Slip != 0 || limit.Window == 0 {
+				t.Fatalf("unexpected rate limit %+v", limit)
+			}
+			if rules, _ := conf.aclRules(); len(rules) != 1 || !rules[0].AllowQuery {
+				t.Fatalf("unexpected ACL rules %+v", rules)
+			}
+			if limit := conf.DNS.ACL[0].RateLimit; limit == nil || limit.config().ResponsesPerSecond != 50 {
+				t.Fatalf("unexpected rate limit of the ACL rule %+v", limit)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name, data, err string
+	}{
+		{"unknown.yaml", "listen:\n  dsn: \":53\"\n", "dsn"},
+		{"unknown.toml", "[listen]\ndsn = \":53\"\n", "listen.dsn"},
+		{"invalid.yaml", "listen:\n  dns: \"53\"\nlog:\n  level: loud\n", "listen.dns"},
+		{"unix.yaml", "listen:\n  dns: [\"unix:/run/dns.sock\"]\n", "listen.dns"},
+		{"invalid.toml", "[[dns.acl]]\nnetwork = \"10.0.0.0\"\n", "dns.acl[0]"},
+		{"limit.yaml", "dns:\n  acl:\n    - network: 10.0.0.0/8\n      rate_limit:\n        slip: -1\n", "dns.acl[0].rate_limit"},
+		{"config.json", "{}", "unknown format"},
+	}
+	for _, tt := range tests {
+		_, err := Loader{Path: writeFile(t, tt.name, tt.data)}.Load()
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.err, err)
+		}
+	}
+}
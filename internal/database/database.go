@@ -1,23 +1,100 @@
 package database
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
 
 // Repository interface represent database.
 type Repository interface {
+	// Ping check that the database is reachable.
+	Ping(ctx context.Context) error
+	// Close close all connections with the database.
+	Close()
 	// AddRecord add resource record to the database and return this resource record with inserted ID.
+	// *ValidationError is returned if the record is invalid.
 	AddRecord(ctx context.Context, rr ResourceRecord) (int32, error)
-	// GetAllRecords return all resource records that database contain.
+	// GetAllRecords return all resource records that database contain in the zones allowed by WithZones.
 	GetAllRecords(ctx context.Context) ([]ResourceRecord, error)
+	// SearchRecords return one page of resource records that match the filter
+	// and cursor for the next page. Cursor is empty if there is no more pages.
+	// Only records in the zones allowed by WithZones are found.
+	SearchRecords(ctx context.Context, filter RecordFilter) ([]ResourceRecord, string, error)
 	// GetRecord return one resource record with provided ID.
+	// pgx.ErrNoRows is returned if the record is out of the zones allowed by WithZones.
 	GetRecord(ctx context.Context, id int32) (ResourceRecord, error)
 	// FindRecords find the resource record based on the provided domain name and type.
 	FindRecords(ctx context.Context, name, rrType string) ([]ResourceRecord, error)
 	// UpdateRecord update the resource record.
 	// Provided resource record contain ID of the record that need to be updated
 	// and other fields contains the new data.
+	// *ValidationError is returned if the record is invalid.
 	UpdateRecord(ctx context.Context, rr ResourceRecord) error
 	// DeleteRecord delete resource record with provided ID.
+	// pgx.ErrNoRows is returned if the record doesn't exist.
 	DeleteRecord(ctx context.Context, id int32) error
+	// ApplyRecordBatch apply all operations in one transaction and return result of every operation.
+	// If any operation failed or dryRun is set, none of the operations is applied.
+	// ErrBatchFailed is returned when some of the operations failed.
+	ApplyRecordBatch(ctx context.Context, ops []RecordOperation, dryRun bool) ([]RecordOperationResult, error)
+	// GetRecordHistory return all changes of the resource record with provided ID, newest first.
+	// Changes of the record out of the zones allowed by WithZones are skipped.
+	GetRecordHistory(ctx context.Context, id int32) ([]RecordChange, error)
+	// GetZoneHistory return last changes of the resource records in the zone, newest first.
+	GetZoneHistory(ctx context.Context, zone string, limit int32) ([]RecordChange, error)
+	// RevertChange revert one change of the resource record with provided ID of the change.
+	// Return the reverted change, its Before is the restored state of the record.
+	RevertChange(ctx context.Context, id int32) (RecordChange, error)
+	// RollbackZone return all resource records of the zone to the state they had at provided time.
+	// Return the reverted changes, their Before are the restored states of the records.
+	RollbackZone(ctx context.Context, zone string, t time.Time) ([]RecordChange, error)
+	// GetAllViews return all views with their networks, zones and resource records.
+	GetAllViews(ctx context.Context) ([]View, error)
+	// GetView return view with provided ID.
+	GetView(ctx context.Context, id int32) (View, error)
+	// AddView add view to the database and return its ID.
+	// *ValidationError is returned if the view is invalid.
+	AddView(ctx context.Context, view View) (int32, error)
+	// UpdateView replace name, networks, zones and resource records of the view with provided ID.
+	// *ValidationError is returned if the view is invalid.
+	UpdateView(ctx context.Context, view View) error
+	// DeleteView delete view with provided ID.
+	DeleteView(ctx context.Context, id int32) error
+	// GetACLRules return all rules of the access control list.
+	GetACLRules(ctx context.Context) ([]ACLRule, error)
+	// AddACLRule add rule to the access control list and return its ID.
+	// *ValidationError is returned if the rule is invalid.
+	AddACLRule(ctx context.Context, rule ACLRule) (int32, error)
+	// UpdateACLRule replace rule of the access control list with provided ID.
+	// *ValidationError is returned if the rule is invalid.
+	UpdateACLRule(ctx context.Context, rule ACLRule) error
+	// DeleteACLRule delete rule of the access control list with provided ID.
+	DeleteACLRule(ctx context.Context, id int32) error
+	// AddQueryStats add counts of the DNS queries to the statistics.
+	AddQueryStats(ctx context.Context, stats []QueryStat) error
+	// GetTopClients return clients that made most of the queries.
+	GetTopClients(ctx context.Context, filter TopFilter) ([]TopItem, error)
+	// GetTopDomains return most queried domains.
+	GetTopDomains(ctx context.Context, filter TopFilter) ([]TopItem, error)
+	// GetTopBlocked return domains with most of the blocked queries.
+	GetTopBlocked(ctx context.Context, filter TopFilter) ([]TopItem, error)
+	// GetQueryVolume return count of the queries made since provided time in intervals of step length.
+	GetQueryVolume(ctx context.Context, since time.Time, step time.Duration) ([]VolumePoint, error)
+	// DeleteQueryStatsBefore delete statistics of the queries made before provided time
+	// and return count of deleted rows.
+	DeleteQueryStatsBefore(ctx context.Context, t time.Time) (int64, error)
+	// AddQueryLog add entries to the query log.
+	AddQueryLog(ctx context.Context, entries []QueryLogEntry) error
+	// SearchQueryLog return one page of the query log entries that match the filter,
+	// newest first, and cursor for the next page. Cursor is empty if there is no more pages.
+	SearchQueryLog(ctx context.Context, filter QueryLogFilter) ([]QueryLogEntry, string, error)
+	// DeleteQueryLogBefore delete entries of the query log made before provided time
+	// and return count of deleted entries.
+	DeleteQueryLogBefore(ctx context.Context, t time.Time) (int64, error)
 	// GetAllUsers return all users from database.
 	GetAllUsers(ctx context.Context) ([]User, error)
 	// GetUser return user with provided login.
@@ -26,26 +103,387 @@ type Repository interface {
 	CheckUserPassword(ctx context.Context, login, pass string) (User, error)
 	// AddUser add user to the database. Return this user with seted ID.
 	AddUser(ctx context.Context, user User, password string) (int32, error)
+	// CountUsers return number of the users.
+	CountUsers(ctx context.Context) (int64, error)
+	// RegisterUser add user invited by the invitation with provided hash of the token and return
+	// its ID. Role and zones are taken from the invitation, ErrInvalidInvitation is returned
+	// if the invitation doesn't exist, is used or expired.
+	RegisterUser(ctx context.Context, user User, password string, invitation []byte) (int32, error)
+	// SetUserPassword change password of the user with provided ID. If mustChange is set,
+	// user has to change the password on the next login.
+	SetUserPassword(ctx context.Context, id int32, password string, mustChange bool) error
+	// GetUserBySubject return user provisioned by the OpenID Connect login with provided subject.
+	GetUserBySubject(ctx context.Context, subject string) (User, error)
+	// AddOIDCUser add user provisioned by the OpenID Connect login and return its ID.
+	// User can't log in with the password.
+	AddOIDCUser(ctx context.Context, user User, subject string) (int32, error)
+	// SetUserRole change role of the user with provided ID.
+	SetUserRole(ctx context.Context, id int32, role string) error
 	// DeleteUser delete user with provided ID.
 	DeleteUser(ctx context.Context, id int32) error
 	// UpdateUser update user with provided ID and values from the struct.
 	UpdateUser(ctx context.Context, user User, password string) error
+	// GetRoles return all roles with their permissions.
+	GetRoles(ctx context.Context) ([]Role, error)
+	// GetRole return role with provided ID.
+	GetRole(ctx context.Context, id int32) (Role, error)
+	// AddRole add role to the database and return its ID.
+	// *ValidationError is returned if the role is invalid.
+	AddRole(ctx context.Context, role Role) (int32, error)
+	// UpdateRole replace name and permissions of the role with provided ID.
+	// *ValidationError is returned if the role is invalid.
+	UpdateRole(ctx context.Context, role Role) error
+	// DeleteRole delete role with provided ID. Roles of users and API keys can't be deleted.
+	DeleteRole(ctx context.Context, id int32) error
+	// AddAPIKey add API key with provided hash of the key and return its ID and creation time.
+	// *ValidationError is returned if the key is invalid.
+	AddAPIKey(ctx context.Context, key APIKey, hash []byte) (APIKey, error)
+	// GetAPIKeys return all API keys including revoked and expired ones.
+	GetAPIKeys(ctx context.Context) ([]APIKey, error)
+	// GetAPIKeyByHash return API key with provided hash of the key.
+	GetAPIKeyByHash(ctx context.Context, hash []byte) (APIKey, error)
+	// RevokeAPIKey revoke API key with provided ID, pgx.ErrNoRows is returned
+	// if the key doesn't exist or is already revoked.
+	RevokeAPIKey(ctx context.Context, id int32) error
+	// TouchAPIKey set time of the last use of the API key.
+	TouchAPIKey(ctx context.Context, id int32, t time.Time) error
+	// AddInvitation add invitation with provided hash of the token and return its ID and creation time.
+	// *ValidationError is returned if the invitation is invalid.
+	AddInvitation(ctx context.Context, invitation Invitation, hash []byte) (Invitation, error)
+	// GetInvitations return all invitations including used and expired ones.
+	GetInvitations(ctx context.Context) ([]Invitation, error)
+	// DeleteInvitation delete invitation with provided ID.
+	DeleteInvitation(ctx context.Context, id int32) error
+	// AddRefreshToken add refresh token of the user with provided hash of the token.
+	AddRefreshToken(ctx context.Context, userID int32, hash []byte, expiresAt time.Time) error
+	// GetRefreshToken return refresh token with provided hash of the token.
+	GetRefreshToken(ctx context.Context, hash []byte) (RefreshToken, error)
+	// RevokeRefreshToken revoke refresh token with provided ID, pgx.ErrNoRows is returned
+	// if the token doesn't exist or is already revoked.
+	RevokeRefreshToken(ctx context.Context, id int32) error
+	// RevokeUserRefreshTokens revoke all refresh tokens of the user with provided ID.
+	RevokeUserRefreshTokens(ctx context.Context, userID int32) error
+	// RevokeToken add ID of the access token to the revocation list until the token expire.
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	// IsTokenRevoked report whether the access token with provided ID is revoked.
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	// DeleteExpiredTokens delete refresh tokens and entries of the revocation list
+	// that expired before provided time and return count of deleted rows.
+	DeleteExpiredTokens(ctx context.Context, t time.Time) (int64, error)
+	// Listen call handler for every change made in the database by any server instance.
+	// ready is called once the changes are listened, changes made while it runs are passed
+	// to the handler after it, its error is returned. Block until context is canceled
+	// or connection is lost.
+	Listen(ctx context.Context, ready func() error, handler func(Change)) error
+}
+
+// Tables that publish their changes.
+const (
+	TableResourceRecords = "resource_records"
+	// TableViews changes are published also when resource records
+	// are assigned to the view or removed from it.
+	TableViews    = "views"
+	TableACLRules = "acl_rules"
+)
+
+// Operations that can be made with the row.
+const (
+	OperationInsert = "INSERT"
+	OperationUpdate = "UPDATE"
+	OperationDelete = "DELETE"
+)
+
+// Change represent change of one row in the database.
+type Change struct {
+	// Table where the row was changed.
+	Table string `json:"table"`
+	// Operation that was made with the row(INSERT, UPDATE or DELETE).
+	Operation string `json:"operation"`
+	// ID of the changed row.
+	ID int32 `json:"id"`
 }
 
 // ResourceRecord structure represent resource record in the dabase.
 type ResourceRecord struct {
 	// ID of the resource record in the database.
-	ID int32
+	ID int32 `json:"id"`
 	// Domain that this resource record contain.
-	Domain string
+	Domain string `json:"domain"`
 	// Data that resource record contain.
-	Data string
+	Data string `json:"data"`
 	// Type of the resource record.
-	Type string
+	Type string `json:"type"`
 	// Class of the resource record.
-	Class string
+	Class string `json:"class"`
 	// Time To Live of the resource record.
-	TTL int32
+	TTL int32 `json:"ttl"`
+}
+
+// ErrInvalidCursor returned when cursor of the page can't be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrCursorMismatch returned when cursor of the page was returned with other sorting.
+var ErrCursorMismatch = fmt.Errorf("%w: sort field or order doesn't match", ErrInvalidCursor)
+
+// RecordFilter contain conditions for searching of the resource records.
+// Empty fields are not used as conditions.
+type RecordFilter struct {
+	// Domain of the record. Leading '*' search domains with provided suffix,
+	// trailing '*' search domains with provided prefix and both of them search
+	// domains that contain provided substring.
+	Domain string
+	// Type of the record.
+	Type string
+	// Class of the record.
+	Class string
+	// Data is substring of the record data.
+	Data string
+	// Zone is the domain which records and records of its subdomains are searched.
+	Zone string
+	// Query is substring that searched in domain and data of the record.
+	Query string
+	// SortBy is the field records sorted by(id, domain, type, class, data or ttl).
+	SortBy string
+	// Descending reverse order of the sorting.
+	Descending bool
+	// Cursor returned with the previous page.
+	Cursor string
+	// Limit of records on the page.
+	Limit int32
+}
+
+// ErrBatchFailed returned when some operations of the batch failed and batch was rolled back.
+var ErrBatchFailed = errors.New("batch operation failed")
+
+// Actions of the operation in the batch.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// RecordOperation is one operation with resource record in the batch.
+type RecordOperation struct {
+	// Action that need to be done(create, update or delete).
+	Action string
+	// Record to create or update. Only ID is used for delete.
+	Record ResourceRecord
+}
+
+// RecordOperationResult is result of one operation in the batch.
+type RecordOperationResult struct {
+	// ID of the created, updated or deleted record.
+	ID int32
+	// Err is not nil if the operation failed.
+	Err error
+}
+
+// RecordChange is one change of the resource record from the history.
+type RecordChange struct {
+	// ID of the change.
+	ID int32
+	// RecordID is ID of the changed resource record.
+	RecordID int32
+	// Operation that changed resource record(INSERT, UPDATE or DELETE).
+	Operation string
+	// Actor is login of the user that made the change.
+	Actor string
+	// Time when the change was made.
+	Time time.Time
+	// Before is state of the record before the change, nil for inserted records.
+	Before *ResourceRecord
+	// After is state of the record after the change, nil for deleted records.
+	After *ResourceRecord
+}
+
+// SystemActor is actor of the changes that made not by the users.
+const SystemActor = "system"
+
+type actorKey struct{}
+
+// WithActor return context with login of the user that make changes.
+func WithActor(ctx context.Context, login string) context.Context {
+	return context.WithValue(ctx, actorKey{}, login)
+}
+
+// ActorFromContext return login of the user that make changes or SystemActor.
+func ActorFromContext(ctx context.Context) string {
+	if login, ok := ctx.Value(actorKey{}).(string); ok && login != "" {
+		return login
+	}
+	return SystemActor
+}
+
+type zonesKey struct{}
+
+// ErrZoneNotAllowed is returned when resource record out of the zones allowed by WithZones is changed.
+var ErrZoneNotAllowed = errors.New("zone is not allowed")
+
+// WithZones return context that allow changes only of the resource records in provided zones.
+// Empty list allow all zones.
+func WithZones(ctx context.Context, zones []string) context.Context {
+	return context.WithValue(ctx, zonesKey{}, zones)
+}
+
+// ZonesFromContext return zones allowed by WithZones, nil if all zones are allowed.
+func ZonesFromContext(ctx context.Context) []string {
+	zones, _ := ctx.Value(zonesKey{}).([]string)
+	return zones
+}
+
+// InZones report whether the domain is in any of the zones, empty list contain all domains.
+// Domain and zones are lower case FQDN.
+func InZones(domain string, zones []string) bool {
+	if len(zones) == 0 {
+		return true
+	}
+	for _, zone := range zones {
+		if zone == "." || domain == zone || strings.HasSuffix(domain, "."+zone) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkZone return ErrZoneNotAllowed if the domain is not in the zones allowed by the context.
+func checkZone(ctx context.Context, domain string) error {
+	if !InZones(domain, ZonesFromContext(ctx)) {
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, domain)
+	}
+	return nil
+}
+
+// View is a named set of answers for the clients from its networks.
+// Resource records assigned to the view directly or through the zone
+// are answered only to the clients of the view.
+type View struct {
+	// ID of the view in the database.
+	ID int32
+	// Name of the view.
+	Name string
+	// Networks of the clients that receive answers of the view.
+	Networks []netip.Prefix
+	// Zones which resource records are answered only in the view.
+	Zones []string
+	// Records is IDs of the resource records that are answered only in the view.
+	Records []int32
+}
+
+// ACLRule allow actions for the clients from the network.
+type ACLRule struct {
+	// ID of the rule in the database, zero for rules that are not stored in it.
+	ID int32
+	// Network of the clients.
+	Network netip.Prefix
+	// AllowQuery allow queries for the records of the server.
+	AllowQuery bool
+	// AllowRecursion is stored for the clients that need recursion, the server
+	// doesn't recurse and doesn't check it.
+	AllowRecursion bool
+	// AllowTransfer allow zone transfers.
+	AllowTransfer bool
+	// AllowUpdate allow dynamic updates.
+	AllowUpdate bool
+}
+
+// QueryStat is count of the same DNS queries made in one minute.
+type QueryStat struct {
+	// Time is the start of the minute.
+	Time time.Time
+	// Client is address of the client that made queries.
+	Client netip.Addr
+	// Domain from the question.
+	Domain string
+	// Type from the question.
+	Type string
+	// Rcode of the response.
+	Rcode string
+	// Blocked is set when queries were refused by the server policy.
+	Blocked bool
+	// Count of the queries.
+	Count int64
+}
+
+// TopFilter contain conditions for top of the query statistics.
+// Empty fields are not used as conditions.
+type TopFilter struct {
+	// Since is the time from which queries are counted.
+	Since time.Time
+	// Type of the queries.
+	Type string
+	// Rcode of the responses.
+	Rcode string
+	// Limit of items in the top.
+	Limit int32
+}
+
+// TopItem is one item of the top with count of its queries.
+type TopItem struct {
+	// Key is the client address or the domain.
+	Key string
+	// Count of the queries.
+	Count int64
+}
+
+// VolumePoint is count of the queries made in one interval.
+type VolumePoint struct {
+	// Time is the start of the interval.
+	Time time.Time
+	// Total count of the queries.
+	Total int64
+	// Blocked count of the queries.
+	Blocked int64
+}
+
+// QueryLogEntry is one answered DNS query in the query log.
+type QueryLogEntry struct {
+	// ID of the entry in the database.
+	ID int64
+	// Time when the query was received.
+	Time time.Time
+	// Client is address of the client that sent the query.
+	Client netip.Addr
+	// Protocol of the query(udp or tcp).
+	Protocol string
+	// Name from the question.
+	Name string
+	// Type from the question.
+	Type string
+	// Rcode of the response.
+	Rcode string
+	// Answer is short summary of the answer records.
+	Answer string
+	// Upstream is address of the upstream server that answered the query, empty for local answers.
+	Upstream string
+	// Latency of the answering.
+	Latency time.Duration
+	// CacheHit is set when the answer was taken from the cache.
+	CacheHit bool
+	// BlockReason is set when the query was refused by the server policy.
+	BlockReason string
+}
+
+// QueryLogFilter contain conditions for searching in the query log.
+// Empty fields are not used as conditions.
+type QueryLogFilter struct {
+	// Network of the clients.
+	Network netip.Prefix
+	// Domain which queries and queries of its subdomains are searched.
+	Domain string
+	// Type of the queries.
+	Type string
+	// Rcode of the responses.
+	Rcode string
+	// Blocked select only blocked or only not blocked queries.
+	Blocked *bool
+	// Since is the time from which queries are searched.
+	Since time.Time
+	// Until is the time before which queries are searched.
+	Until time.Time
+	// Cursor returned with the previous page.
+	Cursor string
+	// Limit of entries on the page.
+	Limit int32
 }
 
 // User represent any people in database.
@@ -60,4 +498,99 @@ type User struct {
 	LastName string
 	// Role of the user(admin, user, etc.).
 	Role string
+	// Zones restrict changes of the resource records to these zones, empty list allow all zones.
+	Zones []string
+	// MustChangePassword is set for temporary passwords, user has to change the password
+	// before other requests.
+	MustChangePassword bool
+	// Permissions of the role, they are set only by GetUser.
+	Permissions []string
+}
+
+// Permissions that can be granted to the roles.
+const (
+	// PermRecordsRead allow to read resource records and their history.
+	PermRecordsRead = "records:read"
+	// PermRecordsWrite allow to add, change, delete and revert resource records.
+	PermRecordsWrite = "records:write"
+	// PermZonesAdmin allow to roll back zones and to manage views.
+	PermZonesAdmin = "zones:admin"
+	// PermUsersAdmin allow to manage users, roles and API keys.
+	PermUsersAdmin = "users:admin"
+	// PermLogsRead allow to read application log, query log and statistics.
+	PermLogsRead = "logs:read"
+	// PermSettingsAdmin allow to manage ACL rules and to reload configuration.
+	PermSettingsAdmin = "settings:admin"
+)
+
+// Permissions is the list of all known permissions.
+var Permissions = []string{
+	PermRecordsRead, PermRecordsWrite, PermZonesAdmin, PermUsersAdmin, PermLogsRead, PermSettingsAdmin,
+}
+
+// ErrRoleInUse is returned when the role assigned to users or API keys is deleted.
+var ErrRoleInUse = errors.New("role is assigned to users or API keys")
+
+// Role is the named set of permissions assigned to users and API keys.
+type Role struct {
+	ID          int32
+	Name        string
+	Permissions []string
+}
+
+// APIKey is the long-lived credential of the script or the service account.
+type APIKey struct {
+	// ID of the key in the database.
+	ID int32
+	// Name describe who use the key.
+	Name string
+	// Prefix is the beginning of the key, it identify the key in lists.
+	Prefix string
+	// Role of the key, it has the same rights as users with this role.
+	Role string
+	// Zones restrict changes of the resource records to these zones, empty list allow all zones.
+	Zones []string
+	// CreatedBy is the login of the user that created the key.
+	CreatedBy string
+	CreatedAt time.Time
+	// ExpiresAt is zero for keys that don't expire.
+	ExpiresAt time.Time
+	// LastUsedAt is zero for keys that are not used yet.
+	LastUsedAt time.Time
+	// RevokedAt is zero for keys that are not revoked.
+	RevokedAt time.Time
+	// Permissions of the role, they are set only by GetAPIKeyByHash.
+	Permissions []string
+}
+
+// ErrInvalidInvitation is returned when the invitation doesn't exist, is used or expired.
+var ErrInvalidInvitation = errors.New("invitation is invalid or expired")
+
+// Invitation allow self-registration of one user with the preset role.
+type Invitation struct {
+	// ID of the invitation in the database.
+	ID int32
+	// Role of the registered user.
+	Role string
+	// Zones restrict changes of the registered user to these zones, empty list allow all zones.
+	Zones []string
+	// CreatedBy is the login of the user that created the invitation.
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// UsedBy is the login of the registered user, empty for unused invitations.
+	UsedBy string
+	// UsedAt is zero for unused invitations.
+	UsedAt time.Time
+}
+
+// RefreshToken issue new access tokens of the user.
+type RefreshToken struct {
+	ID     int32
+	UserID int32
+	// Login of the user that own the token.
+	Login     string
+	ExpiresAt time.Time
+	// RevokedAt is zero for tokens that are not revoked.
+	RevokedAt time.Time
 }
@@ -1,12 +1,25 @@
 package database
 
 import (
+	"cmp"
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/miekg/dns"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/prionis/dns-server/internal/database/sqlc"
@@ -14,25 +27,71 @@ import (
 
 // Postgres struct represent connection to the PostgreSQL database.
 type Postgres struct {
-	db *sqlc.Queries
+	db   *sqlc.Queries
+	pool *pgxpool.Pool
 }
 
-// NewPostgres create new connection to the PostgreSQL database.
-func NewPostgres(connString string) (Postgres, error) {
+// NewPostgres create new connection pool to the PostgreSQL database.
+// Provided tracers are called for every query of the pool.
+func NewPostgres(connString string, tracers ...pgx.QueryTracer) (Postgres, error) {
 	if connString == "" {
 		connString = GetConnectionString()
 	}
-	conn, err := pgx.Connect(context.Background(), connString)
+	config, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return Postgres{}, fmt.Errorf("can't parse connection string: %w", err)
+	}
+	if len(tracers) != 0 {
+		config.ConnConfig.Tracer = queryTracers(tracers)
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
 		return Postgres{}, fmt.Errorf("can't connect to  %w", err)
 	}
-	if err = conn.Ping(context.Background()); err != nil {
+	if err = pool.Ping(context.Background()); err != nil {
 		return Postgres{}, fmt.Errorf("can't ping the database: %w", err)
 	}
 
-	db := sqlc.New(conn)
+	db := sqlc.New(pool)
+
+	return Postgres{db: db, pool: pool}, nil
+}
+
+// queryTracers call all tracers for every query.
+type queryTracers []pgx.QueryTracer
+
+func (ts queryTracers) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	for _, t := range ts {
+		ctx = t.TraceQueryStart(ctx, conn, data)
+	}
+	return ctx
+}
+
+func (ts queryTracers) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	for _, t := range ts {
+		t.TraceQueryEnd(ctx, conn, data)
+	}
+}
+
+// Ping check connection with the database.
+func (repo Postgres) Ping(ctx context.Context) error {
+	return repo.pool.Ping(ctx)
+}
+
+// Close close the connection pool, it wait until all acquired connections are released.
+func (repo Postgres) Close() {
+	repo.pool.Close()
+}
 
-	return Postgres{db}, nil
+// QueryName return name of the sqlc query from its "-- name: X :kind" comment,
+// or "other" for queries without the comment.
+func QueryName(sql string) string {
+	sql, ok := strings.CutPrefix(sql, "-- name: ")
+	if !ok {
+		return "other"
+	}
+	name, _, _ := strings.Cut(sql, " ")
+	return name
 }
 
 // GetConnectionString return the formated connection string for connecting to the PostgreSQL.
@@ -45,8 +104,20 @@ func GetConnectionString() string {
 }
 
 // GetRecord return the resource record with provided id.
+// Record out of the zones allowed by the context is not found.
 func (repo Postgres) GetRecord(ctx context.Context, id int32) (ResourceRecord, error) {
-	rr, err := repo.db.GetResourceRecordByID(ctx, id)
+	rr, err := getRecord(ctx, repo.db, id)
+	if err != nil {
+		return ResourceRecord{}, err
+	}
+	if checkZone(ctx, rr.Domain) != nil {
+		return ResourceRecord{}, pgx.ErrNoRows
+	}
+	return rr, nil
+}
+
+func getRecord(ctx context.Context, q *sqlc.Queries, id int32) (ResourceRecord, error) {
+	rr, err := q.GetResourceRecordByID(ctx, id)
 	if err != nil {
 		return ResourceRecord{}, err
 	}
@@ -61,21 +132,41 @@ func (repo Postgres) GetRecord(ctx context.Context, id int32) (ResourceRecord, e
 }
 
 // AddRecord insert record in the database and return this record with ID settled ID.
+// Record is validated and normalized before insert.
 func (repo Postgres) AddRecord(ctx context.Context, rr ResourceRecord) (int32, error) {
-	id, err := repo.db.CreateResourceRecord(ctx, sqlc.CreateResourceRecordParams{
+	var id int32
+	err := repo.inTx(ctx, func(q *sqlc.Queries) error {
+		var err error
+		id, err = addRecord(ctx, q, rr)
+		return err
+	})
+	return id, err
+}
+
+func addRecord(ctx context.Context, q *sqlc.Queries, rr ResourceRecord) (int32, error) {
+	rr, err := NormalizeRecord(rr)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkZone(ctx, rr.Domain); err != nil {
+		return 0, err
+	}
+
+	id, err := q.CreateResourceRecord(ctx, sqlc.CreateResourceRecordParams{
 		Domain:     rr.Domain,
 		Type:       rr.Type,
 		Class:      rr.Class,
 		Data:       rr.Data,
-		TimeToLive: pgtype.Int4{int32(rr.TTL), true},
+		TimeToLive: pgtype.Int4{Int32: rr.TTL, Valid: true},
 	})
 	if err != nil {
 		return 0, err
 	}
-	return id, nil
+	rr.ID = id
+	return id, addHistory(ctx, q, OperationInsert, id, nil, &rr)
 }
 
-// GetAllRecords return all the resource records from the database
+// GetAllRecords return all the resource records from the database in the zones allowed by the context.
 func (repo Postgres) GetAllRecords(ctx context.Context) ([]ResourceRecord, error) {
 	rrs, err := repo.db.GetAllResourceRecord(ctx)
 	if err != nil {
@@ -84,6 +175,9 @@ func (repo Postgres) GetAllRecords(ctx context.Context) ([]ResourceRecord, error
 
 	resourceRecords := make([]ResourceRecord, 0, len(rrs))
 	for _, record := range rrs {
+		if checkZone(ctx, record.Domain) != nil {
+			continue
+		}
 		resourceRecords = append(resourceRecords, ResourceRecord{
 			ID:     record.ID,
 			Domain: record.Domain,
@@ -96,36 +190,315 @@ func (repo Postgres) GetAllRecords(ctx context.Context) ([]ResourceRecord, error
 	return resourceRecords, nil
 }
 
+// recordSortColumns map sort fields of the RecordFilter to the sorted expressions and their types.
+// Expressions of the domain and TTL match the indexes of the resource_records table,
+// so sorting and seeking to the cursor are served by them.
+var recordSortColumns = map[string]struct{ expr, typ string }{
+	"id":     {"resource_records.id", "integer"},
+	"domain": {"lower(resource_records.domain)", "text"},
+	"type":   {"types.type", "text"},
+	"class":  {"classes.class", "text"},
+	"data":   {"resource_records.data", "text"},
+	"ttl":    {"COALESCE(resource_records.time_to_live, 0)", "integer"},
+}
+
+// SearchRecords return page of the resource records that match the filter and cursor for the next page.
+// Query is built here instead of sqlc, because ORDER BY must name the sorted column
+// and only conditions of the filter must be added, so the indexes can be used.
+func (repo Postgres) SearchRecords(ctx context.Context, filter RecordFilter) ([]ResourceRecord, string, error) {
+	sortBy := cmp.Or(filter.SortBy, "id")
+	column, ok := recordSortColumns[sortBy]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown sort field %q", sortBy)
+	}
+	order := "asc"
+	if filter.Descending {
+		order = "desc"
+	}
+
+	var where []string
+	args := pgx.NamedArgs{
+		// One more record is requested to know if there is next page.
+		"page_size": filter.Limit + 1,
+	}
+	add := func(condition, name string, value any) {
+		where = append(where, condition)
+		args[name] = value
+	}
+
+	domain := strings.ToLower(filter.Domain)
+	prefix := strings.HasSuffix(domain, "*")
+	suffix := strings.HasPrefix(domain, "*")
+	domain = strings.TrimSuffix(strings.Trim(domain, "*"), ".")
+	switch {
+	case domain == "":
+	case prefix && suffix:
+		add("lower(resource_records.domain) LIKE @domain_contains",
+			"domain_contains", "%"+escapeLike(domain)+"%")
+	case prefix:
+		add("lower(rtrim(resource_records.domain, '.')) LIKE @domain_prefix",
+			"domain_prefix", escapeLike(domain)+"%")
+	case suffix:
+		add("reverse(lower(rtrim(resource_records.domain, '.'))) LIKE @reversed_suffix",
+			"reversed_suffix", escapeLike(reverse(domain))+"%")
+	default:
+		add("lower(rtrim(resource_records.domain, '.')) = @domain", "domain", domain)
+	}
+	if filter.Type != "" {
+		add("types.type = @type", "type", strings.ToUpper(filter.Type))
+	}
+	if filter.Class != "" {
+		add("classes.class = @class", "class", strings.ToUpper(filter.Class))
+	}
+	if filter.Data != "" {
+		add("resource_records.data ILIKE @data", "data", "%"+escapeLike(filter.Data)+"%")
+	}
+	if zone := normalizeZone(filter.Zone); zone != "" {
+		add(`(lower(rtrim(resource_records.domain, '.')) = @zone
+			OR reverse(lower(rtrim(resource_records.domain, '.'))) LIKE @reversed_zone)`,
+			"zone", zone)
+		args["reversed_zone"] = escapeLike(reverse("."+zone)) + "%"
+	}
+	if filter.Query != "" {
+		add("(lower(resource_records.domain) LIKE @query OR resource_records.data ILIKE @query)",
+			"query", "%"+escapeLike(strings.ToLower(filter.Query))+"%")
+	}
+
+	// Only records of the zones allowed to the user are found.
+	if zones := ZonesFromContext(ctx); len(zones) != 0 && !slices.Contains(zones, ".") {
+		var allowed, reversed []string
+		for _, zone := range zones {
+			zone = strings.TrimSuffix(zone, ".")
+			allowed = append(allowed, zone)
+			reversed = append(reversed, escapeLike(reverse("."+zone))+"%")
+		}
+		add(`(lower(rtrim(resource_records.domain, '.')) = ANY(@allowed_zones)
+			OR reverse(lower(rtrim(resource_records.domain, '.'))) LIKE ANY(@reversed_allowed_zones))`,
+			"allowed_zones", allowed)
+		args["reversed_allowed_zones"] = reversed
+	}
+
+	if filter.Cursor != "" {
+		key, id, err := decodeRecordCursor(filter.Cursor, sortBy, order, column.typ)
+		if err != nil {
+			return nil, "", err
+		}
+		operator := ">"
+		if filter.Descending {
+			operator = "<"
+		}
+		add(fmt.Sprintf("(%s, resource_records.id) %s (@cursor_key::%s, @cursor_id)", column.expr, operator, column.typ),
+			"cursor_key", key)
+		args["cursor_id"] = id
+	}
+
+	query := fmt.Sprintf(`SELECT resource_records.id, resource_records.domain, resource_records.data,
+	resource_records.time_to_live, types.type, classes.class, (%s)::text
+FROM resource_records
+INNER JOIN types ON resource_records.type_id = types.id
+INNER JOIN classes ON resource_records.class_id = classes.id`, column.expr)
+	if len(where) != 0 {
+		query += "\nWHERE " + strings.Join(where, "\n\tAND ")
+	}
+	query += fmt.Sprintf("\nORDER BY %s %s, resource_records.id %s\nLIMIT @page_size", column.expr, order, order)
+
+	rows, err := repo.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var (
+		resourceRecords []ResourceRecord
+		keys            []string
+	)
+	for rows.Next() {
+		var (
+			rr  ResourceRecord
+			ttl pgtype.Int4
+			key string
+		)
+		if err := rows.Scan(&rr.ID, &rr.Domain, &rr.Data, &ttl, &rr.Type, &rr.Class, &key); err != nil {
+			return nil, "", err
+		}
+		rr.TTL = ttl.Int32
+		resourceRecords = append(resourceRecords, rr)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	cursor := ""
+	if len(resourceRecords) > int(filter.Limit) {
+		resourceRecords = resourceRecords[:filter.Limit]
+		last := len(resourceRecords) - 1
+		cursor = encodeRecordCursor(sortBy, order, keys[last], resourceRecords[last].ID)
+	}
+	return resourceRecords, cursor, nil
+}
+
 // UpdateRecord update record with provided ID and values.
+// Record is validated and normalized before update.
 func (repo Postgres) UpdateRecord(ctx context.Context, rr ResourceRecord) error {
-	_, err := repo.db.UpdateResourceRecord(context.Background(), sqlc.UpdateResourceRecordParams{
+	return repo.inTx(ctx, func(q *sqlc.Queries) error {
+		return updateRecord(ctx, q, rr)
+	})
+}
+
+func updateRecord(ctx context.Context, q *sqlc.Queries, rr ResourceRecord) error {
+	rr, err := NormalizeRecord(rr)
+	if err != nil {
+		return err
+	}
+
+	before, err := getRecord(ctx, q, rr.ID)
+	if err != nil {
+		return err
+	}
+	// Record can't be moved in or out of the allowed zones.
+	if err := checkZone(ctx, before.Domain); err != nil {
+		return err
+	}
+	if err := checkZone(ctx, rr.Domain); err != nil {
+		return err
+	}
+
+	_, err = q.UpdateResourceRecord(ctx, sqlc.UpdateResourceRecordParams{
 		ID:         rr.ID,
 		Domain:     rr.Domain,
 		Data:       rr.Data,
 		Type:       rr.Type,
 		Class:      rr.Class,
-		TimeToLive: pgtype.Int4{rr.TTL, true},
+		TimeToLive: pgtype.Int4{Int32: rr.TTL, Valid: true},
 	})
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return addHistory(ctx, q, OperationUpdate, rr.ID, &before, &rr)
 }
 
 // DeleteRecord delete record with provided ID.
+// pgx.ErrNoRows is returned if record with provided ID doesn't exist.
 func (repo Postgres) DeleteRecord(ctx context.Context, id int32) error {
-	err := repo.db.DeleteResourceRecord(ctx, id)
+	return repo.inTx(ctx, func(q *sqlc.Queries) error {
+		return deleteRecord(ctx, q, id)
+	})
+}
+
+func deleteRecord(ctx context.Context, q *sqlc.Queries, id int32) error {
+	before, err := getRecord(ctx, q, id)
 	if err != nil {
 		return err
 	}
+	if err := checkZone(ctx, before.Domain); err != nil {
+		return err
+	}
 
-	return nil
+	err = q.DeleteResourceRecord(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return addHistory(ctx, q, OperationDelete, id, &before, nil)
+}
+
+// restoreRecord set state of the resource record with provided ID.
+// Record is deleted if state is nil and created again if it was deleted.
+func restoreRecord(ctx context.Context, q *sqlc.Queries, id int32, state *ResourceRecord) error {
+	if state == nil {
+		// Record may be already deleted after the change.
+		if err := deleteRecord(ctx, q, id); !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		return nil
+	}
+
+	rr := *state
+	rr.ID = id
+	_, err := getRecord(ctx, q, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if err := checkZone(ctx, rr.Domain); err != nil {
+			return err
+		}
+		_, err = q.CreateResourceRecordWithID(ctx, sqlc.CreateResourceRecordWithIDParams{
+			ID:         id,
+			Domain:     rr.Domain,
+			Type:       rr.Type,
+			Class:      rr.Class,
+			Data:       rr.Data,
+			TimeToLive: pgtype.Int4{Int32: rr.TTL, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return addHistory(ctx, q, OperationInsert, id, nil, &rr)
+	}
+	if err != nil {
+		return err
+	}
+	return updateRecord(ctx, q, rr)
+}
+
+// ApplyRecordBatch apply operations with resource records in one transaction.
+// Every operation run in its own savepoint, so failed operation don't stop
+// checking of the next ones and result of every operation is returned.
+func (repo Postgres) ApplyRecordBatch(ctx context.Context, ops []RecordOperation, dryRun bool) ([]RecordOperationResult, error) {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	results := make([]RecordOperationResult, 0, len(ops))
+	failed := false
+	for _, op := range ops {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("can't create savepoint: %w", err)
+		}
+
+		result := RecordOperationResult{ID: op.Record.ID}
+		q := repo.db.WithTx(savepoint)
+		switch op.Action {
+		case ActionCreate:
+			result.ID, result.Err = addRecord(ctx, q, op.Record)
+		case ActionUpdate:
+			result.Err = updateRecord(ctx, q, op.Record)
+		case ActionDelete:
+			result.Err = deleteRecord(ctx, q, op.Record.ID)
+		default:
+			result.Err = fmt.Errorf("unknown action %q", op.Action)
+		}
+
+		if result.Err != nil {
+			failed = true
+			err = savepoint.Rollback(ctx)
+		} else {
+			err = savepoint.Commit(ctx)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can't release savepoint: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if failed {
+		return results, ErrBatchFailed
+	}
+	if dryRun {
+		return results, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("can't commit transaction: %w", err)
+	}
+	return results, nil
 }
 
 // FindRecords return resource records with provided domain name and type.
 func (repo Postgres) FindRecords(ctx context.Context, name, rrType string) ([]ResourceRecord, error) {
-	rrs, err := repo.db.GetResourceRecords(ctx, sqlc.GetResourceRecordsParams{name, rrType})
+	rrs, err := repo.db.GetResourceRecords(ctx, sqlc.GetResourceRecordsParams{Domain: name, Type: rrType})
 	if err != nil {
 		return nil, err
 	}
@@ -143,6 +516,411 @@ func (repo Postgres) FindRecords(ctx context.Context, name, rrType string) ([]Re
 	return resourceRecords, nil
 }
 
+// GetRecordHistory return all changes of the resource record with provided ID.
+func (repo Postgres) GetRecordHistory(ctx context.Context, id int32) ([]RecordChange, error) {
+	rows, err := repo.db.GetRecordHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := toRecordChanges(rows)
+	if err != nil {
+		return nil, err
+	}
+	// Changes are hidden if the record was out of the allowed zones before and after them.
+	return slices.DeleteFunc(changes, func(change RecordChange) bool {
+		return (change.Before == nil || checkZone(ctx, change.Before.Domain) != nil) &&
+			(change.After == nil || checkZone(ctx, change.After.Domain) != nil)
+	}), nil
+}
+
+// GetZoneHistory return last changes of the resource records in the zone.
+func (repo Postgres) GetZoneHistory(ctx context.Context, zone string, limit int32) ([]RecordChange, error) {
+	rows, err := repo.db.GetZoneHistory(ctx, sqlc.GetZoneHistoryParams{
+		Zone:     normalizeZone(zone),
+		PageSize: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toRecordChanges(rows)
+}
+
+// RevertChange return resource record to the state before the change with provided ID
+// and return the reverted change. Revert is saved in the history as a new change.
+func (repo Postgres) RevertChange(ctx context.Context, id int32) (RecordChange, error) {
+	var change RecordChange
+	err := repo.inTx(ctx, func(q *sqlc.Queries) error {
+		row, err := q.GetRecordHistoryEntry(ctx, id)
+		if err != nil {
+			return err
+		}
+		change, err = toRecordChange(row)
+		if err != nil {
+			return err
+		}
+		return restoreRecord(ctx, q, change.RecordID, change.Before)
+	})
+	return change, err
+}
+
+// RollbackZone return resource records of the zone to the state they had at provided time
+// and return the reverted changes. State of every record is taken from the first change made after that time.
+func (repo Postgres) RollbackZone(ctx context.Context, zone string, t time.Time) ([]RecordChange, error) {
+	var changes []RecordChange
+	err := repo.inTx(ctx, func(q *sqlc.Queries) error {
+		rows, err := q.GetFirstZoneChangesSince(ctx, sqlc.GetFirstZoneChangesSinceParams{
+			Since: pgtype.Timestamptz{Time: t, Valid: true},
+			Zone:  normalizeZone(zone),
+		})
+		if err != nil {
+			return err
+		}
+
+		changes, err = toRecordChanges(rows)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if err := restoreRecord(ctx, q, change.RecordID, change.Before); err != nil {
+				return fmt.Errorf("can't restore resource record %d: %w", change.RecordID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// GetAllViews return all views with their networks, zones and resource records.
+func (repo Postgres) GetAllViews(ctx context.Context) ([]View, error) {
+	rows, err := repo.db.GetAllViews(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]View, 0, len(rows))
+	for _, row := range rows {
+		views = append(views, View{
+			ID:       row.ID,
+			Name:     row.Name,
+			Networks: row.Networks,
+			Zones:    row.Zones,
+			Records:  row.RecordIds,
+		})
+	}
+	return views, nil
+}
+
+// GetView return view with provided ID.
+func (repo Postgres) GetView(ctx context.Context, id int32) (View, error) {
+	row, err := repo.db.GetView(ctx, id)
+	if err != nil {
+		return View{}, err
+	}
+	return View{
+		ID:       row.ID,
+		Name:     row.Name,
+		Networks: row.Networks,
+		Zones:    row.Zones,
+		Records:  row.RecordIds,
+	}, nil
+}
+
+// AddView insert view with its resource records in the database and return ID of the view.
+func (repo Postgres) AddView(ctx context.Context, view View) (int32, error) {
+	view, err := NormalizeView(view)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int32
+	err = repo.inTx(ctx, func(q *sqlc.Queries) error {
+		var err error
+		id, err = q.CreateView(ctx, sqlc.CreateViewParams{
+			Name:     view.Name,
+			Networks: view.Networks,
+			Zones:    view.Zones,
+		})
+		if err != nil {
+			return err
+		}
+		if len(view.Records) == 0 {
+			return nil
+		}
+		return q.AddViewRecords(ctx, sqlc.AddViewRecordsParams{RecordIds: view.Records, ViewID: id})
+	})
+	return id, err
+}
+
+// UpdateView replace the view and its resource records.
+// pgx.ErrNoRows is returned if view with provided ID doesn't exist.
+func (repo Postgres) UpdateView(ctx context.Context, view View) error {
+	view, err := NormalizeView(view)
+	if err != nil {
+		return err
+	}
+
+	return repo.inTx(ctx, func(q *sqlc.Queries) error {
+		_, err := q.UpdateView(ctx, sqlc.UpdateViewParams{
+			ID:       view.ID,
+			Name:     view.Name,
+			Networks: view.Networks,
+			Zones:    view.Zones,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.DeleteViewRecords(ctx, view.ID); err != nil {
+			return err
+		}
+		if len(view.Records) == 0 {
+			return nil
+		}
+		return q.AddViewRecords(ctx, sqlc.AddViewRecordsParams{RecordIds: view.Records, ViewID: view.ID})
+	})
+}
+
+// DeleteView delete view with provided ID, its resource records stay in the database.
+func (repo Postgres) DeleteView(ctx context.Context, id int32) error {
+	return repo.db.DeleteView(ctx, id)
+}
+
+// GetACLRules return all rules of the access control list.
+func (repo Postgres) GetACLRules(ctx context.Context) ([]ACLRule, error) {
+	rows, err := repo.db.GetACLRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]ACLRule, 0, len(rows))
+	for _, row := range rows {
+		rules = append(rules, ACLRule{
+			ID:             row.ID,
+			Network:        row.Network,
+			AllowQuery:     row.AllowQuery,
+			AllowRecursion: row.AllowRecursion,
+			AllowTransfer:  row.AllowTransfer,
+			AllowUpdate:    row.AllowUpdate,
+		})
+	}
+	return rules, nil
+}
+
+// AddACLRule insert rule of the access control list and return its ID.
+func (repo Postgres) AddACLRule(ctx context.Context, rule ACLRule) (int32, error) {
+	rule, err := NormalizeACLRule(rule)
+	if err != nil {
+		return 0, err
+	}
+	return repo.db.CreateACLRule(ctx, sqlc.CreateACLRuleParams{
+		Network:        rule.Network,
+		AllowQuery:     rule.AllowQuery,
+		AllowRecursion: rule.AllowRecursion,
+		AllowTransfer:  rule.AllowTransfer,
+		AllowUpdate:    rule.AllowUpdate,
+	})
+}
+
+// UpdateACLRule replace rule of the access control list.
+// pgx.ErrNoRows is returned if rule with provided ID doesn't exist.
+func (repo Postgres) UpdateACLRule(ctx context.Context, rule ACLRule) error {
+	rule, err := NormalizeACLRule(rule)
+	if err != nil {
+		return err
+	}
+	_, err = repo.db.UpdateACLRule(ctx, sqlc.UpdateACLRuleParams{
+		ID:             rule.ID,
+		Network:        rule.Network,
+		AllowQuery:     rule.AllowQuery,
+		AllowRecursion: rule.AllowRecursion,
+		AllowTransfer:  rule.AllowTransfer,
+		AllowUpdate:    rule.AllowUpdate,
+	})
+	return err
+}
+
+// DeleteACLRule delete rule of the access control list with provided ID.
+func (repo Postgres) DeleteACLRule(ctx context.Context, id int32) error {
+	return repo.db.DeleteACLRule(ctx, id)
+}
+
+// AddQueryStats add counts of the DNS queries to the statistics.
+// Counts of the same queries that already saved are summed.
+func (repo Postgres) AddQueryStats(ctx context.Context, stats []QueryStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	params := sqlc.AddQueryStatsParams{}
+	for _, stat := range stats {
+		params.Minutes = append(params.Minutes, pgtype.Timestamptz{Time: stat.Time, Valid: true})
+		params.Clients = append(params.Clients, stat.Client)
+		params.Domains = append(params.Domains, stat.Domain)
+		params.Qtypes = append(params.Qtypes, stat.Type)
+		params.Rcodes = append(params.Rcodes, stat.Rcode)
+		params.Blocked = append(params.Blocked, stat.Blocked)
+		params.Counts = append(params.Counts, stat.Count)
+	}
+	return repo.db.AddQueryStats(ctx, params)
+}
+
+// GetTopClients return clients that made most of the queries.
+func (repo Postgres) GetTopClients(ctx context.Context, filter TopFilter) ([]TopItem, error) {
+	rows, err := repo.db.GetTopClients(ctx, sqlc.GetTopClientsParams(topParams(filter)))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]TopItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, TopItem{Key: row.Key, Count: row.Count})
+	}
+	return items, nil
+}
+
+// GetTopDomains return most queried domains.
+func (repo Postgres) GetTopDomains(ctx context.Context, filter TopFilter) ([]TopItem, error) {
+	rows, err := repo.db.GetTopDomains(ctx, sqlc.GetTopDomainsParams(topParams(filter)))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]TopItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, TopItem{Key: row.Key, Count: row.Count})
+	}
+	return items, nil
+}
+
+// GetTopBlocked return domains with most of the blocked queries.
+func (repo Postgres) GetTopBlocked(ctx context.Context, filter TopFilter) ([]TopItem, error) {
+	rows, err := repo.db.GetTopBlocked(ctx, sqlc.GetTopBlockedParams(topParams(filter)))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]TopItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, TopItem{Key: row.Key, Count: row.Count})
+	}
+	return items, nil
+}
+
+// GetQueryVolume return count of the queries made since provided time in intervals of step length.
+func (repo Postgres) GetQueryVolume(ctx context.Context, since time.Time, step time.Duration) ([]VolumePoint, error) {
+	rows, err := repo.db.GetQueryVolume(ctx, sqlc.GetQueryVolumeParams{
+		Step:  int32(step.Seconds()),
+		Since: pgtype.Timestamptz{Time: since, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	points := make([]VolumePoint, 0, len(rows))
+	for _, row := range rows {
+		points = append(points, VolumePoint{Time: row.Time.Time, Total: row.Total, Blocked: row.Blocked})
+	}
+	return points, nil
+}
+
+// DeleteQueryStatsBefore delete statistics of the queries made before provided time.
+func (repo Postgres) DeleteQueryStatsBefore(ctx context.Context, t time.Time) (int64, error) {
+	return repo.db.DeleteQueryStatsBefore(ctx, pgtype.Timestamptz{Time: t, Valid: true})
+}
+
+// AddQueryLog add entries to the query log.
+func (repo Postgres) AddQueryLog(ctx context.Context, entries []QueryLogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	params := sqlc.AddQueryLogParams{}
+	for _, entry := range entries {
+		params.Times = append(params.Times, pgtype.Timestamptz{Time: entry.Time, Valid: true})
+		params.Clients = append(params.Clients, entry.Client)
+		params.Protocols = append(params.Protocols, entry.Protocol)
+		params.Qnames = append(params.Qnames, entry.Name)
+		params.Qtypes = append(params.Qtypes, entry.Type)
+		params.Rcodes = append(params.Rcodes, entry.Rcode)
+		params.Answers = append(params.Answers, entry.Answer)
+		params.Upstreams = append(params.Upstreams, entry.Upstream)
+		params.Latencies = append(params.Latencies, entry.Latency.Microseconds())
+		params.CacheHits = append(params.CacheHits, entry.CacheHit)
+		params.BlockReasons = append(params.BlockReasons, entry.BlockReason)
+	}
+	return repo.db.AddQueryLog(ctx, params)
+}
+
+// SearchQueryLog return page of the query log entries that match the filter and cursor for the next page.
+func (repo Postgres) SearchQueryLog(ctx context.Context, filter QueryLogFilter) ([]QueryLogEntry, string, error) {
+	params := sqlc.SearchQueryLogParams{
+		Qtype: textParam(strings.ToUpper(filter.Type)),
+		Rcode: textParam(strings.ToUpper(filter.Rcode)),
+		// One more entry is requested to know if there is next page.
+		PageSize: filter.Limit + 1,
+	}
+	if filter.Network.IsValid() {
+		network := filter.Network.Masked()
+		params.Network = &network
+	}
+	if filter.Domain != "" {
+		params.Domain = textParam(strings.ToLower(dns.Fqdn(filter.Domain)))
+	}
+	if filter.Blocked != nil {
+		params.Blocked = pgtype.Bool{Bool: *filter.Blocked, Valid: true}
+	}
+	if !filter.Since.IsZero() {
+		params.Since = pgtype.Timestamptz{Time: filter.Since, Valid: true}
+	}
+	if !filter.Until.IsZero() {
+		params.Until = pgtype.Timestamptz{Time: filter.Until, Valid: true}
+	}
+	if filter.Cursor != "" {
+		key, _, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		params.BeforeID = pgtype.Int8{Int64: id, Valid: true}
+	}
+
+	rows, err := repo.db.SearchQueryLog(ctx, params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cursor := ""
+	if len(rows) > int(filter.Limit) {
+		rows = rows[:filter.Limit]
+		cursor = encodeCursor(strconv.FormatInt(rows[len(rows)-1].ID, 10), 0)
+	}
+
+	entries := make([]QueryLogEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, QueryLogEntry{
+			ID:          row.ID,
+			Time:        row.Time.Time,
+			Client:      row.Client,
+			Protocol:    row.Protocol,
+			Name:        row.Qname,
+			Type:        row.Qtype,
+			Rcode:       row.Rcode,
+			Answer:      row.Answer,
+			Upstream:    row.Upstream,
+			Latency:     time.Duration(row.LatencyUs) * time.Microsecond,
+			CacheHit:    row.CacheHit,
+			BlockReason: row.BlockReason,
+		})
+	}
+	return entries, cursor, nil
+}
+
+// DeleteQueryLogBefore delete entries of the query log made before provided time.
+func (repo Postgres) DeleteQueryLogBefore(ctx context.Context, t time.Time) (int64, error) {
+	return repo.db.DeleteQueryLogBefore(ctx, pgtype.Timestamptz{Time: t, Valid: true})
+}
+
 // GetUser return user with provided login.
 func (repo Postgres) GetUser(ctx context.Context, login string) (User, error) {
 	user, err := repo.db.GetUser(ctx, login)
@@ -151,11 +929,14 @@ func (repo Postgres) GetUser(ctx context.Context, login string) (User, error) {
 	}
 
 	return User{
-		ID:        user.ID,
-		Login:     user.Login,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Role:      user.Role,
+		ID:                 user.ID,
+		Login:              user.Login,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		Role:               user.Role,
+		Zones:              user.Zones,
+		Permissions:        user.Permissions,
+		MustChangePassword: user.MustChangePassword,
 	}, nil
 }
 
@@ -174,6 +955,7 @@ func (repo Postgres) GetAllUsers(ctx context.Context) ([]User, error) {
 			FirstName: user.FirstName,
 			LastName:  user.LastName,
 			Role:      user.Role,
+			Zones:     user.Zones,
 		})
 	}
 	return users, nil
@@ -181,6 +963,12 @@ func (repo Postgres) GetAllUsers(ctx context.Context) ([]User, error) {
 
 // UpdateUser update user with provided ID and values.
 func (repo Postgres) UpdateUser(ctx context.Context, user User, password string) error {
+	verr := &ValidationError{}
+	user.Zones = normalizeZones(verr, user.Zones)
+	if len(verr.Fields) != 0 {
+		return verr
+	}
+
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), 12)
 	if err != nil {
 		return fmt.Errorf("can't hash password: %w", err)
@@ -193,29 +981,24 @@ func (repo Postgres) UpdateUser(ctx context.Context, user User, password string)
 		LastName:  user.LastName,
 		Role:      user.Role,
 		Password:  string(hash),
+		Zones:     user.Zones,
 	})
 }
 
 // AddUser add user in the database and return this user with settled ID.
 func (repo Postgres) AddUser(ctx context.Context, user User, password string) (int32, error) {
-	if len(user.FirstName) < 2 {
-		return 0, fmt.Errorf("can't use name %s, the length less than 2")
-	}
-
-	if len(user.LastName) < 2 {
-		return 0, fmt.Errorf("can't use last name %s, the length less than 2")
+	if err := validateUser(user, password); err != nil {
+		return 0, err
 	}
 
 	if len(user.Role) < 4 {
 		return 0, fmt.Errorf("length of role can't be less than 4")
 	}
 
-	if len(password) < 4 {
-		return 0, fmt.Errorf("password is too weak, it must contain at least " +
-			"1 special symbol and 1 number and 8 symbols in total")
-	}
-	if len(password) > 71 {
-		return 0, fmt.Errorf("password is too long, 70 symbols max")
+	verr := &ValidationError{}
+	user.Zones = normalizeZones(verr, user.Zones)
+	if len(verr.Fields) != 0 {
+		return 0, verr
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), 12)
@@ -224,11 +1007,54 @@ func (repo Postgres) AddUser(ctx context.Context, user User, password string) (i
 	}
 
 	id, err := repo.db.CreateUser(ctx, sqlc.CreateUserParams{
-		Login:     user.Login,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Role:      user.Role,
-		Password:  string(hash),
+		Login:              user.Login,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		Role:               user.Role,
+		Password:           string(hash),
+		Zones:              user.Zones,
+		MustChangePassword: user.MustChangePassword,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("can't register new user: %w", err)
+	}
+	return id, nil
+}
+
+// RegisterUser add user invited by the invitation with provided hash of the token.
+// Invitation is used in the same transaction, so it can't be used twice.
+func (repo Postgres) RegisterUser(ctx context.Context, user User, password string, invitation []byte) (int32, error) {
+	if err := validateUser(user, password); err != nil {
+		return 0, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return 0, fmt.Errorf("can't hash password: %w", err)
+	}
+
+	var id int32
+	err = repo.inTx(ctx, func(q *sqlc.Queries) error {
+		invited, err := q.UseInvitation(ctx, sqlc.UseInvitationParams{
+			TokenHash: invitation,
+			UsedBy:    pgtype.Text{String: user.Login, Valid: true},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidInvitation
+		}
+		if err != nil {
+			return err
+		}
+
+		id, err = q.CreateUser(ctx, sqlc.CreateUserParams{
+			Login:     user.Login,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Role:      invited.Role,
+			Password:  string(hash),
+			Zones:     invited.Zones,
+		})
+		return err
 	})
 	if err != nil {
 		return 0, fmt.Errorf("can't register new user: %w", err)
@@ -236,6 +1062,103 @@ func (repo Postgres) AddUser(ctx context.Context, user User, password string) (i
 	return id, nil
 }
 
+// CountUsers return number of the users.
+func (repo Postgres) CountUsers(ctx context.Context) (int64, error) {
+	return repo.db.CountUsers(ctx)
+}
+
+// SetUserPassword change password of the user with provided ID.
+func (repo Postgres) SetUserPassword(ctx context.Context, id int32, password string, mustChange bool) error {
+	if err := checkPassword(password); err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return fmt.Errorf("can't hash password: %w", err)
+	}
+
+	n, err := repo.db.SetUserPassword(ctx, sqlc.SetUserPasswordParams{
+		ID:                 id,
+		Password:           string(hash),
+		MustChangePassword: mustChange,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetUserBySubject return user provisioned by the OpenID Connect login with provided subject.
+func (repo Postgres) GetUserBySubject(ctx context.Context, subject string) (User, error) {
+	user, err := repo.db.GetUserBySubject(ctx, pgtype.Text{String: subject, Valid: true})
+	if err != nil {
+		return User{}, fmt.Errorf("can't get user from database: %w", err)
+	}
+
+	return User{
+		ID:          user.ID,
+		Login:       user.Login,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		Role:        user.Role,
+		Zones:       user.Zones,
+		Permissions: user.Permissions,
+	}, nil
+}
+
+// AddOIDCUser add user provisioned by the OpenID Connect login and return its ID.
+// User get random password, so it can log in only through the provider.
+func (repo Postgres) AddOIDCUser(ctx context.Context, user User, subject string) (int32, error) {
+	password := make([]byte, 32)
+	if _, err := rand.Read(password); err != nil {
+		return 0, fmt.Errorf("can't generate password: %w", err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(password)), 12)
+	if err != nil {
+		return 0, fmt.Errorf("can't hash password: %w", err)
+	}
+
+	var id int32
+	err = repo.inTx(ctx, func(q *sqlc.Queries) error {
+		var err error
+		id, err = q.CreateUser(ctx, sqlc.CreateUserParams{
+			Login:     user.Login,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Role:      user.Role,
+			Password:  string(hash),
+			Zones:     []string{},
+		})
+		if err != nil {
+			return err
+		}
+		return q.SetUserSubject(ctx, sqlc.SetUserSubjectParams{
+			ID:          id,
+			OidcSubject: pgtype.Text{String: subject, Valid: true},
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("can't provision user: %w", err)
+	}
+	return id, nil
+}
+
+// SetUserRole change role of the user with provided ID.
+func (repo Postgres) SetUserRole(ctx context.Context, id int32, role string) error {
+	n, err := repo.db.SetUserRole(ctx, sqlc.SetUserRoleParams{ID: id, Role: role})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
 // CheckUserPassword check if the password is correct for provided user.
 func (repo Postgres) CheckUserPassword(ctx context.Context, login, pass string) (User, error) {
 	user, err := repo.db.GetUser(ctx, login)
@@ -244,11 +1167,14 @@ func (repo Postgres) CheckUserPassword(ctx context.Context, login, pass string)
 	}
 
 	return User{
-		ID:        user.ID,
-		Login:     user.Login,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Role:      user.Role,
+		ID:                 user.ID,
+		Login:              user.Login,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		Role:               user.Role,
+		Zones:              user.Zones,
+		Permissions:        user.Permissions,
+		MustChangePassword: user.MustChangePassword,
 	}, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(pass))
 }
 
@@ -256,3 +1182,522 @@ func (repo Postgres) CheckUserPassword(ctx context.Context, login, pass string)
 func (repo Postgres) DeleteUser(ctx context.Context, id int32) error {
 	return repo.db.DeleteUser(ctx, id)
 }
+
+// GetRoles return all roles with their permissions.
+func (repo Postgres) GetRoles(ctx context.Context) ([]Role, error) {
+	rows, err := repo.db.GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]Role, 0, len(rows))
+	for _, row := range rows {
+		roles = append(roles, Role{ID: row.ID, Name: row.Role, Permissions: row.Permissions})
+	}
+	return roles, nil
+}
+
+// GetRole return role with provided ID.
+func (repo Postgres) GetRole(ctx context.Context, id int32) (Role, error) {
+	row, err := repo.db.GetRole(ctx, id)
+	if err != nil {
+		return Role{}, err
+	}
+	return Role{ID: row.ID, Name: row.Role, Permissions: row.Permissions}, nil
+}
+
+// AddRole insert role with its permissions in the database and return its ID.
+func (repo Postgres) AddRole(ctx context.Context, role Role) (int32, error) {
+	role, err := NormalizeRole(role)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int32
+	err = repo.inTx(ctx, func(q *sqlc.Queries) error {
+		var err error
+		id, err = q.CreateRole(ctx, role.Name)
+		if err != nil {
+			return err
+		}
+		return q.AddRolePermissions(ctx, sqlc.AddRolePermissionsParams{RoleID: id, Permissions: role.Permissions})
+	})
+	return id, err
+}
+
+// UpdateRole replace name and permissions of the role.
+// pgx.ErrNoRows is returned if role with provided ID doesn't exist.
+func (repo Postgres) UpdateRole(ctx context.Context, role Role) error {
+	role, err := NormalizeRole(role)
+	if err != nil {
+		return err
+	}
+
+	return repo.inTx(ctx, func(q *sqlc.Queries) error {
+		n, err := q.UpdateRole(ctx, sqlc.UpdateRoleParams{ID: role.ID, Role: role.Name})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return pgx.ErrNoRows
+		}
+		if err := q.DeleteRolePermissions(ctx, role.ID); err != nil {
+			return err
+		}
+		return q.AddRolePermissions(ctx, sqlc.AddRolePermissionsParams{RoleID: role.ID, Permissions: role.Permissions})
+	})
+}
+
+// DeleteRole delete role with provided ID. pgx.ErrNoRows is returned if the role doesn't exist
+// and ErrRoleInUse if it is assigned to users or API keys.
+func (repo Postgres) DeleteRole(ctx context.Context, id int32) error {
+	n, err := repo.db.DeleteRole(ctx, id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+		return ErrRoleInUse
+	}
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// AddAPIKey insert API key with provided hash of the key in the database.
+// Key is validated and normalized before insert, it is returned with settled ID and creation time.
+func (repo Postgres) AddAPIKey(ctx context.Context, key APIKey, hash []byte) (APIKey, error) {
+	key, err := NormalizeAPIKey(key)
+	if err != nil {
+		return key, err
+	}
+
+	row, err := repo.db.CreateAPIKey(ctx, sqlc.CreateAPIKeyParams{
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		KeyHash:   hash,
+		Role:      key.Role,
+		Zones:     key.Zones,
+		CreatedBy: key.CreatedBy,
+		ExpiresAt: timestampParam(key.ExpiresAt),
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23502" { // not_null_violation of role_id
+		verr := &ValidationError{}
+		verr.Add("role", fmt.Sprintf("role %q doesn't exist", key.Role))
+		return key, verr
+	}
+	if err != nil {
+		return key, fmt.Errorf("can't add API key: %w", err)
+	}
+	key.ID = row.ID
+	key.CreatedAt = row.CreatedAt.Time
+	return key, nil
+}
+
+// GetAPIKeys return all API keys.
+func (repo Postgres) GetAPIKeys(ctx context.Context) ([]APIKey, error) {
+	rows, err := repo.db.GetAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, toAPIKey(row))
+	}
+	return keys, nil
+}
+
+// GetAPIKeyByHash return API key with provided hash of the key.
+func (repo Postgres) GetAPIKeyByHash(ctx context.Context, hash []byte) (APIKey, error) {
+	row, err := repo.db.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		return APIKey{}, err
+	}
+	key := toAPIKey(sqlc.GetAPIKeysRow{
+		ID:         row.ID,
+		Name:       row.Name,
+		Prefix:     row.Prefix,
+		Role:       row.Role,
+		Zones:      row.Zones,
+		CreatedBy:  row.CreatedBy,
+		CreatedAt:  row.CreatedAt,
+		ExpiresAt:  row.ExpiresAt,
+		LastUsedAt: row.LastUsedAt,
+		RevokedAt:  row.RevokedAt,
+	})
+	key.Permissions = row.Permissions
+	return key, nil
+}
+
+// RevokeAPIKey revoke API key with provided ID.
+func (repo Postgres) RevokeAPIKey(ctx context.Context, id int32) error {
+	n, err := repo.db.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// TouchAPIKey set time of the last use of the API key.
+func (repo Postgres) TouchAPIKey(ctx context.Context, id int32, t time.Time) error {
+	return repo.db.TouchAPIKey(ctx, sqlc.TouchAPIKeyParams{
+		ID:         id,
+		LastUsedAt: pgtype.Timestamptz{Time: t, Valid: true},
+	})
+}
+
+// AddInvitation add invitation with provided hash of the token.
+func (repo Postgres) AddInvitation(ctx context.Context, invitation Invitation, hash []byte) (Invitation, error) {
+	invitation, err := NormalizeInvitation(invitation)
+	if err != nil {
+		return invitation, err
+	}
+
+	row, err := repo.db.CreateInvitation(ctx, sqlc.CreateInvitationParams{
+		TokenHash: hash,
+		Role:      invitation.Role,
+		Zones:     invitation.Zones,
+		CreatedBy: invitation.CreatedBy,
+		ExpiresAt: timestampParam(invitation.ExpiresAt),
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23502" { // not_null_violation of role_id
+		verr := &ValidationError{}
+		verr.Add("role", fmt.Sprintf("role %q doesn't exist", invitation.Role))
+		return invitation, verr
+	}
+	if err != nil {
+		return invitation, fmt.Errorf("can't add invitation: %w", err)
+	}
+	invitation.ID = row.ID
+	invitation.CreatedAt = row.CreatedAt.Time
+	return invitation, nil
+}
+
+// GetInvitations return all invitations.
+func (repo Postgres) GetInvitations(ctx context.Context) ([]Invitation, error) {
+	rows, err := repo.db.GetInvitations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := make([]Invitation, 0, len(rows))
+	for _, row := range rows {
+		invitations = append(invitations, Invitation{
+			ID:        row.ID,
+			Role:      row.Role,
+			Zones:     row.Zones,
+			CreatedBy: row.CreatedBy,
+			CreatedAt: row.CreatedAt.Time,
+			ExpiresAt: row.ExpiresAt.Time,
+			UsedBy:    row.UsedBy.String,
+			UsedAt:    row.UsedAt.Time,
+		})
+	}
+	return invitations, nil
+}
+
+// DeleteInvitation delete invitation with provided ID.
+func (repo Postgres) DeleteInvitation(ctx context.Context, id int32) error {
+	n, err := repo.db.DeleteInvitation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// AddRefreshToken add refresh token of the user with provided hash of the token.
+func (repo Postgres) AddRefreshToken(ctx context.Context, userID int32, hash []byte, expiresAt time.Time) error {
+	return repo.db.CreateRefreshToken(ctx, sqlc.CreateRefreshTokenParams{
+		TokenHash: hash,
+		UserID:    userID,
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+}
+
+// GetRefreshToken return refresh token with provided hash of the token.
+func (repo Postgres) GetRefreshToken(ctx context.Context, hash []byte) (RefreshToken, error) {
+	row, err := repo.db.GetRefreshToken(ctx, hash)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+	return RefreshToken{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		Login:     row.Login,
+		ExpiresAt: row.ExpiresAt.Time,
+		RevokedAt: row.RevokedAt.Time,
+	}, nil
+}
+
+// RevokeRefreshToken revoke refresh token with provided ID, pgx.ErrNoRows is returned
+// if the token doesn't exist or is already revoked.
+func (repo Postgres) RevokeRefreshToken(ctx context.Context, id int32) error {
+	n, err := repo.db.RevokeRefreshToken(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// RevokeUserRefreshTokens revoke all refresh tokens of the user with provided ID.
+func (repo Postgres) RevokeUserRefreshTokens(ctx context.Context, userID int32) error {
+	return repo.db.RevokeUserRefreshTokens(ctx, userID)
+}
+
+// RevokeToken add ID of the access token to the revocation list until the token expire.
+func (repo Postgres) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return repo.db.RevokeToken(ctx, sqlc.RevokeTokenParams{
+		Jti:       jti,
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+}
+
+// IsTokenRevoked report whether the access token with provided ID is revoked.
+func (repo Postgres) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return repo.db.IsTokenRevoked(ctx, jti)
+}
+
+// DeleteExpiredTokens delete refresh tokens and entries of the revocation list
+// that expired before provided time and return count of deleted rows.
+func (repo Postgres) DeleteExpiredTokens(ctx context.Context, t time.Time) (int64, error) {
+	before := pgtype.Timestamptz{Time: t, Valid: true}
+	refresh, err := repo.db.DeleteExpiredRefreshTokens(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	revoked, err := repo.db.DeleteExpiredRevokedTokens(ctx, before)
+	if err != nil {
+		return refresh, err
+	}
+	return refresh + revoked, nil
+}
+
+// toAPIKey convert row of the api_keys table, NULL times are zero.
+func toAPIKey(row sqlc.GetAPIKeysRow) APIKey {
+	return APIKey{
+		ID:         row.ID,
+		Name:       row.Name,
+		Prefix:     row.Prefix,
+		Role:       row.Role,
+		Zones:      row.Zones,
+		CreatedBy:  row.CreatedBy,
+		CreatedAt:  row.CreatedAt.Time,
+		ExpiresAt:  row.ExpiresAt.Time,
+		LastUsedAt: row.LastUsedAt.Time,
+		RevokedAt:  row.RevokedAt.Time,
+	}
+}
+
+// Listen wait for notifications about changes from the database triggers
+// and call handler for every of them.
+// Dedicated connection from the pool is used while listening.
+func (repo Postgres) Listen(ctx context.Context, ready func() error, handler func(Change)) error {
+	conn, err := repo.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("can't acquire connection for listening: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN changes"); err != nil {
+		return fmt.Errorf("can't listen changes channel: %w", err)
+	}
+	// Connection with LISTEN state must not be reused by other queries.
+	defer conn.Exec(context.Background(), "UNLISTEN *")
+
+	// Notifications received meanwhile are queued by the connection.
+	if err := ready(); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("can't wait for notification: %w", err)
+		}
+
+		change := Change{}
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			return fmt.Errorf("can't unmarshal notification payload %q: %w",
+				notification.Payload, err)
+		}
+		handler(change)
+	}
+}
+
+// topParams return parameters of the top queries, all of them have the same parameters.
+func topParams(filter TopFilter) sqlc.GetTopClientsParams {
+	return sqlc.GetTopClientsParams{
+		Since:    pgtype.Timestamptz{Time: filter.Since, Valid: true},
+		Qtype:    textParam(strings.ToUpper(filter.Type)),
+		Rcode:    textParam(strings.ToUpper(filter.Rcode)),
+		PageSize: filter.Limit,
+	}
+}
+
+// timestampParam return NULL parameter for zero time.
+func timestampParam(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: !t.IsZero()}
+}
+
+// textParam return NULL parameter for empty string.
+func textParam(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+// escapeLike escape special symbols of the LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// reverse return string with the reversed order of characters.
+func reverse(s string) string {
+	runes := []rune(s)
+	slices.Reverse(runes)
+	return string(runes)
+}
+
+// encodeCursor encode sort key and ID of the last record on the page.
+func encodeCursor(key string, id int32) string {
+	return base64.RawURLEncoding.EncodeToString(
+		[]byte(strconv.FormatInt(int64(id), 10) + ":" + key))
+}
+
+// encodeRecordCursor encode sort field, order, sort key and ID of the last record on the page of SearchRecords.
+func encodeRecordCursor(sortBy, order, key string, id int32) string {
+	return encodeCursor(sortBy+":"+order+":"+key, id)
+}
+
+// decodeRecordCursor return sort key and ID of the last record on the previous page of SearchRecords.
+// ErrCursorMismatch is returned if the cursor was returned with other sort field or order.
+func decodeRecordCursor(cursor, sortBy, order, typ string) (string, int32, error) {
+	key, id, err := decodeCursor(cursor)
+	if err != nil {
+		return "", 0, err
+	}
+	cursorSort, key, ok := strings.Cut(key, ":")
+	if !ok {
+		return "", 0, ErrInvalidCursor
+	}
+	cursorOrder, key, ok := strings.Cut(key, ":")
+	if !ok {
+		return "", 0, ErrInvalidCursor
+	}
+	if cursorSort != sortBy || cursorOrder != order {
+		return "", 0, ErrCursorMismatch
+	}
+	if typ == "integer" {
+		if _, err := strconv.ParseInt(key, 10, 32); err != nil {
+			return "", 0, ErrInvalidCursor
+		}
+	}
+	return key, id, nil
+}
+
+// decodeCursor return sort key and ID of the last record on the previous page.
+func decodeCursor(cursor string) (string, int32, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+	idStr, key, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return "", 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+	return key, int32(id), nil
+}
+
+// inTx run fn in the transaction and commit it if fn don't return error.
+func (repo Postgres) inTx(ctx context.Context, fn func(q *sqlc.Queries) error) error {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	if err := fn(repo.db.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// addHistory save change of the resource record made by actor from the context.
+func addHistory(ctx context.Context, q *sqlc.Queries, operation string, id int32, before, after *ResourceRecord) error {
+	params := sqlc.CreateRecordHistoryParams{
+		RecordID:  id,
+		Operation: operation,
+		Actor:     ActorFromContext(ctx),
+	}
+
+	var err error
+	if before != nil {
+		if params.Before, err = json.Marshal(before); err != nil {
+			return fmt.Errorf("can't marshal resource record: %w", err)
+		}
+	}
+	if after != nil {
+		if params.After, err = json.Marshal(after); err != nil {
+			return fmt.Errorf("can't marshal resource record: %w", err)
+		}
+	}
+
+	if _, err := q.CreateRecordHistory(ctx, params); err != nil {
+		return fmt.Errorf("can't save change to the history: %w", err)
+	}
+	return nil
+}
+
+func toRecordChanges(rows []sqlc.RecordHistory) ([]RecordChange, error) {
+	changes := make([]RecordChange, 0, len(rows))
+	for _, row := range rows {
+		change, err := toRecordChange(row)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func toRecordChange(row sqlc.RecordHistory) (RecordChange, error) {
+	change := RecordChange{
+		ID:        row.ID,
+		RecordID:  row.RecordID,
+		Operation: row.Operation,
+		Actor:     row.Actor,
+		Time:      row.ChangedAt.Time,
+	}
+	if row.Before != nil {
+		change.Before = &ResourceRecord{}
+		if err := json.Unmarshal(row.Before, change.Before); err != nil {
+			return RecordChange{}, fmt.Errorf("can't unmarshal resource record from history: %w", err)
+		}
+	}
+	if row.After != nil {
+		change.After = &ResourceRecord{}
+		if err := json.Unmarshal(row.After, change.After); err != nil {
+			return RecordChange{}, fmt.Errorf("can't unmarshal resource record from history: %w", err)
+		}
+	}
+	return change, nil
+}
+
+// normalizeZone return zone in lower case without trailing dot.
+func normalizeZone(zone string) string {
+	return strings.TrimSuffix(strings.ToLower(zone), ".")
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestRecordCursor(t *testing.T) {
+	cursor := encodeRecordCursor("domain", "desc", "www:example.com.", 42)
+	key, id, err := decodeRecordCursor(cursor, "domain", "desc", "text")
+	if err != nil || key != "www:example.com." || id != 42 {
+		t.Fatalf("unexpected decoded cursor %q, %d, %v", key, id, err)
+	}
+
+	if _, _, err := decodeRecordCursor(cursor, "domain", "asc", "text"); !errors.Is(err, ErrCursorMismatch) {
+		t.Fatalf("expected mismatch of the order, got %v", err)
+	}
+	if _, _, err := decodeRecordCursor(cursor, "ttl", "desc", "integer"); !errors.Is(err, ErrCursorMismatch) {
+		t.Fatalf("expected mismatch of the sort field, got %v", err)
+	}
+	if _, _, err := decodeRecordCursor(encodeRecordCursor("ttl", "asc", "x", 1), "ttl", "asc", "integer"); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected invalid key of the integer field, got %v", err)
+	}
+	if _, _, err := decodeRecordCursor(encodeCursor("10", 1), "id", "asc", "integer"); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected invalid cursor without sorting, got %v", err)
+	}
+}
+
+// TestSearchRecords run only when DNS_SERVER_TEST_DATABASE contain connection string
+// to the database that can be filled with test records.
+func TestSearchRecords(t *testing.T) {
+	connString := os.Getenv("DNS_SERVER_TEST_DATABASE")
+	if connString == "" {
+		t.Skip("DNS_SERVER_TEST_DATABASE is not set")
+	}
+	db, err := NewPostgres(connString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	zone := fmt.Sprintf("search-%d.test", time.Now().UnixNano())
+	var ids []int32
+	for i := range 7 {
+		rr := ResourceRecord{
+			Domain: fmt.Sprintf("host%d.%s.", i, zone),
+			Type:   "A",
+			Class:  "IN",
+			Data:   fmt.Sprintf("10.0.0.%d", i),
+			TTL:    int32(i%3) * 60,
+		}
+		if i == 6 {
+			rr.Domain, rr.Type, rr.Data = "mail."+zone+".", "TXT", "v=spf1 -all"
+		}
+		id, err := db.AddRecord(ctx, rr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+		defer db.DeleteRecord(ctx, id)
+	}
+
+	// Pages must follow each other without gaps and duplicates for every sorting.
+	for sortBy := range recordSortColumns {
+		for _, descending := range []bool{false, true} {
+			filter := RecordFilter{Zone: zone, SortBy: sortBy, Descending: descending, Limit: 1000}
+			all, _, err := db.SearchRecords(ctx, filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != len(ids) {
+				t.Fatalf("%s: expected %d records, got %d", sortBy, len(ids), len(all))
+			}
+
+			var paged []ResourceRecord
+			filter.Limit = 2
+			for {
+				page, cursor, err := db.SearchRecords(ctx, filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+				paged = append(paged, page...)
+				if cursor == "" {
+					break
+				}
+				filter.Cursor = cursor
+			}
+			if !slices.Equal(paged, all) {
+				t.Fatalf("%s: pages %v don't match the full result %v", sortBy, paged, all)
+			}
+			if sortBy == "ttl" && !slices.IsSortedFunc(all, func(a, b ResourceRecord) int {
+				if descending {
+					a, b = b, a
+				}
+				return int(a.TTL - b.TTL)
+			}) {
+				t.Fatalf("records are not sorted by TTL: %v", all)
+			}
+		}
+	}
+
+	_, cursor, err := db.SearchRecords(ctx, RecordFilter{Zone: zone, SortBy: "ttl", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := db.SearchRecords(ctx, RecordFilter{Zone: zone, SortBy: "ttl", Descending: true, Cursor: cursor, Limit: 2}); !errors.Is(err, ErrCursorMismatch) {
+		t.Fatalf("expected cursor mismatch, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter RecordFilter
+		ctx    context.Context
+		want   int
+	}{
+		{"type", RecordFilter{Type: "txt"}, ctx, 1},
+		{"prefix", RecordFilter{Domain: "host1*"}, ctx, 1},
+		{"suffix", RecordFilter{Domain: "*." + zone}, ctx, 7},
+		{"contains", RecordFilter{Domain: "*ost*"}, ctx, 6},
+		{"data", RecordFilter{Data: "SPF1"}, ctx, 1},
+		{"query", RecordFilter{Query: "10.0.0.3"}, ctx, 1},
+		{"allowed zone", RecordFilter{}, WithZones(ctx, []string{"host2." + zone + "."}), 1},
+		{"other zone", RecordFilter{}, WithZones(ctx, []string{"example.com."}), 0},
+	}
+	for _, tt := range tests {
+		tt.filter.Zone, tt.filter.Limit = zone, 100
+		rrs, _, err := db.SearchRecords(tt.ctx, tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rrs) != tt.want {
+			t.Errorf("%s: expected %d records, got %v", tt.name, tt.want, rrs)
+		}
+	}
+}
+
+// TestApplyRecordBatch run only when DNS_SERVER_TEST_DATABASE contain connection string
+// to the database that can be filled with test records.
+func TestApplyRecordBatch(t *testing.T) {
+	connString := os.Getenv("DNS_SERVER_TEST_DATABASE")
+	if connString == "" {
+		t.Skip("DNS_SERVER_TEST_DATABASE is not set")
+	}
+	db, err := NewPostgres(connString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	zone := fmt.Sprintf("batch-%d.test", time.Now().UnixNano())
+	rr := ResourceRecord{Domain: "www." + zone + ".", Type: "A", Class: "IN", Data: "10.0.0.1", TTL: 60}
+	id, err := db.AddRecord(ctx, rr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.DeleteRecord(ctx, id)
+
+	records := func() []ResourceRecord {
+		rrs, _, err := db.SearchRecords(ctx, RecordFilter{Zone: zone, Limit: 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rrs
+	}
+	updated := rr
+	updated.ID, updated.Data = id, "10.0.0.2"
+	ops := []RecordOperation{
+		{Action: ActionCreate, Record: ResourceRecord{Domain: "mail." + zone + ".", Type: "A", Class: "IN", Data: "10.0.0.3", TTL: 60}},
+		{Action: ActionUpdate, Record: updated},
+	}
+
+	// Deleting missing record fails only its operation, earlier ones are rolled back with it.
+	results, err := db.ApplyRecordBatch(ctx, append(ops, RecordOperation{Action: ActionDelete, Record: ResourceRecord{ID: -1}}), false)
+	if !errors.Is(err, ErrBatchFailed) || len(results) != 3 || results[0].Err != nil || results[1].Err != nil ||
+		!errors.Is(results[2].Err, pgx.ErrNoRows) {
+		t.Fatalf("expected failed delete of the missing record, got %v, %v", results, err)
+	}
+	if rrs := records(); len(rrs) != 1 || rrs[0].Data != "10.0.0.1" {
+		t.Fatalf("expected batch to be rolled back, got %v", rrs)
+	}
+
+	if _, err := db.ApplyRecordBatch(ctx, ops, true); err != nil {
+		t.Fatal(err)
+	}
+	if rrs := records(); len(rrs) != 1 || rrs[0].Data != "10.0.0.1" {
+		t.Fatalf("expected dry run to change nothing, got %v", rrs)
+	}
+
+	results, err = db.ApplyRecordBatch(ctx, ops, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.DeleteRecord(ctx, results[0].ID)
+	if rrs := records(); len(rrs) != 2 {
+		t.Fatalf("expected batch to be applied, got %v", rrs)
+	}
+}
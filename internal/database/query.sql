@@ -43,25 +43,236 @@ RETURNING id;
 DELETE FROM resource_records
 WHERE id = $1 ;
 
+-- name: CreateResourceRecordWithID :one
+INSERT INTO resource_records (id, domain, data, type_id, class_id, time_to_live)
+VALUES (
+    $1,
+    $2,
+    $3,
+    (SELECT id FROM types WHERE type = $4),
+    (SELECT id FROM classes WHERE class = $5),
+    $6
+)
+RETURNING id;
+
+-- name: CreateRecordHistory :one
+INSERT INTO record_history (record_id, operation, actor, before, after)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: GetRecordHistoryEntry :one
+SELECT id, record_id, operation, actor, changed_at, before, after
+FROM record_history
+WHERE id = $1;
+
+-- name: GetRecordHistory :many
+SELECT id, record_id, operation, actor, changed_at, before, after
+FROM record_history
+WHERE record_id = $1
+ORDER BY changed_at DESC, id DESC;
+
+-- name: GetZoneHistory :many
+SELECT id, record_id, operation, actor, changed_at, before, after
+FROM record_history
+WHERE lower(rtrim(before->>'domain', '.')) = @zone::text
+    OR right(lower(rtrim(before->>'domain', '.')), length(@zone::text) + 1) = '.' || @zone::text
+    OR lower(rtrim(after->>'domain', '.')) = @zone::text
+    OR right(lower(rtrim(after->>'domain', '.')), length(@zone::text) + 1) = '.' || @zone::text
+ORDER BY changed_at DESC, id DESC
+LIMIT @page_size::int;
+
+-- name: GetFirstZoneChangesSince :many
+SELECT DISTINCT ON (record_id) id, record_id, operation, actor, changed_at, before, after
+FROM record_history
+WHERE changed_at > @since::timestamptz AND (
+    lower(rtrim(before->>'domain', '.')) = @zone::text
+    OR right(lower(rtrim(before->>'domain', '.')), length(@zone::text) + 1) = '.' || @zone::text
+    OR lower(rtrim(after->>'domain', '.')) = @zone::text
+    OR right(lower(rtrim(after->>'domain', '.')), length(@zone::text) + 1) = '.' || @zone::text
+)
+ORDER BY record_id, changed_at, id;
+
+-- name: GetAllViews :many
+SELECT views.id, views.name, views.networks, views.zones,
+    array_remove(array_agg(record_views.record_id ORDER BY record_views.record_id), NULL)::int[] AS record_ids
+FROM views LEFT JOIN record_views ON views.id = record_views.view_id
+GROUP BY views.id
+ORDER BY views.id;
+
+-- name: GetView :one
+SELECT views.id, views.name, views.networks, views.zones,
+    array_remove(array_agg(record_views.record_id ORDER BY record_views.record_id), NULL)::int[] AS record_ids
+FROM views LEFT JOIN record_views ON views.id = record_views.view_id
+WHERE views.id = $1
+GROUP BY views.id;
+
+-- name: CreateView :one
+INSERT INTO views (name, networks, zones)
+VALUES ($1, $2, $3)
+RETURNING id;
+
+-- name: UpdateView :one
+UPDATE views
+SET name = $2, networks = $3, zones = $4
+WHERE id = $1
+RETURNING id;
+
+-- name: DeleteView :exec
+DELETE FROM views
+WHERE id = $1;
+
+-- name: DeleteViewRecords :exec
+DELETE FROM record_views
+WHERE view_id = $1;
+
+-- name: AddViewRecords :exec
+INSERT INTO record_views (record_id, view_id)
+SELECT unnest(@record_ids::int[]), @view_id::int;
+
+-- name: GetACLRules :many
+SELECT id, network, allow_query, allow_recursion, allow_transfer, allow_update
+FROM acl_rules
+ORDER BY id;
+
+-- name: CreateACLRule :one
+INSERT INTO acl_rules (network, allow_query, allow_recursion, allow_transfer, allow_update)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: UpdateACLRule :one
+UPDATE acl_rules
+SET network = $2, allow_query = $3, allow_recursion = $4, allow_transfer = $5, allow_update = $6
+WHERE id = $1
+RETURNING id;
+
+-- name: DeleteACLRule :exec
+DELETE FROM acl_rules
+WHERE id = $1;
+
+-- name: AddQueryStats :exec
+INSERT INTO query_stats (minute, client, domain, qtype, rcode, blocked, count)
+SELECT unnest(@minutes::timestamptz[]), unnest(@clients::inet[]), unnest(@domains::text[]),
+    unnest(@qtypes::text[]), unnest(@rcodes::text[]), unnest(@blocked::boolean[]), unnest(@counts::bigint[])
+ON CONFLICT (minute, client, domain, qtype, rcode, blocked)
+DO UPDATE SET count = query_stats.count + EXCLUDED.count;
+
+-- name: GetTopClients :many
+SELECT host(client) AS key, sum(count)::bigint AS count
+FROM query_stats
+WHERE minute >= @since::timestamptz
+    AND (sqlc.narg(qtype)::text IS NULL OR qtype = sqlc.narg(qtype))
+    AND (sqlc.narg(rcode)::text IS NULL OR rcode = sqlc.narg(rcode))
+GROUP BY 1
+ORDER BY 2 DESC, 1
+LIMIT @page_size::int;
+
+-- name: GetTopDomains :many
+SELECT domain AS key, sum(count)::bigint AS count
+FROM query_stats
+WHERE minute >= @since::timestamptz
+    AND (sqlc.narg(qtype)::text IS NULL OR qtype = sqlc.narg(qtype))
+    AND (sqlc.narg(rcode)::text IS NULL OR rcode = sqlc.narg(rcode))
+GROUP BY 1
+ORDER BY 2 DESC, 1
+LIMIT @page_size::int;
+
+-- name: GetTopBlocked :many
+SELECT domain AS key, sum(count)::bigint AS count
+FROM query_stats
+WHERE minute >= @since::timestamptz AND blocked
+    AND (sqlc.narg(qtype)::text IS NULL OR qtype = sqlc.narg(qtype))
+    AND (sqlc.narg(rcode)::text IS NULL OR rcode = sqlc.narg(rcode))
+GROUP BY 1
+ORDER BY 2 DESC, 1
+LIMIT @page_size::int;
+
+-- name: GetQueryVolume :many
+SELECT to_timestamp(floor(extract(epoch FROM minute) / @step::int) * @step::int)::timestamptz AS time,
+    sum(count)::bigint AS total,
+    COALESCE(sum(count) FILTER (WHERE blocked), 0)::bigint AS blocked
+FROM query_stats
+WHERE minute >= @since::timestamptz
+GROUP BY 1
+ORDER BY 1;
+
+-- name: DeleteQueryStatsBefore :execrows
+DELETE FROM query_stats
+WHERE minute < $1;
+
+-- name: AddQueryLog :exec
+INSERT INTO query_log (time, client, protocol, qname, qtype, rcode, answer, upstream, latency_us, cache_hit, block_reason)
+SELECT unnest(@times::timestamptz[]), unnest(@clients::inet[]), unnest(@protocols::text[]),
+    unnest(@qnames::text[]), unnest(@qtypes::text[]), unnest(@rcodes::text[]), unnest(@answers::text[]),
+    unnest(@upstreams::text[]), unnest(@latencies::bigint[]), unnest(@cache_hits::boolean[]),
+    unnest(@block_reasons::text[]);
+
+-- name: SearchQueryLog :many
+SELECT id, time, client, protocol, qname, qtype, rcode, answer, upstream, latency_us, cache_hit, block_reason
+FROM query_log
+WHERE (sqlc.narg(before_id)::bigint IS NULL OR id < sqlc.narg(before_id))
+    AND (sqlc.narg(network)::cidr IS NULL OR client <<= sqlc.narg(network))
+    AND (sqlc.narg(domain)::text IS NULL OR qname = sqlc.narg(domain)
+        OR right(qname, length(sqlc.narg(domain)) + 1) = '.' || sqlc.narg(domain))
+    AND (sqlc.narg(qtype)::text IS NULL OR qtype = sqlc.narg(qtype))
+    AND (sqlc.narg(rcode)::text IS NULL OR rcode = sqlc.narg(rcode))
+    AND (sqlc.narg(blocked)::boolean IS NULL OR (block_reason <> '') = sqlc.narg(blocked))
+    AND (sqlc.narg(since)::timestamptz IS NULL OR time >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR time < sqlc.narg(until))
+ORDER BY id DESC
+LIMIT @page_size::int;
+
+-- name: DeleteQueryLogBefore :execrows
+DELETE FROM query_log
+WHERE time < $1;
+
 -- name: CreateUser :one
-INSERT INTO users (login, first_name, last_name,password,role_id)
+INSERT INTO users (login, first_name, last_name,password,role_id, zones, must_change_password)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    (SELECT roles.id FROM roles WHERE roles.role = $5)
+    (SELECT roles.id FROM roles WHERE roles.role = $5),
+    $6,
+    $7
 )
 RETURNING id ;
 
+-- name: CountUsers :one
+SELECT count(*) FROM users;
+
 
 -- name: GetUser :one
-SELECT users.id, login, first_name, last_name, role, password
+SELECT users.id, login, first_name, last_name, role, password, zones, must_change_password,
+    array(SELECT permission FROM role_permissions
+        WHERE role_permissions.role_id = users.role_id ORDER BY permission)::text[] AS permissions
 FROM users INNER JOIN roles ON users.role_id = roles.id
 WHERE users.login = $1;
 
+-- name: GetUserBySubject :one
+SELECT users.id, login, first_name, last_name, role, zones,
+    array(SELECT permission FROM role_permissions
+        WHERE role_permissions.role_id = users.role_id ORDER BY permission)::text[] AS permissions
+FROM users INNER JOIN roles ON users.role_id = roles.id
+WHERE users.oidc_subject = $1;
+
+-- name: SetUserSubject :exec
+UPDATE users
+SET oidc_subject = $2
+WHERE id = $1;
+
+-- name: SetUserPassword :execrows
+UPDATE users
+SET password = $2, must_change_password = $3
+WHERE id = $1;
+
+-- name: SetUserRole :execrows
+UPDATE users
+SET role_id = (SELECT id FROM roles WHERE role = $2)
+WHERE users.id = $1;
+
 -- name: GetAllUsers :many
-SELECT users.id, login, first_name, last_name, role
+SELECT users.id, login, first_name, last_name, role, zones
 FROM users INNER JOIN roles ON users.role_id = roles.id;
 
 -- name: DeleteUser :exec
@@ -72,5 +283,139 @@ WHERE users.id = $1;
 UPDATE users
 SET login = $2, first_name = $3, last_name = $4, 
     role_id = (SELECT id FROM roles WHERE role = $5 AND role IS NOT NULL),
-    password = $6
+    password = $6, zones = $7
 WHERE users.id = $1;
+
+-- name: CreateInvitation :one
+INSERT INTO invitations (token_hash, role_id, zones, created_by, expires_at)
+VALUES (
+    $1,
+    (SELECT roles.id FROM roles WHERE roles.role = $2),
+    $3,
+    $4,
+    $5
+)
+RETURNING id, created_at;
+
+-- name: GetInvitations :many
+SELECT invitations.id, role, zones, created_by, created_at, expires_at, used_by, used_at
+FROM invitations INNER JOIN roles ON invitations.role_id = roles.id
+ORDER BY invitations.id;
+
+-- name: UseInvitation :one
+UPDATE invitations
+SET used_by = $2, used_at = now()
+FROM roles
+WHERE invitations.role_id = roles.id AND token_hash = $1
+    AND used_at IS NULL AND expires_at > now()
+RETURNING roles.role, invitations.zones;
+
+-- name: DeleteInvitation :execrows
+DELETE FROM invitations
+WHERE id = $1;
+
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (name, prefix, key_hash, role_id, zones, created_by, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    (SELECT roles.id FROM roles WHERE roles.role = $4),
+    $5,
+    $6,
+    $7
+)
+RETURNING id, created_at;
+
+-- name: GetAPIKeys :many
+SELECT api_keys.id, name, prefix, role, zones, created_by, created_at, expires_at, last_used_at, revoked_at
+FROM api_keys INNER JOIN roles ON api_keys.role_id = roles.id
+ORDER BY api_keys.id;
+
+-- name: GetAPIKeyByHash :one
+SELECT api_keys.id, name, prefix, role, zones, created_by, created_at, expires_at, last_used_at, revoked_at,
+    array(SELECT permission FROM role_permissions
+        WHERE role_permissions.role_id = api_keys.role_id ORDER BY permission)::text[] AS permissions
+FROM api_keys INNER JOIN roles ON api_keys.role_id = roles.id
+WHERE key_hash = $1;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = now()
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = $2
+WHERE id = $1;
+
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (token_hash, user_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: GetRefreshToken :one
+SELECT refresh_tokens.id, user_id, login, expires_at, revoked_at
+FROM refresh_tokens INNER JOIN users ON refresh_tokens.user_id = users.id
+WHERE token_hash = $1;
+
+-- name: RevokeRefreshToken :execrows
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: DeleteExpiredRefreshTokens :execrows
+DELETE FROM refresh_tokens
+WHERE expires_at < $1;
+
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (jti, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (jti) DO NOTHING;
+
+-- name: IsTokenRevoked :one
+SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1);
+
+-- name: DeleteExpiredRevokedTokens :execrows
+DELETE FROM revoked_tokens
+WHERE expires_at < $1;
+
+-- name: GetRoles :many
+SELECT roles.id, role,
+    array(SELECT permission FROM role_permissions
+        WHERE role_permissions.role_id = roles.id ORDER BY permission)::text[] AS permissions
+FROM roles
+ORDER BY roles.id;
+
+-- name: GetRole :one
+SELECT roles.id, role,
+    array(SELECT permission FROM role_permissions
+        WHERE role_permissions.role_id = roles.id ORDER BY permission)::text[] AS permissions
+FROM roles
+WHERE roles.id = $1;
+
+-- name: CreateRole :one
+INSERT INTO roles (role)
+VALUES ($1)
+RETURNING id;
+
+-- name: UpdateRole :execrows
+UPDATE roles
+SET role = $2
+WHERE id = $1;
+
+-- name: DeleteRole :execrows
+DELETE FROM roles
+WHERE id = $1;
+
+-- name: AddRolePermissions :exec
+INSERT INTO role_permissions (role_id, permission)
+SELECT @role_id::int, unnest(@permissions::text[]);
+
+-- name: DeleteRolePermissions :exec
+DELETE FROM role_permissions
+WHERE role_id = $1;
@@ -20,11 +20,126 @@ CREATE TABLE resource_records (
     UNIQUE(domain, data, type_id, class_id) 
 );
 
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Indexes for exact, prefix and suffix search of the domain.
+CREATE INDEX resource_records_domain_idx
+ON resource_records (lower(rtrim(domain, '.')) text_pattern_ops);
+CREATE INDEX resource_records_reversed_domain_idx
+ON resource_records (reverse(lower(rtrim(domain, '.'))) text_pattern_ops);
+
+-- Indexes for substring search of the domain and data.
+CREATE INDEX resource_records_domain_trgm_idx
+ON resource_records USING gin (lower(domain) gin_trgm_ops);
+CREATE INDEX resource_records_data_trgm_idx
+ON resource_records USING gin (data gin_trgm_ops);
+
+-- Indexes for sorting of the search results and seeking to the cursor of the page.
+CREATE INDEX resource_records_domain_sort_idx
+ON resource_records (lower(domain), id);
+CREATE INDEX resource_records_ttl_sort_idx
+ON resource_records ((COALESCE(time_to_live, 0)), id);
+
+-- record_history contain every change of the resource records with state
+-- of the record before and after the change.
+CREATE TABLE record_history(
+    id SERIAL PRIMARY KEY,
+    record_id INTEGER NOT NULL,
+    operation TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    before JSONB,
+    after JSONB
+);
+
+CREATE INDEX record_history_record_id_idx ON record_history (record_id, changed_at);
+CREATE INDEX record_history_changed_at_idx ON record_history (changed_at);
+
+-- views split answers by the network of the client. Records of the zones
+-- assigned to the view and records assigned to it directly are answered
+-- only to the clients of the view.
+CREATE TABLE views(
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    networks CIDR[] NOT NULL DEFAULT '{}',
+    zones TEXT[] NOT NULL DEFAULT '{}'
+);
+
+CREATE TABLE record_views(
+    record_id INTEGER NOT NULL,
+    view_id INTEGER NOT NULL,
+    PRIMARY KEY (record_id, view_id),
+    FOREIGN KEY (record_id) REFERENCES resource_records(id) ON DELETE CASCADE,
+    FOREIGN KEY (view_id) REFERENCES views(id) ON DELETE CASCADE
+);
+
+-- acl_rules allow actions for the clients from the network.
+CREATE TABLE acl_rules(
+    id SERIAL PRIMARY KEY,
+    network CIDR NOT NULL UNIQUE,
+    allow_query BOOLEAN NOT NULL DEFAULT false,
+    allow_recursion BOOLEAN NOT NULL DEFAULT false,
+    allow_transfer BOOLEAN NOT NULL DEFAULT false,
+    allow_update BOOLEAN NOT NULL DEFAULT false
+);
+
+-- query_stats contain count of the same DNS queries made in one minute.
+CREATE TABLE query_stats(
+    minute TIMESTAMPTZ NOT NULL,
+    client INET NOT NULL,
+    domain TEXT NOT NULL,
+    qtype TEXT NOT NULL,
+    rcode TEXT NOT NULL,
+    blocked BOOLEAN NOT NULL,
+    count BIGINT NOT NULL,
+    PRIMARY KEY (minute, client, domain, qtype, rcode, blocked)
+);
+
+-- query_log contain every answered DNS query.
+CREATE TABLE query_log(
+    id BIGSERIAL PRIMARY KEY,
+    time TIMESTAMPTZ NOT NULL,
+    client INET NOT NULL,
+    protocol TEXT NOT NULL,
+    qname TEXT NOT NULL,
+    qtype TEXT NOT NULL,
+    rcode TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    upstream TEXT NOT NULL DEFAULT '',
+    latency_us BIGINT NOT NULL,
+    cache_hit BOOLEAN NOT NULL DEFAULT false,
+    block_reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX query_log_time_idx ON query_log (time);
+CREATE INDEX query_log_client_idx ON query_log USING gist (client inet_ops);
+CREATE INDEX query_log_qname_idx ON query_log (qname);
+
 CREATE TABLE roles(
     id SERIAL PRIMARY KEY,
     role VARCHAR(20) NOT NULL UNIQUE
 );
 
+-- role_permissions grant permissions to the roles, e.g. 'records:write'.
+CREATE TABLE role_permissions(
+    role_id INTEGER NOT NULL,
+    permission TEXT NOT NULL,
+    PRIMARY KEY (role_id, permission),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+-- Default roles keep rights of the users that existed before permissions.
+INSERT INTO roles (role) VALUES ('admin'), ('user');
+INSERT INTO role_permissions (role_id, permission)
+SELECT roles.id, permission
+FROM roles, unnest(ARRAY['records:read', 'records:write', 'zones:admin', 'users:admin',
+    'logs:read', 'settings:admin']) AS permission
+WHERE roles.role = 'admin'
+UNION ALL
+SELECT roles.id, permission
+FROM roles, unnest(ARRAY['records:read', 'records:write', 'logs:read']) AS permission
+WHERE roles.role = 'user';
+
 CREATE TABLE users(
     id SERIAL PRIMARY KEY,
     login VARCHAR(16) NOT NULL UNIQUE,
@@ -32,5 +147,106 @@ CREATE TABLE users(
     last_name VARCHAR(20) NOT NULL,
     password VARCHAR(72) NOT NULL,
     role_id INTEGER NOT NULL,
+    -- zones restrict changes of the user to these zones, empty list allow all zones.
+    zones TEXT[] NOT NULL DEFAULT '{}',
+    -- oidc_subject is the issuer and the subject, separated by space, of the user
+    -- provisioned by the OpenID Connect login, NULL for local users.
+    oidc_subject TEXT UNIQUE,
+    -- must_change_password is set for temporary passwords given by the admin
+    -- or generated on the first start.
+    must_change_password BOOLEAN NOT NULL DEFAULT false,
+    FOREIGN KEY (role_id) REFERENCES roles(id)
+);
+
+-- api_keys are long-lived credentials of scripts and service accounts.
+-- Only SHA-256 hash of the key is stored, prefix of the key identify it in lists.
+CREATE TABLE api_keys(
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash BYTEA NOT NULL UNIQUE,
+    role_id INTEGER NOT NULL,
+    zones TEXT[] NOT NULL DEFAULT '{}',
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
     FOREIGN KEY (role_id) REFERENCES roles(id)
 );
+
+-- invitations allow self-registration of one user with the preset role and zones.
+-- Only SHA-256 hash of the token is stored.
+CREATE TABLE invitations(
+    id SERIAL PRIMARY KEY,
+    token_hash BYTEA NOT NULL UNIQUE,
+    role_id INTEGER NOT NULL,
+    zones TEXT[] NOT NULL DEFAULT '{}',
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_by TEXT,
+    used_at TIMESTAMPTZ,
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+-- refresh_tokens issue new access tokens of the users. Only SHA-256 hash of the token
+-- is stored, used token is revoked and replaced with the new one.
+CREATE TABLE refresh_tokens(
+    id SERIAL PRIMARY KEY,
+    token_hash BYTEA NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- revoked_tokens contain IDs of the access tokens revoked before their expiry,
+-- rows are deleted when tokens expire.
+CREATE TABLE revoked_tokens(
+    jti TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- notify_change publish every change of the row to the 'changes' channel,
+-- so all server instances that share the database can update their state.
+CREATE FUNCTION notify_change() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('changes', json_build_object(
+        'table', TG_TABLE_NAME,
+        'operation', TG_OP,
+        'id', CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER resource_records_notify_change
+AFTER INSERT OR UPDATE OR DELETE ON resource_records
+FOR EACH ROW EXECUTE FUNCTION notify_change();
+
+CREATE TRIGGER views_notify_change
+AFTER INSERT OR UPDATE OR DELETE ON views
+FOR EACH ROW EXECUTE FUNCTION notify_change();
+
+CREATE TRIGGER acl_rules_notify_change
+AFTER INSERT OR UPDATE OR DELETE ON acl_rules
+FOR EACH ROW EXECUTE FUNCTION notify_change();
+
+-- notify_record_view_change publish change of the view when records are
+-- assigned to it or removed from it.
+CREATE FUNCTION notify_record_view_change() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('changes', json_build_object(
+        'table', 'views',
+        'operation', 'UPDATE',
+        'id', CASE WHEN TG_OP = 'DELETE' THEN OLD.view_id ELSE NEW.view_id END
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_views_notify_change
+AFTER INSERT OR DELETE ON record_views
+FOR EACH ROW EXECUTE FUNCTION notify_record_view_change();
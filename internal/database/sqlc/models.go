@@ -5,14 +5,100 @@
 package sqlc
 
 import (
+	"net/netip"
+
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AclRule struct {
+	ID             int32        `db:"id" json:"id"`
+	Network        netip.Prefix `db:"network" json:"network"`
+	AllowQuery     bool         `db:"allow_query" json:"allow_query"`
+	AllowRecursion bool         `db:"allow_recursion" json:"allow_recursion"`
+	AllowTransfer  bool         `db:"allow_transfer" json:"allow_transfer"`
+	AllowUpdate    bool         `db:"allow_update" json:"allow_update"`
+}
+
+type ApiKey struct {
+	ID         int32              `db:"id" json:"id"`
+	Name       string             `db:"name" json:"name"`
+	Prefix     string             `db:"prefix" json:"prefix"`
+	KeyHash    []byte             `db:"key_hash" json:"key_hash"`
+	RoleID     int32              `db:"role_id" json:"role_id"`
+	Zones      []string           `db:"zones" json:"zones"`
+	CreatedBy  string             `db:"created_by" json:"created_by"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ExpiresAt  pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `db:"last_used_at" json:"last_used_at"`
+	RevokedAt  pgtype.Timestamptz `db:"revoked_at" json:"revoked_at"`
+}
+
 type Class struct {
 	ID    int32  `db:"id" json:"id"`
 	Class string `db:"class" json:"class"`
 }
 
+type Invitation struct {
+	ID        int32              `db:"id" json:"id"`
+	TokenHash []byte             `db:"token_hash" json:"token_hash"`
+	RoleID    int32              `db:"role_id" json:"role_id"`
+	Zones     []string           `db:"zones" json:"zones"`
+	CreatedBy string             `db:"created_by" json:"created_by"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	UsedBy    pgtype.Text        `db:"used_by" json:"used_by"`
+	UsedAt    pgtype.Timestamptz `db:"used_at" json:"used_at"`
+}
+
+type QueryLog struct {
+	ID          int64              `db:"id" json:"id"`
+	Time        pgtype.Timestamptz `db:"time" json:"time"`
+	Client      netip.Addr         `db:"client" json:"client"`
+	Protocol    string             `db:"protocol" json:"protocol"`
+	Qname       string             `db:"qname" json:"qname"`
+	Qtype       string             `db:"qtype" json:"qtype"`
+	Rcode       string             `db:"rcode" json:"rcode"`
+	Answer      string             `db:"answer" json:"answer"`
+	Upstream    string             `db:"upstream" json:"upstream"`
+	LatencyUs   int64              `db:"latency_us" json:"latency_us"`
+	CacheHit    bool               `db:"cache_hit" json:"cache_hit"`
+	BlockReason string             `db:"block_reason" json:"block_reason"`
+}
+
+type QueryStat struct {
+	Minute  pgtype.Timestamptz `db:"minute" json:"minute"`
+	Client  netip.Addr         `db:"client" json:"client"`
+	Domain  string             `db:"domain" json:"domain"`
+	Qtype   string             `db:"qtype" json:"qtype"`
+	Rcode   string             `db:"rcode" json:"rcode"`
+	Blocked bool               `db:"blocked" json:"blocked"`
+	Count   int64              `db:"count" json:"count"`
+}
+
+type RecordHistory struct {
+	ID        int32              `db:"id" json:"id"`
+	RecordID  int32              `db:"record_id" json:"record_id"`
+	Operation string             `db:"operation" json:"operation"`
+	Actor     string             `db:"actor" json:"actor"`
+	ChangedAt pgtype.Timestamptz `db:"changed_at" json:"changed_at"`
+	Before    []byte             `db:"before" json:"before"`
+	After     []byte             `db:"after" json:"after"`
+}
+
+type RecordView struct {
+	RecordID int32 `db:"record_id" json:"record_id"`
+	ViewID   int32 `db:"view_id" json:"view_id"`
+}
+
+type RefreshToken struct {
+	ID        int32              `db:"id" json:"id"`
+	TokenHash []byte             `db:"token_hash" json:"token_hash"`
+	UserID    int32              `db:"user_id" json:"user_id"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	RevokedAt pgtype.Timestamptz `db:"revoked_at" json:"revoked_at"`
+}
+
 type ResourceRecord struct {
 	ID         int32       `db:"id" json:"id"`
 	Domain     string      `db:"domain" json:"domain"`
@@ -22,21 +108,41 @@ type ResourceRecord struct {
 	TimeToLive pgtype.Int4 `db:"time_to_live" json:"time_to_live"`
 }
 
+type RevokedToken struct {
+	Jti       string             `db:"jti" json:"jti"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+}
+
 type Role struct {
 	ID   int32  `db:"id" json:"id"`
 	Role string `db:"role" json:"role"`
 }
 
+type RolePermission struct {
+	RoleID     int32  `db:"role_id" json:"role_id"`
+	Permission string `db:"permission" json:"permission"`
+}
+
 type Type struct {
 	ID   int32  `db:"id" json:"id"`
 	Type string `db:"type" json:"type"`
 }
 
 type User struct {
-	ID        int32  `db:"id" json:"id"`
-	Login     string `db:"login" json:"login"`
-	FirstName string `db:"first_name" json:"first_name"`
-	LastName  string `db:"last_name" json:"last_name"`
-	Password  string `db:"password" json:"password"`
-	RoleID    int32  `db:"role_id" json:"role_id"`
+	ID                 int32       `db:"id" json:"id"`
+	Login              string      `db:"login" json:"login"`
+	FirstName          string      `db:"first_name" json:"first_name"`
+	LastName           string      `db:"last_name" json:"last_name"`
+	Password           string      `db:"password" json:"password"`
+	RoleID             int32       `db:"role_id" json:"role_id"`
+	Zones              []string    `db:"zones" json:"zones"`
+	OidcSubject        pgtype.Text `db:"oidc_subject" json:"oidc_subject"`
+	MustChangePassword bool        `db:"must_change_password" json:"must_change_password"`
+}
+
+type View struct {
+	ID       int32          `db:"id" json:"id"`
+	Name     string         `db:"name" json:"name"`
+	Networks []netip.Prefix `db:"networks" json:"networks"`
+	Zones    []string       `db:"zones" json:"zones"`
 }
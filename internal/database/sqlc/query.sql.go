@@ -7,10 +7,274 @@ package sqlc
 
 import (
 	"context"
+	"net/netip"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addQueryLog = `-- name: AddQueryLog :exec
+INSERT INTO query_log (time, client, protocol, qname, qtype, rcode, answer, upstream, latency_us, cache_hit, block_reason)
+SELECT unnest($1::timestamptz[]), unnest($2::inet[]), unnest($3::text[]),
+    unnest($4::text[]), unnest($5::text[]), unnest($6::text[]), unnest($7::text[]),
+    unnest($8::text[]), unnest($9::bigint[]), unnest($10::boolean[]),
+    unnest($11::text[])
+`
+
+type AddQueryLogParams struct {
+	Times        []pgtype.Timestamptz `db:"times" json:"times"`
+	Clients      []netip.Addr         `db:"clients" json:"clients"`
+	Protocols    []string             `db:"protocols" json:"protocols"`
+	Qnames       []string             `db:"qnames" json:"qnames"`
+	Qtypes       []string             `db:"qtypes" json:"qtypes"`
+	Rcodes       []string             `db:"rcodes" json:"rcodes"`
+	Answers      []string             `db:"answers" json:"answers"`
+	Upstreams    []string             `db:"upstreams" json:"upstreams"`
+	Latencies    []int64              `db:"latencies" json:"latencies"`
+	CacheHits    []bool               `db:"cache_hits" json:"cache_hits"`
+	BlockReasons []string             `db:"block_reasons" json:"block_reasons"`
+}
+
+func (q *Queries) AddQueryLog(ctx context.Context, arg AddQueryLogParams) error {
+	_, err := q.db.Exec(ctx, addQueryLog,
+		arg.Times,
+		arg.Clients,
+		arg.Protocols,
+		arg.Qnames,
+		arg.Qtypes,
+		arg.Rcodes,
+		arg.Answers,
+		arg.Upstreams,
+		arg.Latencies,
+		arg.CacheHits,
+		arg.BlockReasons,
+	)
+	return err
+}
+
+const addQueryStats = `-- name: AddQueryStats :exec
+INSERT INTO query_stats (minute, client, domain, qtype, rcode, blocked, count)
+SELECT unnest($1::timestamptz[]), unnest($2::inet[]), unnest($3::text[]),
+    unnest($4::text[]), unnest($5::text[]), unnest($6::boolean[]), unnest($7::bigint[])
+ON CONFLICT (minute, client, domain, qtype, rcode, blocked)
+DO UPDATE SET count = query_stats.count + EXCLUDED.count
+`
+
+type AddQueryStatsParams struct {
+	Minutes []pgtype.Timestamptz `db:"minutes" json:"minutes"`
+	Clients []netip.Addr         `db:"clients" json:"clients"`
+	Domains []string             `db:"domains" json:"domains"`
+	Qtypes  []string             `db:"qtypes" json:"qtypes"`
+	Rcodes  []string             `db:"rcodes" json:"rcodes"`
+	Blocked []bool               `db:"blocked" json:"blocked"`
+	Counts  []int64              `db:"counts" json:"counts"`
+}
+
+func (q *Queries) AddQueryStats(ctx context.Context, arg AddQueryStatsParams) error {
+	_, err := q.db.Exec(ctx, addQueryStats,
+		arg.Minutes,
+		arg.Clients,
+		arg.Domains,
+		arg.Qtypes,
+		arg.Rcodes,
+		arg.Blocked,
+		arg.Counts,
+	)
+	return err
+}
+
+const addRolePermissions = `-- name: AddRolePermissions :exec
+INSERT INTO role_permissions (role_id, permission)
+SELECT $1::int, unnest($2::text[])
+`
+
+type AddRolePermissionsParams struct {
+	RoleID      int32    `db:"role_id" json:"role_id"`
+	Permissions []string `db:"permissions" json:"permissions"`
+}
+
+func (q *Queries) AddRolePermissions(ctx context.Context, arg AddRolePermissionsParams) error {
+	_, err := q.db.Exec(ctx, addRolePermissions, arg.RoleID, arg.Permissions)
+	return err
+}
+
+const addViewRecords = `-- name: AddViewRecords :exec
+INSERT INTO record_views (record_id, view_id)
+SELECT unnest($1::int[]), $2::int
+`
+
+type AddViewRecordsParams struct {
+	RecordIds []int32 `db:"record_ids" json:"record_ids"`
+	ViewID    int32   `db:"view_id" json:"view_id"`
+}
+
+func (q *Queries) AddViewRecords(ctx context.Context, arg AddViewRecordsParams) error {
+	_, err := q.db.Exec(ctx, addViewRecords, arg.RecordIds, arg.ViewID)
+	return err
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT count(*) FROM users
+`
+
+func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createACLRule = `-- name: CreateACLRule :one
+INSERT INTO acl_rules (network, allow_query, allow_recursion, allow_transfer, allow_update)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id
+`
+
+type CreateACLRuleParams struct {
+	Network        netip.Prefix `db:"network" json:"network"`
+	AllowQuery     bool         `db:"allow_query" json:"allow_query"`
+	AllowRecursion bool         `db:"allow_recursion" json:"allow_recursion"`
+	AllowTransfer  bool         `db:"allow_transfer" json:"allow_transfer"`
+	AllowUpdate    bool         `db:"allow_update" json:"allow_update"`
+}
+
+func (q *Queries) CreateACLRule(ctx context.Context, arg CreateACLRuleParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createACLRule,
+		arg.Network,
+		arg.AllowQuery,
+		arg.AllowRecursion,
+		arg.AllowTransfer,
+		arg.AllowUpdate,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_keys (name, prefix, key_hash, role_id, zones, created_by, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    (SELECT roles.id FROM roles WHERE roles.role = $4),
+    $5,
+    $6,
+    $7
+)
+RETURNING id, created_at
+`
+
+type CreateAPIKeyParams struct {
+	Name      string             `db:"name" json:"name"`
+	Prefix    string             `db:"prefix" json:"prefix"`
+	KeyHash   []byte             `db:"key_hash" json:"key_hash"`
+	Role      string             `db:"role" json:"role"`
+	Zones     []string           `db:"zones" json:"zones"`
+	CreatedBy string             `db:"created_by" json:"created_by"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+}
+
+type CreateAPIKeyRow struct {
+	ID        int32              `db:"id" json:"id"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (CreateAPIKeyRow, error) {
+	row := q.db.QueryRow(ctx, createAPIKey,
+		arg.Name,
+		arg.Prefix,
+		arg.KeyHash,
+		arg.Role,
+		arg.Zones,
+		arg.CreatedBy,
+		arg.ExpiresAt,
+	)
+	var i CreateAPIKeyRow
+	err := row.Scan(&i.ID, &i.CreatedAt)
+	return i, err
+}
+
+const createInvitation = `-- name: CreateInvitation :one
+INSERT INTO invitations (token_hash, role_id, zones, created_by, expires_at)
+VALUES (
+    $1,
+    (SELECT roles.id FROM roles WHERE roles.role = $2),
+    $3,
+    $4,
+    $5
+)
+RETURNING id, created_at
+`
+
+type CreateInvitationParams struct {
+	TokenHash []byte             `db:"token_hash" json:"token_hash"`
+	Role      string             `db:"role" json:"role"`
+	Zones     []string           `db:"zones" json:"zones"`
+	CreatedBy string             `db:"created_by" json:"created_by"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+}
+
+type CreateInvitationRow struct {
+	ID        int32              `db:"id" json:"id"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) CreateInvitation(ctx context.Context, arg CreateInvitationParams) (CreateInvitationRow, error) {
+	row := q.db.QueryRow(ctx, createInvitation,
+		arg.TokenHash,
+		arg.Role,
+		arg.Zones,
+		arg.CreatedBy,
+		arg.ExpiresAt,
+	)
+	var i CreateInvitationRow
+	err := row.Scan(&i.ID, &i.CreatedAt)
+	return i, err
+}
+
+const createRecordHistory = `-- name: CreateRecordHistory :one
+INSERT INTO record_history (record_id, operation, actor, before, after)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id
+`
+
+type CreateRecordHistoryParams struct {
+	RecordID  int32  `db:"record_id" json:"record_id"`
+	Operation string `db:"operation" json:"operation"`
+	Actor     string `db:"actor" json:"actor"`
+	Before    []byte `db:"before" json:"before"`
+	After     []byte `db:"after" json:"after"`
+}
+
+func (q *Queries) CreateRecordHistory(ctx context.Context, arg CreateRecordHistoryParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createRecordHistory,
+		arg.RecordID,
+		arg.Operation,
+		arg.Actor,
+		arg.Before,
+		arg.After,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createRefreshToken = `-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (token_hash, user_id, expires_at)
+VALUES ($1, $2, $3)
+`
+
+type CreateRefreshTokenParams struct {
+	TokenHash []byte             `db:"token_hash" json:"token_hash"`
+	UserID    int32              `db:"user_id" json:"user_id"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) error {
+	_, err := q.db.Exec(ctx, createRefreshToken, arg.TokenHash, arg.UserID, arg.ExpiresAt)
+	return err
+}
+
 const createResourceRecord = `-- name: CreateResourceRecord :one
 INSERT INTO resource_records (domain, data, type_id, class_id, time_to_live)
 VALUES (
@@ -44,24 +308,77 @@ func (q *Queries) CreateResourceRecord(ctx context.Context, arg CreateResourceRe
 	return id, err
 }
 
+const createResourceRecordWithID = `-- name: CreateResourceRecordWithID :one
+INSERT INTO resource_records (id, domain, data, type_id, class_id, time_to_live)
+VALUES (
+    $1,
+    $2,
+    $3,
+    (SELECT id FROM types WHERE type = $4),
+    (SELECT id FROM classes WHERE class = $5),
+    $6
+)
+RETURNING id
+`
+
+type CreateResourceRecordWithIDParams struct {
+	ID         int32       `db:"id" json:"id"`
+	Domain     string      `db:"domain" json:"domain"`
+	Data       string      `db:"data" json:"data"`
+	Type       string      `db:"type" json:"type"`
+	Class      string      `db:"class" json:"class"`
+	TimeToLive pgtype.Int4 `db:"time_to_live" json:"time_to_live"`
+}
+
+func (q *Queries) CreateResourceRecordWithID(ctx context.Context, arg CreateResourceRecordWithIDParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createResourceRecordWithID,
+		arg.ID,
+		arg.Domain,
+		arg.Data,
+		arg.Type,
+		arg.Class,
+		arg.TimeToLive,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createRole = `-- name: CreateRole :one
+INSERT INTO roles (role)
+VALUES ($1)
+RETURNING id
+`
+
+func (q *Queries) CreateRole(ctx context.Context, role string) (int32, error) {
+	row := q.db.QueryRow(ctx, createRole, role)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (login, first_name, last_name,password,role_id)
+INSERT INTO users (login, first_name, last_name,password,role_id, zones, must_change_password)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    (SELECT roles.id FROM roles WHERE roles.role = $5)
+    (SELECT roles.id FROM roles WHERE roles.role = $5),
+    $6,
+    $7
 )
 RETURNING id
 `
 
 type CreateUserParams struct {
-	Login     string `db:"login" json:"login"`
-	FirstName string `db:"first_name" json:"first_name"`
-	LastName  string `db:"last_name" json:"last_name"`
-	Password  string `db:"password" json:"password"`
-	Role      string `db:"role" json:"role"`
+	Login              string   `db:"login" json:"login"`
+	FirstName          string   `db:"first_name" json:"first_name"`
+	LastName           string   `db:"last_name" json:"last_name"`
+	Password           string   `db:"password" json:"password"`
+	Role               string   `db:"role" json:"role"`
+	Zones              []string `db:"zones" json:"zones"`
+	MustChangePassword bool     `db:"must_change_password" json:"must_change_password"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (int32, error) {
@@ -71,12 +388,108 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (int32,
 		arg.LastName,
 		arg.Password,
 		arg.Role,
+		arg.Zones,
+		arg.MustChangePassword,
 	)
 	var id int32
 	err := row.Scan(&id)
 	return id, err
 }
 
+const createView = `-- name: CreateView :one
+INSERT INTO views (name, networks, zones)
+VALUES ($1, $2, $3)
+RETURNING id
+`
+
+type CreateViewParams struct {
+	Name     string         `db:"name" json:"name"`
+	Networks []netip.Prefix `db:"networks" json:"networks"`
+	Zones    []string       `db:"zones" json:"zones"`
+}
+
+func (q *Queries) CreateView(ctx context.Context, arg CreateViewParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createView, arg.Name, arg.Networks, arg.Zones)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const deleteACLRule = `-- name: DeleteACLRule :exec
+DELETE FROM acl_rules
+WHERE id = $1
+`
+
+func (q *Queries) DeleteACLRule(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteACLRule, id)
+	return err
+}
+
+const deleteExpiredRefreshTokens = `-- name: DeleteExpiredRefreshTokens :execrows
+DELETE FROM refresh_tokens
+WHERE expires_at < $1
+`
+
+func (q *Queries) DeleteExpiredRefreshTokens(ctx context.Context, expiresAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredRefreshTokens, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredRevokedTokens = `-- name: DeleteExpiredRevokedTokens :execrows
+DELETE FROM revoked_tokens
+WHERE expires_at < $1
+`
+
+func (q *Queries) DeleteExpiredRevokedTokens(ctx context.Context, expiresAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredRevokedTokens, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteInvitation = `-- name: DeleteInvitation :execrows
+DELETE FROM invitations
+WHERE id = $1
+`
+
+func (q *Queries) DeleteInvitation(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteInvitation, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteQueryLogBefore = `-- name: DeleteQueryLogBefore :execrows
+DELETE FROM query_log
+WHERE time < $1
+`
+
+func (q *Queries) DeleteQueryLogBefore(ctx context.Context, time pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteQueryLogBefore, time)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteQueryStatsBefore = `-- name: DeleteQueryStatsBefore :execrows
+DELETE FROM query_stats
+WHERE minute < $1
+`
+
+func (q *Queries) DeleteQueryStatsBefore(ctx context.Context, minute pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteQueryStatsBefore, minute)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteResourceRecord = `-- name: DeleteResourceRecord :exec
 DELETE FROM resource_records
 WHERE id = $1
@@ -87,6 +500,29 @@ func (q *Queries) DeleteResourceRecord(ctx context.Context, id int32) error {
 	return err
 }
 
+const deleteRole = `-- name: DeleteRole :execrows
+DELETE FROM roles
+WHERE id = $1
+`
+
+func (q *Queries) DeleteRole(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRole, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteRolePermissions = `-- name: DeleteRolePermissions :exec
+DELETE FROM role_permissions
+WHERE role_id = $1
+`
+
+func (q *Queries) DeleteRolePermissions(ctx context.Context, roleID int32) error {
+	_, err := q.db.Exec(ctx, deleteRolePermissions, roleID)
+	return err
+}
+
 const deleteUser = `-- name: DeleteUser :exec
 DELETE FROM users
 WHERE users.id = $1
@@ -97,6 +533,150 @@ func (q *Queries) DeleteUser(ctx context.Context, id int32) error {
 	return err
 }
 
+const deleteView = `-- name: DeleteView :exec
+DELETE FROM views
+WHERE id = $1
+`
+
+func (q *Queries) DeleteView(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteView, id)
+	return err
+}
+
+const deleteViewRecords = `-- name: DeleteViewRecords :exec
+DELETE FROM record_views
+WHERE view_id = $1
+`
+
+func (q *Queries) DeleteViewRecords(ctx context.Context, viewID int32) error {
+	_, err := q.db.Exec(ctx, deleteViewRecords, viewID)
+	return err
+}
+
+const getACLRules = `-- name: GetACLRules :many
+SELECT id, network, allow_query, allow_recursion, allow_transfer, allow_update
+FROM acl_rules
+ORDER BY id
+`
+
+func (q *Queries) GetACLRules(ctx context.Context) ([]AclRule, error) {
+	rows, err := q.db.Query(ctx, getACLRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AclRule
+	for rows.Next() {
+		var i AclRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Network,
+			&i.AllowQuery,
+			&i.AllowRecursion,
+			&i.AllowTransfer,
+			&i.AllowUpdate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAPIKeyByHash = `-- name: GetAPIKeyByHash :one
+SELECT api_keys.id, name, prefix, role, zones, created_by, created_at, expires_at, last_used_at, revoked_at,
+    array(SELECT permission FROM role_permissions
+        WHERE role_permissions.role_id = api_keys.role_id ORDER BY permission)::text[] AS permissions
+FROM api_keys INNER JOIN roles ON api_keys.role_id = roles.id
+WHERE key_hash = $1
+`
+
+type GetAPIKeyByHashRow struct {
+	ID          int32              `db:"id" json:"id"`
+	Name        string             `db:"name" json:"name"`
+	Prefix      string             `db:"prefix" json:"prefix"`
+	Role        string             `db:"role" json:"role"`
+	Zones       []string           `db:"zones" json:"zones"`
+	CreatedBy   string             `db:"created_by" json:"created_by"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ExpiresAt   pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	LastUsedAt  pgtype.Timestamptz `db:"last_used_at" json:"last_used_at"`
+	RevokedAt   pgtype.Timestamptz `db:"revoked_at" json:"revoked_at"`
+	Permissions []string           `db:"permissions" json:"permissions"`
+}
+
+func (q *Queries) GetAPIKeyByHash(ctx context.Context, keyHash []byte) (GetAPIKeyByHashRow, error) {
+	row := q.db.QueryRow(ctx, getAPIKeyByHash, keyHash)
+	var i GetAPIKeyByHashRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Prefix,
+		&i.Role,
+		&i.Zones,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.Permissions,
+	)
+	return i, err
+}
+
+const getAPIKeys = `-- name: GetAPIKeys :many
+SELECT api_keys.id, name, prefix, role, zones, created_by, created_at, expires_at, last_used_at, revoked_at
+FROM api_keys INNER JOIN roles ON api_keys.role_id = roles.id
+ORDER BY api_keys.id
+`
+
+type GetAPIKeysRow struct {
+	ID         int32              `db:"id" json:"id"`
+	Name       string             `db:"name" json:"name"`
+	Prefix     string             `db:"prefix" json:"prefix"`
+	Role       string             `db:"role" json:"role"`
+	Zones      []string           `db:"zones" json:"zones"`
+	CreatedBy  string             `db:"created_by" json:"created_by"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ExpiresAt  pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `db:"last_used_at" json:"last_used_at"`
+	RevokedAt  pgtype.Timestamptz `db:"revoked_at" json:"revoked_at"`
+}
+
+func (q *Queries) GetAPIKeys(ctx context.Context) ([]GetAPIKeysRow, error) {
+	rows, err := q.db.Query(ctx, getAPIKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAPIKeysRow
+	for rows.Next() {
+		var i GetAPIKeysRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Prefix,
+			&i.Role,
+			&i.Zones,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAllResourceRecord = `-- name: GetAllResourceRecord :many
 SELECT id , domain , data, type_id, class_id , time_to_live ,
 (SELECT type FROM types WHERE resource_records.type_id = types.id) AS type,
@@ -145,16 +725,17 @@ func (q *Queries) GetAllResourceRecord(ctx context.Context) ([]GetAllResourceRec
 }
 
 const getAllUsers = `-- name: GetAllUsers :many
-SELECT users.id, login, first_name, last_name, role
+SELECT users.id, login, first_name, last_name, role, zones
 FROM users INNER JOIN roles ON users.role_id = roles.id
 `
 
 type GetAllUsersRow struct {
-	ID        int32  `db:"id" json:"id"`
-	Login     string `db:"login" json:"login"`
-	FirstName string `db:"first_name" json:"first_name"`
-	LastName  string `db:"last_name" json:"last_name"`
-	Role      string `db:"role" json:"role"`
+	ID        int32    `db:"id" json:"id"`
+	Login     string   `db:"login" json:"login"`
+	FirstName string   `db:"first_name" json:"first_name"`
+	LastName  string   `db:"last_name" json:"last_name"`
+	Role      string   `db:"role" json:"role"`
+	Zones     []string `db:"zones" json:"zones"`
 }
 
 func (q *Queries) GetAllUsers(ctx context.Context) ([]GetAllUsersRow, error) {
@@ -172,6 +753,7 @@ func (q *Queries) GetAllUsers(ctx context.Context) ([]GetAllUsersRow, error) {
 			&i.FirstName,
 			&i.LastName,
 			&i.Role,
+			&i.Zones,
 		); err != nil {
 			return nil, err
 		}
@@ -183,6 +765,263 @@ func (q *Queries) GetAllUsers(ctx context.Context) ([]GetAllUsersRow, error) {
 	return items, nil
 }
 
+const getAllViews = `-- name: GetAllViews :many
+SELECT views.id, views.name, views.networks, views.zones,
+    array_remove(array_agg(record_views.record_id ORDER BY record_views.record_id), NULL)::int[] AS record_ids
+FROM views LEFT JOIN record_views ON views.id = record_views.view_id
+GROUP BY views.id
+ORDER BY views.id
+`
+
+type GetAllViewsRow struct {
+	ID        int32          `db:"id" json:"id"`
+	Name      string         `db:"name" json:"name"`
+	Networks  []netip.Prefix `db:"networks" json:"networks"`
+	Zones     []string       `db:"zones" json:"zones"`
+	RecordIds []int32        `db:"record_ids" json:"record_ids"`
+}
+
+func (q *Queries) GetAllViews(ctx context.Context) ([]GetAllViewsRow, error) {
+	rows, err := q.db.Query(ctx, getAllViews)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAllViewsRow
+	for rows.Next() {
+		var i GetAllViewsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Networks,
+			&i.Zones,
+			&i.RecordIds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFirstZoneChangesSince = `-- name: GetFirstZoneChangesSince :many
+SELECT DISTINCT ON (record_id) id, record_id, operation, actor, changed_at, before, after
+FROM record_history
+WHERE changed_at > $1::timestamptz AND (
+    lower(rtrim(before->>'domain', '.')) = $2::text
+    OR right(lower(rtrim(before->>'domain', '.')), length($2::text) + 1) = '.' || $2::text
+    OR lower(rtrim(after->>'domain', '.')) = $2::text
+    OR right(lower(rtrim(after->>'domain', '.')), length($2::text) + 1) = '.' || $2::text
+)
+ORDER BY record_id, changed_at, id
+`
+
+type GetFirstZoneChangesSinceParams struct {
+	Since pgtype.Timestamptz `db:"since" json:"since"`
+	Zone  string             `db:"zone" json:"zone"`
+}
+
+func (q *Queries) GetFirstZoneChangesSince(ctx context.Context, arg GetFirstZoneChangesSinceParams) ([]RecordHistory, error) {
+	rows, err := q.db.Query(ctx, getFirstZoneChangesSince, arg.Since, arg.Zone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RecordHistory
+	for rows.Next() {
+		var i RecordHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.RecordID,
+			&i.Operation,
+			&i.Actor,
+			&i.ChangedAt,
+			&i.Before,
+			&i.After,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getInvitations = `-- name: GetInvitations :many
+SELECT invitations.id, role, zones, created_by, created_at, expires_at, used_by, used_at
+FROM invitations INNER JOIN roles ON invitations.role_id = roles.id
+ORDER BY invitations.id
+`
+
+type GetInvitationsRow struct {
+	ID        int32              `db:"id" json:"id"`
+	Role      string             `db:"role" json:"role"`
+	Zones     []string           `db:"zones" json:"zones"`
+	CreatedBy string             `db:"created_by" json:"created_by"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	UsedBy    pgtype.Text        `db:"used_by" json:"used_by"`
+	UsedAt    pgtype.Timestamptz `db:"used_at" json:"used_at"`
+}
+
+func (q *Queries) GetInvitations(ctx context.Context) ([]GetInvitationsRow, error) {
+	rows, err := q.db.Query(ctx, getInvitations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetInvitationsRow
+	for rows.Next() {
+		var i GetInvitationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Role,
+			&i.Zones,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.UsedBy,
+			&i.UsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getQueryVolume = `-- name: GetQueryVolume :many
+SELECT to_timestamp(floor(extract(epoch FROM minute) / $1::int) * $1::int)::timestamptz AS time,
+    sum(count)::bigint AS total,
+    COALESCE(sum(count) FILTER (WHERE blocked), 0)::bigint AS blocked
+FROM query_stats
+WHERE minute >= $2::timestamptz
+GROUP BY 1
+ORDER BY 1
+`
+
+type GetQueryVolumeParams struct {
+	Step  int32              `db:"step" json:"step"`
+	Since pgtype.Timestamptz `db:"since" json:"since"`
+}
+
+type GetQueryVolumeRow struct {
+	Time    pgtype.Timestamptz `db:"time" json:"time"`
+	Total   int64              `db:"total" json:"total"`
+	Blocked int64              `db:"blocked" json:"blocked"`
+}
+
+func (q *Queries) GetQueryVolume(ctx context.Context, arg GetQueryVolumeParams) ([]GetQueryVolumeRow, error) {
+	rows, err := q.db.Query(ctx, getQueryVolume, arg.Step, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetQueryVolumeRow
+	for rows.Next() {
+		var i GetQueryVolumeRow
+		if err := rows.Scan(&i.Time, &i.Total, &i.Blocked); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecordHistory = `-- name: GetRecordHistory :many
+SELECT id, record_id, operation, actor, changed_at, before, after
+FROM record_history
+WHERE record_id = $1
+ORDER BY changed_at DESC, id DESC
+`
+
+func (q *Queries) GetRecordHistory(ctx context.Context, recordID int32) ([]RecordHistory, error) {
+	rows, err := q.db.Query(ctx, getRecordHistory, recordID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RecordHistory
+	for rows.Next() {
+		var i RecordHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.RecordID,
+			&i.Operation,
+			&i.Actor,
+			&i.ChangedAt,
+			&i.Before,
+			&i.After,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecordHistoryEntry = `-- name: GetRecordHistoryEntry :one
+SELECT id, record_id, operation, actor, changed_at, before, after
+FROM record_history
+WHERE id = $1
+`
+
+func (q *Queries) GetRecordHistoryEntry(ctx context.Context, id int32) (RecordHistory, error) {
+	row := q.db.QueryRow(ctx, getRecordHistoryEntry, id)
+	var i RecordHistory
+	err := row.Scan(
+		&i.ID,
+		&i.RecordID,
+		&i.Operation,
+		&i.Actor,
+		&i.ChangedAt,
+		&i.Before,
+		&i.After,
+	)
+	return i, err
+}
+
+const getRefreshToken = `-- name: GetRefreshToken :one
+SELECT refresh_tokens.id, user_id, login, expires_at, revoked_at
+FROM refresh_tokens INNER JOIN users ON refresh_tokens.user_id = users.id
+WHERE token_hash = $1
+`
+
+type GetRefreshTokenRow struct {
+	ID        int32              `db:"id" json:"id"`
+	UserID    int32              `db:"user_id" json:"user_id"`
+	Login     string             `db:"login" json:"login"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	RevokedAt pgtype.Timestamptz `db:"revoked_at" json:"revoked_at"`
+}
+
+func (q *Queries) GetRefreshToken(ctx context.Context, tokenHash []byte) (GetRefreshTokenRow, error) {
+	row := q.db.QueryRow(ctx, getRefreshToken, tokenHash)
+	var i GetRefreshTokenRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Login,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
 const getResourceRecordByID = `-- name: GetResourceRecordByID :one
 SELECT id , domain , data, type_id, class_id , time_to_live ,
 (SELECT type FROM types WHERE resource_records.type_id = types.id) AS type,
@@ -271,19 +1110,223 @@ func (q *Queries) GetResourceRecords(ctx context.Context, arg GetResourceRecords
 	return items, nil
 }
 
+const getRole = `-- name: GetRole :one
+SELECT roles.id, role,
+    array(SELECT permission FROM role_permissions
+        WHERE role_permissions.role_id = roles.id ORDER BY permission)::text[] AS permissions
+FROM roles
+WHERE roles.id = $1
+`
+
+type GetRoleRow struct {
+	ID          int32    `db:"id" json:"id"`
+	Role        string   `db:"role" json:"role"`
+	Permissions []string `db:"permissions" json:"permissions"`
+}
+
+func (q *Queries) GetRole(ctx context.Context, id int32) (GetRoleRow, error) {
+	row := q.db.QueryRow(ctx, getRole, id)
+	var i GetRoleRow
+	err := row.Scan(&i.ID, &i.Role, &i.Permissions)
+	return i, err
+}
+
+const getRoles = `-- name: GetRoles :many
+SELECT roles.id, role,
+    array(SELECT permission FROM role_permissions
+        WHERE role_permissions.role_id = roles.id ORDER BY permission)::text[] AS permissions
+FROM roles
+ORDER BY roles.id
+`
+
+type GetRolesRow struct {
+	ID          int32    `db:"id" json:"id"`
+	Role        string   `db:"role" json:"role"`
+	Permissions []string `db:"permissions" json:"permissions"`
+}
+
+func (q *Queries) GetRoles(ctx context.Context) ([]GetRolesRow, error) {
+	rows, err := q.db.Query(ctx, getRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRolesRow
+	for rows.Next() {
+		var i GetRolesRow
+		if err := rows.Scan(&i.ID, &i.Role, &i.Permissions); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTopBlocked = `-- name: GetTopBlocked :many
+SELECT domain AS key, sum(count)::bigint AS count
+FROM query_stats
+WHERE minute >= $1::timestamptz AND blocked
+    AND ($2::text IS NULL OR qtype = $2)
+    AND ($3::text IS NULL OR rcode = $3)
+GROUP BY 1
+ORDER BY 2 DESC, 1
+LIMIT $4::int
+`
+
+type GetTopBlockedParams struct {
+	Since    pgtype.Timestamptz `db:"since" json:"since"`
+	Qtype    pgtype.Text        `db:"qtype" json:"qtype"`
+	Rcode    pgtype.Text        `db:"rcode" json:"rcode"`
+	PageSize int32              `db:"page_size" json:"page_size"`
+}
+
+type GetTopBlockedRow struct {
+	Key   string `db:"key" json:"key"`
+	Count int64  `db:"count" json:"count"`
+}
+
+func (q *Queries) GetTopBlocked(ctx context.Context, arg GetTopBlockedParams) ([]GetTopBlockedRow, error) {
+	rows, err := q.db.Query(ctx, getTopBlocked,
+		arg.Since,
+		arg.Qtype,
+		arg.Rcode,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTopBlockedRow
+	for rows.Next() {
+		var i GetTopBlockedRow
+		if err := rows.Scan(&i.Key, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTopClients = `-- name: GetTopClients :many
+SELECT host(client) AS key, sum(count)::bigint AS count
+FROM query_stats
+WHERE minute >= $1::timestamptz
+    AND ($2::text IS NULL OR qtype = $2)
+    AND ($3::text IS NULL OR rcode = $3)
+GROUP BY 1
+ORDER BY 2 DESC, 1
+LIMIT $4::int
+`
+
+type GetTopClientsParams struct {
+	Since    pgtype.Timestamptz `db:"since" json:"since"`
+	Qtype    pgtype.Text        `db:"qtype" json:"qtype"`
+	Rcode    pgtype.Text        `db:"rcode" json:"rcode"`
+	PageSize int32              `db:"page_size" json:"page_size"`
+}
+
+type GetTopClientsRow struct {
+	Key   string `db:"key" json:"key"`
+	Count int64  `db:"count" json:"count"`
+}
+
+func (q *Queries) GetTopClients(ctx context.Context, arg GetTopClientsParams) ([]GetTopClientsRow, error) {
+	rows, err := q.db.Query(ctx, getTopClients,
+		arg.Since,
+		arg.Qtype,
+		arg.Rcode,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTopClientsRow
+	for rows.Next() {
+		var i GetTopClientsRow
+		if err := rows.Scan(&i.Key, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTopDomains = `-- name: GetTopDomains :many
+SELECT domain AS key, sum(count)::bigint AS count
+FROM query_stats
+WHERE minute >= $1::timestamptz
+    AND ($2::text IS NULL OR qtype = $2)
+    AND ($3::text IS NULL OR rcode = $3)
+GROUP BY 1
+ORDER BY 2 DESC, 1
+LIMIT $4::int
+`
+
+type GetTopDomainsParams struct {
+	Since    pgtype.Timestamptz `db:"since" json:"since"`
+	Qtype    pgtype.Text        `db:"qtype" json:"qtype"`
+	Rcode    pgtype.Text        `db:"rcode" json:"rcode"`
+	PageSize int32              `db:"page_size" json:"page_size"`
+}
+
+type GetTopDomainsRow struct {
+	Key   string `db:"key" json:"key"`
+	Count int64  `db:"count" json:"count"`
+}
+
+func (q *Queries) GetTopDomains(ctx context.Context, arg GetTopDomainsParams) ([]GetTopDomainsRow, error) {
+	rows, err := q.db.Query(ctx, getTopDomains,
+		arg.Since,
+		arg.Qtype,
+		arg.Rcode,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTopDomainsRow
+	for rows.Next() {
+		var i GetTopDomainsRow
+		if err := rows.Scan(&i.Key, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUser = `-- name: GetUser :one
-SELECT users.id, login, first_name, last_name, role, password
+SELECT users.id, login, first_name, last_name, role, password, zones, must_change_password,
+    array(SELECT permission FROM role_permissions
+        WHERE role_permissions.role_id = users.role_id ORDER BY permission)::text[] AS permissions
 FROM users INNER JOIN roles ON users.role_id = roles.id
 WHERE users.login = $1
 `
 
 type GetUserRow struct {
-	ID        int32  `db:"id" json:"id"`
-	Login     string `db:"login" json:"login"`
-	FirstName string `db:"first_name" json:"first_name"`
-	LastName  string `db:"last_name" json:"last_name"`
-	Role      string `db:"role" json:"role"`
-	Password  string `db:"password" json:"password"`
+	ID                 int32    `db:"id" json:"id"`
+	Login              string   `db:"login" json:"login"`
+	FirstName          string   `db:"first_name" json:"first_name"`
+	LastName           string   `db:"last_name" json:"last_name"`
+	Role               string   `db:"role" json:"role"`
+	Password           string   `db:"password" json:"password"`
+	Zones              []string `db:"zones" json:"zones"`
+	MustChangePassword bool     `db:"must_change_password" json:"must_change_password"`
+	Permissions        []string `db:"permissions" json:"permissions"`
 }
 
 func (q *Queries) GetUser(ctx context.Context, login string) (GetUserRow, error) {
@@ -296,10 +1339,357 @@ func (q *Queries) GetUser(ctx context.Context, login string) (GetUserRow, error)
 		&i.LastName,
 		&i.Role,
 		&i.Password,
+		&i.Zones,
+		&i.MustChangePassword,
+		&i.Permissions,
+	)
+	return i, err
+}
+
+const getUserBySubject = `-- name: GetUserBySubject :one
+SELECT users.id, login, first_name, last_name, role, zones,
+    array(SELECT permission FROM role_permissions
+        WHERE role_permissions.role_id = users.role_id ORDER BY permission)::text[] AS permissions
+FROM users INNER JOIN roles ON users.role_id = roles.id
+WHERE users.oidc_subject = $1
+`
+
+type GetUserBySubjectRow struct {
+	ID          int32    `db:"id" json:"id"`
+	Login       string   `db:"login" json:"login"`
+	FirstName   string   `db:"first_name" json:"first_name"`
+	LastName    string   `db:"last_name" json:"last_name"`
+	Role        string   `db:"role" json:"role"`
+	Zones       []string `db:"zones" json:"zones"`
+	Permissions []string `db:"permissions" json:"permissions"`
+}
+
+func (q *Queries) GetUserBySubject(ctx context.Context, oidcSubject pgtype.Text) (GetUserBySubjectRow, error) {
+	row := q.db.QueryRow(ctx, getUserBySubject, oidcSubject)
+	var i GetUserBySubjectRow
+	err := row.Scan(
+		&i.ID,
+		&i.Login,
+		&i.FirstName,
+		&i.LastName,
+		&i.Role,
+		&i.Zones,
+		&i.Permissions,
 	)
 	return i, err
 }
 
+const getView = `-- name: GetView :one
+SELECT views.id, views.name, views.networks, views.zones,
+    array_remove(array_agg(record_views.record_id ORDER BY record_views.record_id), NULL)::int[] AS record_ids
+FROM views LEFT JOIN record_views ON views.id = record_views.view_id
+WHERE views.id = $1
+GROUP BY views.id
+`
+
+type GetViewRow struct {
+	ID        int32          `db:"id" json:"id"`
+	Name      string         `db:"name" json:"name"`
+	Networks  []netip.Prefix `db:"networks" json:"networks"`
+	Zones     []string       `db:"zones" json:"zones"`
+	RecordIds []int32        `db:"record_ids" json:"record_ids"`
+}
+
+func (q *Queries) GetView(ctx context.Context, id int32) (GetViewRow, error) {
+	row := q.db.QueryRow(ctx, getView, id)
+	var i GetViewRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Networks,
+		&i.Zones,
+		&i.RecordIds,
+	)
+	return i, err
+}
+
+const getZoneHistory = `-- name: GetZoneHistory :many
+SELECT id, record_id, operation, actor, changed_at, before, after
+FROM record_history
+WHERE lower(rtrim(before->>'domain', '.')) = $1::text
+    OR right(lower(rtrim(before->>'domain', '.')), length($1::text) + 1) = '.' || $1::text
+    OR lower(rtrim(after->>'domain', '.')) = $1::text
+    OR right(lower(rtrim(after->>'domain', '.')), length($1::text) + 1) = '.' || $1::text
+ORDER BY changed_at DESC, id DESC
+LIMIT $2::int
+`
+
+type GetZoneHistoryParams struct {
+	Zone     string `db:"zone" json:"zone"`
+	PageSize int32  `db:"page_size" json:"page_size"`
+}
+
+func (q *Queries) GetZoneHistory(ctx context.Context, arg GetZoneHistoryParams) ([]RecordHistory, error) {
+	rows, err := q.db.Query(ctx, getZoneHistory, arg.Zone, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RecordHistory
+	for rows.Next() {
+		var i RecordHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.RecordID,
+			&i.Operation,
+			&i.Actor,
+			&i.ChangedAt,
+			&i.Before,
+			&i.After,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isTokenRevoked = `-- name: IsTokenRevoked :one
+SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)
+`
+
+func (q *Queries) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	row := q.db.QueryRow(ctx, isTokenRevoked, jti)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = now()
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeAPIKey, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeRefreshToken = `-- name: RevokeRefreshToken :execrows
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshToken(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeRefreshToken, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeToken = `-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (jti, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (jti) DO NOTHING
+`
+
+type RevokeTokenParams struct {
+	Jti       string             `db:"jti" json:"jti"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) RevokeToken(ctx context.Context, arg RevokeTokenParams) error {
+	_, err := q.db.Exec(ctx, revokeToken, arg.Jti, arg.ExpiresAt)
+	return err
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, revokeUserRefreshTokens, userID)
+	return err
+}
+
+const searchQueryLog = `-- name: SearchQueryLog :many
+SELECT id, time, client, protocol, qname, qtype, rcode, answer, upstream, latency_us, cache_hit, block_reason
+FROM query_log
+WHERE ($1::bigint IS NULL OR id < $1)
+    AND ($2::cidr IS NULL OR client <<= $2)
+    AND ($3::text IS NULL OR qname = $3
+        OR right(qname, length($3) + 1) = '.' || $3)
+    AND ($4::text IS NULL OR qtype = $4)
+    AND ($5::text IS NULL OR rcode = $5)
+    AND ($6::boolean IS NULL OR (block_reason <> '') = $6)
+    AND ($7::timestamptz IS NULL OR time >= $7)
+    AND ($8::timestamptz IS NULL OR time < $8)
+ORDER BY id DESC
+LIMIT $9::int
+`
+
+type SearchQueryLogParams struct {
+	BeforeID pgtype.Int8        `db:"before_id" json:"before_id"`
+	Network  *netip.Prefix      `db:"network" json:"network"`
+	Domain   pgtype.Text        `db:"domain" json:"domain"`
+	Qtype    pgtype.Text        `db:"qtype" json:"qtype"`
+	Rcode    pgtype.Text        `db:"rcode" json:"rcode"`
+	Blocked  pgtype.Bool        `db:"blocked" json:"blocked"`
+	Since    pgtype.Timestamptz `db:"since" json:"since"`
+	Until    pgtype.Timestamptz `db:"until" json:"until"`
+	PageSize int32              `db:"page_size" json:"page_size"`
+}
+
+func (q *Queries) SearchQueryLog(ctx context.Context, arg SearchQueryLogParams) ([]QueryLog, error) {
+	rows, err := q.db.Query(ctx, searchQueryLog,
+		arg.BeforeID,
+		arg.Network,
+		arg.Domain,
+		arg.Qtype,
+		arg.Rcode,
+		arg.Blocked,
+		arg.Since,
+		arg.Until,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QueryLog
+	for rows.Next() {
+		var i QueryLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Time,
+			&i.Client,
+			&i.Protocol,
+			&i.Qname,
+			&i.Qtype,
+			&i.Rcode,
+			&i.Answer,
+			&i.Upstream,
+			&i.LatencyUs,
+			&i.CacheHit,
+			&i.BlockReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setUserPassword = `-- name: SetUserPassword :execrows
+UPDATE users
+SET password = $2, must_change_password = $3
+WHERE id = $1
+`
+
+type SetUserPasswordParams struct {
+	ID                 int32  `db:"id" json:"id"`
+	Password           string `db:"password" json:"password"`
+	MustChangePassword bool   `db:"must_change_password" json:"must_change_password"`
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserPassword, arg.ID, arg.Password, arg.MustChangePassword)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setUserRole = `-- name: SetUserRole :execrows
+UPDATE users
+SET role_id = (SELECT id FROM roles WHERE role = $2)
+WHERE users.id = $1
+`
+
+type SetUserRoleParams struct {
+	ID   int32  `db:"id" json:"id"`
+	Role string `db:"role" json:"role"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserRole, arg.ID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setUserSubject = `-- name: SetUserSubject :exec
+UPDATE users
+SET oidc_subject = $2
+WHERE id = $1
+`
+
+type SetUserSubjectParams struct {
+	ID          int32       `db:"id" json:"id"`
+	OidcSubject pgtype.Text `db:"oidc_subject" json:"oidc_subject"`
+}
+
+func (q *Queries) SetUserSubject(ctx context.Context, arg SetUserSubjectParams) error {
+	_, err := q.db.Exec(ctx, setUserSubject, arg.ID, arg.OidcSubject)
+	return err
+}
+
+const touchAPIKey = `-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = $2
+WHERE id = $1
+`
+
+type TouchAPIKeyParams struct {
+	ID         int32              `db:"id" json:"id"`
+	LastUsedAt pgtype.Timestamptz `db:"last_used_at" json:"last_used_at"`
+}
+
+func (q *Queries) TouchAPIKey(ctx context.Context, arg TouchAPIKeyParams) error {
+	_, err := q.db.Exec(ctx, touchAPIKey, arg.ID, arg.LastUsedAt)
+	return err
+}
+
+const updateACLRule = `-- name: UpdateACLRule :one
+UPDATE acl_rules
+SET network = $2, allow_query = $3, allow_recursion = $4, allow_transfer = $5, allow_update = $6
+WHERE id = $1
+RETURNING id
+`
+
+type UpdateACLRuleParams struct {
+	ID             int32        `db:"id" json:"id"`
+	Network        netip.Prefix `db:"network" json:"network"`
+	AllowQuery     bool         `db:"allow_query" json:"allow_query"`
+	AllowRecursion bool         `db:"allow_recursion" json:"allow_recursion"`
+	AllowTransfer  bool         `db:"allow_transfer" json:"allow_transfer"`
+	AllowUpdate    bool         `db:"allow_update" json:"allow_update"`
+}
+
+func (q *Queries) UpdateACLRule(ctx context.Context, arg UpdateACLRuleParams) (int32, error) {
+	row := q.db.QueryRow(ctx, updateACLRule,
+		arg.ID,
+		arg.Network,
+		arg.AllowQuery,
+		arg.AllowRecursion,
+		arg.AllowTransfer,
+		arg.AllowUpdate,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
 const updateResourceRecord = `-- name: UpdateResourceRecord :one
 UPDATE resource_records
 SET domain = $1,
@@ -334,21 +1724,41 @@ func (q *Queries) UpdateResourceRecord(ctx context.Context, arg UpdateResourceRe
 	return id, err
 }
 
+const updateRole = `-- name: UpdateRole :execrows
+UPDATE roles
+SET role = $2
+WHERE id = $1
+`
+
+type UpdateRoleParams struct {
+	ID   int32  `db:"id" json:"id"`
+	Role string `db:"role" json:"role"`
+}
+
+func (q *Queries) UpdateRole(ctx context.Context, arg UpdateRoleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateRole, arg.ID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateUser = `-- name: UpdateUser :exec
 UPDATE users
 SET login = $2, first_name = $3, last_name = $4, 
     role_id = (SELECT id FROM roles WHERE role = $5 AND role IS NOT NULL),
-    password = $6
+    password = $6, zones = $7
 WHERE users.id = $1
 `
 
 type UpdateUserParams struct {
-	ID        int32  `db:"id" json:"id"`
-	Login     string `db:"login" json:"login"`
-	FirstName string `db:"first_name" json:"first_name"`
-	LastName  string `db:"last_name" json:"last_name"`
-	Role      string `db:"role" json:"role"`
-	Password  string `db:"password" json:"password"`
+	ID        int32    `db:"id" json:"id"`
+	Login     string   `db:"login" json:"login"`
+	FirstName string   `db:"first_name" json:"first_name"`
+	LastName  string   `db:"last_name" json:"last_name"`
+	Role      string   `db:"role" json:"role"`
+	Password  string   `db:"password" json:"password"`
+	Zones     []string `db:"zones" json:"zones"`
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
@@ -359,6 +1769,59 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
 		arg.LastName,
 		arg.Role,
 		arg.Password,
+		arg.Zones,
 	)
 	return err
 }
+
+const updateView = `-- name: UpdateView :one
+UPDATE views
+SET name = $2, networks = $3, zones = $4
+WHERE id = $1
+RETURNING id
+`
+
+type UpdateViewParams struct {
+	ID       int32          `db:"id" json:"id"`
+	Name     string         `db:"name" json:"name"`
+	Networks []netip.Prefix `db:"networks" json:"networks"`
+	Zones    []string       `db:"zones" json:"zones"`
+}
+
+func (q *Queries) UpdateView(ctx context.Context, arg UpdateViewParams) (int32, error) {
+	row := q.db.QueryRow(ctx, updateView,
+		arg.ID,
+		arg.Name,
+		arg.Networks,
+		arg.Zones,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const useInvitation = `-- name: UseInvitation :one
+UPDATE invitations
+SET used_by = $2, used_at = now()
+FROM roles
+WHERE invitations.role_id = roles.id AND token_hash = $1
+    AND used_at IS NULL AND expires_at > now()
+RETURNING roles.role, invitations.zones
+`
+
+type UseInvitationParams struct {
+	TokenHash []byte      `db:"token_hash" json:"token_hash"`
+	UsedBy    pgtype.Text `db:"used_by" json:"used_by"`
+}
+
+type UseInvitationRow struct {
+	Role  string   `db:"role" json:"role"`
+	Zones []string `db:"zones" json:"zones"`
+}
+
+func (q *Queries) UseInvitation(ctx context.Context, arg UseInvitationParams) (UseInvitationRow, error) {
+	row := q.db.QueryRow(ctx, useInvitation, arg.TokenHash, arg.UsedBy)
+	var i UseInvitationRow
+	err := row.Scan(&i.Role, &i.Zones)
+	return i, err
+}
@@ -0,0 +1,265 @@
+package database
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// FieldError describe one invalid field of the resource record.
+type FieldError struct {
+	// Field is the name of invalid field.
+	Field string
+	// Message describe what is wrong with the field.
+	Message string
+}
+
+// ValidationError returned when resource record contain invalid fields.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		msgs = append(msgs, field.Field+": "+field.Message)
+	}
+	return "invalid resource record: " + strings.Join(msgs, "; ")
+}
+
+// Add add invalid field to the error.
+func (e *ValidationError) Add(field, msg string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: msg})
+}
+
+// NormalizeRecord check that resource record can be served and return it in the canonical form:
+// domain is lower case FQDN, type and class are upper case and data is in the presentation
+// format produced by miekg/dns. *ValidationError is returned for invalid records.
+func NormalizeRecord(rr ResourceRecord) (ResourceRecord, error) {
+	verr := &ValidationError{}
+
+	domain := strings.ToLower(dns.Fqdn(strings.TrimSpace(rr.Domain)))
+	if _, ok := dns.IsDomainName(domain); !ok || domain == "." && rr.Domain != "." {
+		verr.Add("domain", fmt.Sprintf("%q is not a valid domain name", rr.Domain))
+	}
+
+	rrType, ok := dns.StringToType[strings.ToUpper(rr.Type)]
+	if !ok {
+		verr.Add("type", fmt.Sprintf("unknown type %q", rr.Type))
+	}
+
+	class, ok := dns.StringToClass[strings.ToUpper(rr.Class)]
+	if !ok {
+		verr.Add("class", fmt.Sprintf("unknown class %q", rr.Class))
+	}
+
+	if rr.TTL < 0 {
+		verr.Add("time_to_live", "must not be negative")
+	}
+
+	if strings.TrimSpace(rr.Data) == "" {
+		verr.Add("data", "must not be empty")
+	}
+
+	if len(verr.Fields) != 0 {
+		return rr, verr
+	}
+
+	parsed, err := dns.NewRR(fmt.Sprintf("%s %d %s %s %s",
+		domain, rr.TTL, dns.ClassToString[class], dns.TypeToString[rrType], rr.Data))
+	if err != nil || parsed == nil {
+		msg := fmt.Sprintf("can't parse %s data", dns.TypeToString[rrType])
+		if err != nil {
+			msg += ": " + strings.TrimPrefix(err.Error(), "dns: ")
+		}
+		verr.Add("data", msg)
+		return rr, verr
+	}
+
+	rr.Domain = domain
+	rr.Type = dns.TypeToString[rrType]
+	rr.Class = dns.ClassToString[class]
+	rr.Data = strings.TrimPrefix(parsed.String(), parsed.Header().String())
+	return rr, nil
+}
+
+// NormalizeView check the view and return it in the canonical form: networks are masked,
+// zones are lower case FQDN and records are sorted without duplicates.
+// *ValidationError is returned for invalid views.
+func NormalizeView(view View) (View, error) {
+	verr := &ValidationError{}
+
+	view.Name = strings.TrimSpace(view.Name)
+	if view.Name == "" {
+		verr.Add("name", "must not be empty")
+	}
+
+	networks := make([]netip.Prefix, 0, len(view.Networks))
+	for _, network := range view.Networks {
+		if !network.IsValid() {
+			verr.Add("networks", "network is not valid")
+			continue
+		}
+		networks = append(networks, network.Masked())
+	}
+	view.Networks = networks
+
+	zones := make([]string, 0, len(view.Zones))
+	for _, zone := range view.Zones {
+		normalized := strings.ToLower(dns.Fqdn(strings.TrimSpace(zone)))
+		if _, ok := dns.IsDomainName(normalized); !ok || zone == "" {
+			verr.Add("zones", fmt.Sprintf("%q is not a valid domain name", zone))
+			continue
+		}
+		zones = append(zones, normalized)
+	}
+	slices.Sort(zones)
+	view.Zones = slices.Compact(zones)
+
+	records := slices.Clone(view.Records)
+	slices.Sort(records)
+	view.Records = slices.Compact(records)
+
+	if len(verr.Fields) != 0 {
+		return view, verr
+	}
+	return view, nil
+}
+
+// NormalizeACLRule check the rule of the access control list and return it with masked network.
+// *ValidationError is returned for invalid rules.
+func NormalizeACLRule(rule ACLRule) (ACLRule, error) {
+	if !rule.Network.IsValid() {
+		verr := &ValidationError{}
+		verr.Add("network", "network is not valid")
+		return rule, verr
+	}
+	rule.Network = rule.Network.Masked()
+	return rule, nil
+}
+
+// NormalizeAPIKey check the API key and return it with zones in lower case FQDN.
+// *ValidationError is returned for invalid keys.
+func NormalizeAPIKey(key APIKey) (APIKey, error) {
+	verr := &ValidationError{}
+	key.Name = strings.TrimSpace(key.Name)
+	if key.Name == "" || len(key.Name) > 64 {
+		verr.Add("name", "name must contain from 1 to 64 characters")
+	}
+	if key.Role == "" {
+		verr.Add("role", "role is required")
+	}
+
+	key.Zones = normalizeZones(verr, key.Zones)
+
+	if !key.ExpiresAt.IsZero() && key.ExpiresAt.Before(time.Now()) {
+		verr.Add("expires_at", "expiry time is in the past")
+	}
+
+	if len(verr.Fields) != 0 {
+		return key, verr
+	}
+	return key, nil
+}
+
+// NormalizeInvitation check the invitation and return it with zones in lower case FQDN.
+// *ValidationError is returned for invalid invitations.
+func NormalizeInvitation(invitation Invitation) (Invitation, error) {
+	verr := &ValidationError{}
+	if invitation.Role == "" {
+		verr.Add("role", "role is required")
+	}
+
+	invitation.Zones = normalizeZones(verr, invitation.Zones)
+
+	if !invitation.ExpiresAt.After(time.Now()) {
+		verr.Add("expires_at", "expiry time must be in the future")
+	}
+
+	if len(verr.Fields) != 0 {
+		return invitation, verr
+	}
+	return invitation, nil
+}
+
+// NormalizeRole check that the role has the name and known permissions and return it
+// with sorted permissions without duplicates.
+func NormalizeRole(role Role) (Role, error) {
+	verr := &ValidationError{}
+	role.Name = strings.TrimSpace(role.Name)
+	if role.Name == "" || len(role.Name) > 20 {
+		verr.Add("name", "name must contain from 1 to 20 characters")
+	}
+	for _, perm := range role.Permissions {
+		if !slices.Contains(Permissions, perm) {
+			verr.Add("permissions", fmt.Sprintf("unknown permission %q", perm))
+		}
+	}
+	role.Permissions = slices.Clone(role.Permissions)
+	slices.Sort(role.Permissions)
+	role.Permissions = slices.Compact(role.Permissions)
+
+	if len(verr.Fields) != 0 {
+		return role, verr
+	}
+	return role, nil
+}
+
+// validateUser check names and password of the new user.
+// *ValidationError is returned for invalid users.
+func validateUser(user User, password string) error {
+	verr := &ValidationError{}
+	if len(user.FirstName) < 2 {
+		verr.Add("first_name", fmt.Sprintf("can't use name %s, the length less than 2", user.FirstName))
+	}
+	if len(user.LastName) < 2 {
+		verr.Add("last_name", fmt.Sprintf("can't use last name %s, the length less than 2", user.LastName))
+	}
+	addPasswordError(verr, password)
+
+	if len(verr.Fields) != 0 {
+		return verr
+	}
+	return nil
+}
+
+// checkPassword check length of the password, *ValidationError is returned for invalid password.
+func checkPassword(password string) error {
+	verr := &ValidationError{}
+	addPasswordError(verr, password)
+	if len(verr.Fields) != 0 {
+		return verr
+	}
+	return nil
+}
+
+// addPasswordError add error to verr if the password is too short or too long for bcrypt.
+func addPasswordError(verr *ValidationError, password string) {
+	if len(password) < 4 {
+		verr.Add("password", "password is too weak, it must contain at least "+
+			"1 special symbol and 1 number and 8 symbols in total")
+	}
+	if len(password) > 71 {
+		verr.Add("password", "password is too long, 70 symbols max")
+	}
+}
+
+// normalizeZones return zones as lower case FQDN sorted without duplicates.
+// Invalid zones are added to the error.
+func normalizeZones(verr *ValidationError, zones []string) []string {
+	normalized := make([]string, 0, len(zones))
+	for _, zone := range zones {
+		fqdn := strings.ToLower(dns.Fqdn(strings.TrimSpace(zone)))
+		if _, ok := dns.IsDomainName(fqdn); !ok || zone == "" {
+			verr.Add("zones", fmt.Sprintf("%q is not a valid domain name", zone))
+			continue
+		}
+		normalized = append(normalized, fqdn)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
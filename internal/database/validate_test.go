@@ -0,0 +1,134 @@
+package database
+
+import (
+	"errors"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNormalizeRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		rr     ResourceRecord
+		want   ResourceRecord
+		fields []string
+	}{
+		{
+			name: "a",
+			rr:   ResourceRecord{Domain: "WWW.Example.com", Type: "a", Class: "in", TTL: 60, Data: "10.0.3.14"},
+			want: ResourceRecord{Domain: "www.example.com.", Type: "A", Class: "IN", TTL: 60, Data: "10.0.3.14"},
+		},
+		{
+			name: "mx",
+			rr:   ResourceRecord{Domain: "example.com.", Type: "MX", Class: "IN", TTL: 60, Data: "10   Mail.Example.com."},
+			want: ResourceRecord{Domain: "example.com.", Type: "MX", Class: "IN", TTL: 60, Data: "10 Mail.Example.com."},
+		},
+		{
+			name: "txt",
+			rr:   ResourceRecord{Domain: "example.com.", Type: "TXT", Class: "IN", Data: `"v=spf1 -all"`},
+			want: ResourceRecord{Domain: "example.com.", Type: "TXT", Class: "IN", Data: `"v=spf1 -all"`},
+		},
+		{
+			name:   "bad mx",
+			rr:     ResourceRecord{Domain: "example.com.", Type: "MX", Class: "IN", Data: "mail.example.com."},
+			fields: []string{"data"},
+		},
+		{
+			name:   "bad a",
+			rr:     ResourceRecord{Domain: "example.com.", Type: "A", Class: "IN", Data: "10.0.3"},
+			fields: []string{"data"},
+		},
+		{
+			name:   "bad fields",
+			rr:     ResourceRecord{Domain: "", Type: "NOPE", Class: "XX", TTL: -1},
+			fields: []string{"domain", "type", "class", "time_to_live", "data"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeRecord(tt.rr)
+			if tt.fields == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if got != tt.want {
+					t.Fatalf("expected %+v, got %+v", tt.want, got)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected validation error, got %v", err)
+			}
+			if len(verr.Fields) != len(tt.fields) {
+				t.Fatalf("expected invalid fields %v, got %v", tt.fields, verr.Fields)
+			}
+			for i, field := range tt.fields {
+				if verr.Fields[i].Field != field {
+					t.Fatalf("expected invalid fields %v, got %v", tt.fields, verr.Fields)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeView(t *testing.T) {
+	view, err := NormalizeView(View{
+		Name:     " office ",
+		Networks: []netip.Prefix{netip.MustParsePrefix("10.1.2.3/8")},
+		Zones:    []string{"Corp.Example", "corp.example."},
+		Records:  []int32{3, 1, 3},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if view.Name != "office" || view.Networks[0].String() != "10.0.0.0/8" ||
+		!slices.Equal(view.Zones, []string{"corp.example."}) || !slices.Equal(view.Records, []int32{1, 3}) {
+		t.Fatalf("unexpected view %+v", view)
+	}
+
+	_, err = NormalizeView(View{Networks: []netip.Prefix{{}}, Zones: []string{""}})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 3 {
+		t.Fatalf("expected 3 invalid fields, got %v", err)
+	}
+}
+
+func TestNormalizeAPIKey(t *testing.T) {
+	key, err := NormalizeAPIKey(APIKey{Name: " ci ", Role: "user", Zones: []string{"Example.com", "example.com.", "."}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if key.Name != "ci" || !slices.Equal(key.Zones, []string{".", "example.com."}) {
+		t.Fatalf("unexpected key %+v", key)
+	}
+	if !InZones("www.example.com.", key.Zones[1:]) || InZones("badexample.com.", key.Zones[1:]) {
+		t.Fatal("unexpected zone check")
+	}
+
+	_, err = NormalizeAPIKey(APIKey{ExpiresAt: time.Now().Add(-time.Hour)})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 3 {
+		t.Fatalf("expected 3 invalid fields, got %v", err)
+	}
+}
+
+func TestNormalizeRole(t *testing.T) {
+	role, err := NormalizeRole(Role{Name: " editor ", Permissions: []string{PermRecordsWrite, PermRecordsRead, PermRecordsWrite}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if role.Name != "editor" || !slices.Equal(role.Permissions, []string{PermRecordsRead, PermRecordsWrite}) {
+		t.Fatalf("unexpected role %+v", role)
+	}
+
+	_, err = NormalizeRole(Role{Permissions: []string{"records:delete"}})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 2 {
+		t.Fatalf("expected 2 invalid fields, got %v", err)
+	}
+}
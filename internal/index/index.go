@@ -0,0 +1,392 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+
+	"github.com/prionis/dns-server/internal/database"
+)
+
+// Index is an in-memory label tree of resource records.
+// It is used to answer DNS questions without round trip to the database,
+// database stay the durable store and the index is kept in sync with it.
+//
+// Index also contain views of the records. Records assigned to the view directly
+// or through the most specific zone of the views are answered only in these views,
+// other records are answered in all of them.
+type Index struct {
+	mx    sync.RWMutex
+	root  *node
+	byID  map[int32]database.ResourceRecord
+	views views
+}
+
+// Entry is the resource record with dns.RR built from it once when record is loaded or changed.
+type Entry struct {
+	Record database.ResourceRecord
+	// RR is shared between all readers and must be copied before modification.
+	RR dns.RR
+	// Views is IDs of the views where the record is answered, empty for all views.
+	Views []int32
+}
+
+// views contain views prepared for lookups.
+type views struct {
+	names    map[int32]string
+	networks []viewNetwork
+	// byRecord contain views of the records assigned to them directly.
+	byRecord map[int32][]int32
+	// byZone contain views of the zones.
+	byZone map[string][]int32
+}
+
+type viewNetwork struct {
+	prefix netip.Prefix
+	view   int32
+}
+
+// node is one label of the domain name in the tree.
+type node struct {
+	children map[string]*node
+	entries  []Entry
+}
+
+// New create empty index.
+func New() *Index {
+	return &Index{
+		root: newNode(),
+		byID: make(map[int32]database.ResourceRecord),
+	}
+}
+
+func newNode() *node {
+	return &node{children: make(map[string]*node)}
+}
+
+// Load replace content of the index with provided resource records and views.
+// Records that can't be parsed are skipped and returned in the error.
+func (idx *Index) Load(rrs []database.ResourceRecord, vs []database.View) error {
+	root := newNode()
+	byID := make(map[int32]database.ResourceRecord, len(rrs))
+	prepared := newViews(vs)
+	var errs []error
+	for _, rr := range rrs {
+		entry, err := newEntry(rr, prepared)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		insert(root, entry)
+		byID[rr.ID] = rr
+	}
+
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+	idx.root = root
+	idx.byID = byID
+	idx.views = prepared
+	return errors.Join(errs...)
+}
+
+// SetViews replace views of the index and update views of all resource records.
+func (idx *Index) SetViews(vs []database.View) {
+	prepared := newViews(vs)
+
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+	idx.views = prepared
+	walk(idx.root, func(n *node) {
+		for i := range n.entries {
+			n.entries[i].Views = prepared.of(n.entries[i].Record)
+		}
+	})
+}
+
+// SelectView return ID of the view for the client with provided address.
+// View with the most specific network that contain the address is selected.
+// Zero is returned if address is not in the networks of any view.
+func (idx *Index) SelectView(addr netip.Addr) int32 {
+	addr = addr.Unmap()
+
+	idx.mx.RLock()
+	defer idx.mx.RUnlock()
+
+	var view int32
+	bits := -1
+	for _, network := range idx.views.networks {
+		if network.prefix.Bits() > bits && network.prefix.Contains(addr) {
+			view = network.view
+			bits = network.prefix.Bits()
+		}
+	}
+	return view
+}
+
+// Scope return prefix length of the network around the address where all addresses
+// select the same view, so the answer for the address is valid in the whole network.
+func (idx *Index) Scope(addr netip.Addr) int {
+	addr = addr.Unmap()
+
+	idx.mx.RLock()
+	defer idx.mx.RUnlock()
+
+	matched := 0
+	for _, network := range idx.views.networks {
+		if network.prefix.Bits() > matched && network.prefix.Contains(addr) {
+			matched = network.prefix.Bits()
+		}
+	}
+	// More specific networks near the address must be outside of the scope.
+	scope := matched
+	for _, network := range idx.views.networks {
+		if network.prefix.Bits() <= matched || network.prefix.Addr().Is4() != addr.Is4() {
+			continue
+		}
+		if common := commonBits(addr, network.prefix.Addr()); common < network.prefix.Bits() {
+			scope = max(scope, common+1)
+		}
+	}
+	return scope
+}
+
+// commonBits return length of the common prefix of the addresses of the same family.
+func commonBits(a, b netip.Addr) int {
+	x, y := a.AsSlice(), b.AsSlice()
+	for i := range x {
+		if d := x[i] ^ y[i]; d != 0 {
+			return i*8 + bits.LeadingZeros8(d)
+		}
+	}
+	return len(x) * 8
+}
+
+// ViewName return name of the view with provided ID, empty for zero view.
+func (idx *Index) ViewName(id int32) string {
+	idx.mx.RLock()
+	defer idx.mx.RUnlock()
+	return idx.views.names[id]
+}
+
+// Put insert resource record in the index.
+// If record with the same ID already exist it will be replaced.
+// Record that can't be parsed is not inserted, but old version of it is still removed.
+func (idx *Index) Put(rr database.ResourceRecord) error {
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+
+	entry, err := newEntry(rr, idx.views)
+	if old, ok := idx.byID[rr.ID]; ok {
+		remove(idx.root, old)
+		delete(idx.byID, rr.ID)
+	}
+	if err != nil {
+		return err
+	}
+	insert(idx.root, entry)
+	idx.byID[rr.ID] = rr
+	return nil
+}
+
+// Delete remove resource record with provided ID from the index.
+func (idx *Index) Delete(id int32) {
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+	old, ok := idx.byID[id]
+	if !ok {
+		return
+	}
+	remove(idx.root, old)
+	delete(idx.byID, id)
+}
+
+// Find return entries with provided domain name and type that answered in the view.
+// Entries of the view replace entries answered in all views, so the view can
+// override answer for the name. Zero view contain only records of all views.
+func (idx *Index) Find(name, rrType string, view int32) []Entry {
+	idx.mx.RLock()
+	defer idx.mx.RUnlock()
+
+	n := lookup(idx.root, labels(name))
+	if n == nil {
+		return nil
+	}
+
+	var entries, viewEntries []Entry
+	for _, entry := range n.entries {
+		if entry.Record.Type != rrType {
+			continue
+		}
+		switch {
+		case len(entry.Views) == 0:
+			entries = append(entries, entry)
+		case view != 0 && slices.Contains(entry.Views, view):
+			viewEntries = append(viewEntries, entry)
+		}
+	}
+	if len(viewEntries) != 0 {
+		return viewEntries
+	}
+	return entries
+}
+
+// Exists report whether the domain name or names below it have records answered in the view.
+// Name without them doesn't exist, so the query for it is answered with NXDOMAIN.
+func (idx *Index) Exists(name string, view int32) bool {
+	idx.mx.RLock()
+	defer idx.mx.RUnlock()
+
+	n := lookup(idx.root, labels(name))
+	return n != nil && visible(n, view)
+}
+
+// visible report whether the node or any node below it has records answered in the view.
+func visible(n *node, view int32) bool {
+	for _, entry := range n.entries {
+		if len(entry.Views) == 0 || view != 0 && slices.Contains(entry.Views, view) {
+			return true
+		}
+	}
+	for _, child := range n.children {
+		if visible(child, view) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len return count of resource records in the index.
+func (idx *Index) Len() int {
+	idx.mx.RLock()
+	defer idx.mx.RUnlock()
+	return len(idx.byID)
+}
+
+// newEntry parse resource record to the dns.RR and find views of it.
+func newEntry(rr database.ResourceRecord, vs views) (Entry, error) {
+	parsed, err := dns.NewRR(fmt.Sprintf("%s %d %s %s %s",
+		rr.Domain, rr.TTL, rr.Class, rr.Type, rr.Data))
+	if err != nil {
+		return Entry{}, fmt.Errorf("can't parse resource record %d: %w", rr.ID, err)
+	}
+	if parsed == nil {
+		return Entry{}, fmt.Errorf("resource record %d is empty", rr.ID)
+	}
+	return Entry{Record: rr, RR: parsed, Views: vs.of(rr)}, nil
+}
+
+func newViews(vs []database.View) views {
+	prepared := views{
+		names:    make(map[int32]string, len(vs)),
+		byRecord: make(map[int32][]int32),
+		byZone:   make(map[string][]int32),
+	}
+	for _, view := range vs {
+		prepared.names[view.ID] = view.Name
+		for _, network := range view.Networks {
+			prepared.networks = append(prepared.networks, viewNetwork{prefix: network, view: view.ID})
+		}
+		for _, id := range view.Records {
+			prepared.byRecord[id] = append(prepared.byRecord[id], view.ID)
+		}
+		for _, zone := range view.Zones {
+			zone = strings.ToLower(dns.Fqdn(zone))
+			prepared.byZone[zone] = append(prepared.byZone[zone], view.ID)
+		}
+	}
+	return prepared
+}
+
+// of return views of the resource record. Views of the record itself are preferred
+// over views of its most specific zone.
+func (vs views) of(rr database.ResourceRecord) []int32 {
+	if ids, ok := vs.byRecord[rr.ID]; ok {
+		return ids
+	}
+	if len(vs.byZone) == 0 {
+		return nil
+	}
+
+	name := strings.ToLower(dns.Fqdn(rr.Domain))
+	for off, end := 0, false; !end; off, end = dns.NextLabel(name, off) {
+		if ids, ok := vs.byZone[name[off:]]; ok {
+			return ids
+		}
+	}
+	return vs.byZone["."]
+}
+
+func insert(root *node, entry Entry) {
+	n := root
+	for _, label := range labels(entry.Record.Domain) {
+		child, ok := n.children[label]
+		if !ok {
+			child = newNode()
+			n.children[label] = child
+		}
+		n = child
+	}
+	n.entries = append(n.entries, entry)
+}
+
+func remove(root *node, rr database.ResourceRecord) {
+	path := []*node{root}
+	lbls := labels(rr.Domain)
+	n := root
+	for _, label := range lbls {
+		n = n.children[label]
+		if n == nil {
+			return
+		}
+		path = append(path, n)
+	}
+
+	for i, entry := range n.entries {
+		if entry.Record.ID == rr.ID {
+			n.entries = append(n.entries[:i], n.entries[i+1:]...)
+			break
+		}
+	}
+
+	// Prune nodes that left without entries and children.
+	for i := len(path) - 1; i > 0; i-- {
+		if len(path[i].entries) != 0 || len(path[i].children) != 0 {
+			break
+		}
+		delete(path[i-1].children, lbls[i-1])
+	}
+}
+
+// walk call fn for every node of the tree.
+func walk(n *node, fn func(*node)) {
+	fn(n)
+	for _, child := range n.children {
+		walk(child, fn)
+	}
+}
+
+func lookup(root *node, lbls []string) *node {
+	n := root
+	for _, label := range lbls {
+		n = n.children[label]
+		if n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// labels return labels of the domain name starting from the top level domain.
+func labels(name string) []string {
+	lbls := dns.SplitDomainName(strings.ToLower(dns.Fqdn(name)))
+	for i, j := 0, len(lbls)-1; i < j; i, j = i+1, j-1 {
+		lbls[i], lbls[j] = lbls[j], lbls[i]
+	}
+	return lbls
+}
@@ -0,0 +1,247 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/prionis/dns-server/internal/database"
+)
+
+func TestIndex(t *testing.T) {
+	idx := New()
+	err := idx.Load([]database.ResourceRecord{
+		{ID: 1, Domain: "example.com.", Type: "A", Class: "IN", Data: "10.0.0.1", TTL: 60},
+		{ID: 2, Domain: "Example.com", Type: "AAAA", Class: "IN", Data: "::1", TTL: 60},
+		{ID: 3, Domain: "www.example.com.", Type: "A", Class: "IN", Data: "10.0.0.2", TTL: 60},
+		{ID: 4, Domain: "bad.example.com.", Type: "MX", Class: "IN", Data: "mail.example.com.", TTL: 60},
+	}, nil)
+	if err == nil {
+		t.Fatal("expected error for record that can't be parsed")
+	}
+
+	if rrs := idx.Find("EXAMPLE.COM.", "A", 0); len(rrs) != 1 || rrs[0].Record.ID != 1 ||
+		rrs[0].RR.(*dns.A).A.String() != "10.0.0.1" {
+		t.Fatalf("expected record 1, got %v", rrs)
+	}
+	if rrs := idx.Find("example.com.", "AAAA", 0); len(rrs) != 1 || rrs[0].Record.ID != 2 {
+		t.Fatalf("expected record 2, got %v", rrs)
+	}
+	if rrs := idx.Find("bad.example.com.", "MX", 0); len(rrs) != 0 {
+		t.Fatalf("expected record that can't be parsed to be skipped, got %v", rrs)
+	}
+	if rrs := idx.Find("com.", "A", 0); len(rrs) != 0 {
+		t.Fatalf("expected no records for com., got %v", rrs)
+	}
+	if !idx.Exists("com.", 0) || !idx.Exists("example.com.", 0) || idx.Exists("missing.example.com.", 0) {
+		t.Fatal("expected names with records or names below them to exist")
+	}
+
+	if err := idx.Put(database.ResourceRecord{ID: 3, Domain: "mail.example.com.", Type: "A", Class: "IN", Data: "10.0.0.3"}); err != nil {
+		t.Fatal(err)
+	}
+	if rrs := idx.Find("www.example.com.", "A", 0); len(rrs) != 0 {
+		t.Fatalf("expected updated record to be moved, got %v", rrs)
+	}
+	if rrs := idx.Find("mail.example.com.", "A", 0); len(rrs) != 1 || rrs[0].Record.Data != "10.0.0.3" {
+		t.Fatalf("expected updated record, got %v", rrs)
+	}
+
+	idx.Delete(3)
+	if rrs := idx.Find("mail.example.com.", "A", 0); len(rrs) != 0 {
+		t.Fatalf("expected deleted record to be gone, got %v", rrs)
+	}
+	if _, ok := idx.root.children["com"].children["example"].children["mail"]; ok {
+		t.Fatal("expected empty node to be pruned")
+	}
+	if idx.Len() != 2 {
+		t.Fatalf("expected 2 records, got %d", idx.Len())
+	}
+}
+
+func TestIndexViews(t *testing.T) {
+	idx := New()
+	err := idx.Load([]database.ResourceRecord{
+		{ID: 1, Domain: "intranet.example.com.", Type: "A", Class: "IN", Data: "203.0.113.10", TTL: 60},
+		{ID: 2, Domain: "intranet.example.com.", Type: "A", Class: "IN", Data: "10.0.0.10", TTL: 60},
+		{ID: 3, Domain: "db.corp.example.", Type: "A", Class: "IN", Data: "10.0.0.20", TTL: 60},
+		{ID: 4, Domain: "www.example.com.", Type: "A", Class: "IN", Data: "203.0.113.20", TTL: 60},
+	}, []database.View{
+		{ID: 1, Name: "office", Networks: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, Records: []int32{2}},
+		{ID: 2, Name: "vpn", Networks: []netip.Prefix{netip.MustParsePrefix("10.8.0.0/16")}, Zones: []string{"corp.example"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	office := idx.SelectView(netip.MustParseAddr("10.1.2.3"))
+	vpn := idx.SelectView(netip.MustParseAddr("::ffff:10.8.0.1"))
+	public := idx.SelectView(netip.MustParseAddr("198.51.100.1"))
+	if office != 1 || vpn != 2 || public != 0 {
+		t.Fatalf("expected views 1, 2 and 0, got %d, %d and %d", office, vpn, public)
+	}
+
+	tests := []struct {
+		name string
+		view int32
+		want []int32
+	}{
+		{"intranet.example.com.", office, []int32{2}},
+		{"intranet.example.com.", vpn, []int32{1}},
+		{"intranet.example.com.", public, []int32{1}},
+		{"db.corp.example.", vpn, []int32{3}},
+		{"db.corp.example.", office, nil},
+		{"www.example.com.", office, []int32{4}},
+	}
+	for _, tt := range tests {
+		var got []int32
+		for _, entry := range idx.Find(tt.name, "A", tt.view) {
+			got = append(got, entry.Record.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s in view %d: expected records %v, got %v", tt.name, tt.view, tt.want, got)
+		}
+	}
+
+	// Names of the records answered only in other views don't exist.
+	if idx.Exists("corp.example.", office) || !idx.Exists("corp.example.", vpn) || !idx.Exists("example.com.", public) {
+		t.Fatal("expected names to exist only in the views their records are answered in")
+	}
+
+	// Scope exclude more specific networks of other views.
+	for addr, want := range map[string]int{"10.1.2.3": 13, "10.8.0.1": 16, "198.51.100.1": 1} {
+		if got := idx.Scope(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("expected scope %d for %s, got %d", want, addr, got)
+		}
+	}
+
+	// Records are moved between views without reloading them.
+	idx.SetViews([]database.View{
+		{ID: 1, Name: "office", Networks: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, Zones: []string{"corp.example."}},
+	})
+	if rrs := idx.Find("db.corp.example.", "A", 1); len(rrs) != 1 {
+		t.Fatalf("expected record to be moved to the office view, got %v", rrs)
+	}
+	if rrs := idx.Find("intranet.example.com.", "A", 1); len(rrs) != 2 {
+		t.Fatalf("expected records to be answered in all views, got %v", rrs)
+	}
+}
+
+func testRecords(n int) []database.ResourceRecord {
+	rrs := make([]database.ResourceRecord, 0, n)
+	for i := range n {
+		rrs = append(rrs, database.ResourceRecord{
+			ID:     int32(i + 1),
+			Domain: fmt.Sprintf("host%d.bench.example.", i),
+			Type:   "A",
+			Class:  "IN",
+			Data:   fmt.Sprintf("10.%d.%d.%d", i>>16&0xff, i>>8&0xff, i&0xff),
+			TTL:    300,
+		})
+	}
+	return rrs
+}
+
+// memoryRepository find records kept in memory, so the lookup through the repository
+// can be measured without the database.
+type memoryRepository struct {
+	database.Repository
+	records map[string][]database.ResourceRecord
+}
+
+func newMemoryRepository(rrs []database.ResourceRecord) memoryRepository {
+	repo := memoryRepository{records: make(map[string][]database.ResourceRecord)}
+	for _, rr := range rrs {
+		repo.records[rr.Domain+" "+rr.Type] = append(repo.records[rr.Domain+" "+rr.Type], rr)
+	}
+	return repo
+}
+
+func (r memoryRepository) FindRecords(_ context.Context, name, rrType string) ([]database.ResourceRecord, error) {
+	return r.records[name+" "+rrType], nil
+}
+
+// lookupRepository answer the query as the handler did before the index:
+// records are found through the repository and parsed for every query.
+func lookupRepository(repo database.Repository, name string) ([]dns.RR, error) {
+	rrs, err := repo.FindRecords(context.Background(), name, "A")
+	if err != nil {
+		return nil, err
+	}
+	answers := make([]dns.RR, 0, len(rrs))
+	for _, rr := range rrs {
+		parsed, err := dns.NewRR(fmt.Sprintf("%s %d %s %s %s", rr.Domain, rr.TTL, rr.Class, rr.Type, rr.Data))
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, parsed)
+	}
+	return answers, nil
+}
+
+// BenchmarkLookup compare lookups through the index with lookups through the repository
+// that were made before the index. Repository keeping records in memory is the lower bound
+// of the previous lookups, it doesn't include latency of the database. Lookups through
+// the database run only when DNS_SERVER_TEST_DATABASE contain connection string
+// to the database that can be filled with test records.
+func BenchmarkLookup(b *testing.B) {
+	const n = 10000
+	rrs := testRecords(n)
+
+	b.Run("index", func(b *testing.B) {
+		idx := New()
+		idx.Load(rrs, nil)
+		start := time.Now()
+		b.ResetTimer()
+		for i := range b.N {
+			idx.Find(rrs[i%n].Domain, "A", 0)
+		}
+		b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "qps")
+	})
+
+	b.Run("memory", func(b *testing.B) {
+		repo := newMemoryRepository(rrs)
+		start := time.Now()
+		b.ResetTimer()
+		for i := range b.N {
+			if _, err := lookupRepository(repo, rrs[i%n].Domain); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "qps")
+	})
+
+	b.Run("postgres", func(b *testing.B) {
+		connString := os.Getenv("DNS_SERVER_TEST_DATABASE")
+		if connString == "" {
+			b.Skip("DNS_SERVER_TEST_DATABASE is not set")
+		}
+		db, err := database.NewPostgres(connString)
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer db.Close()
+		ctx := context.Background()
+		for _, rr := range rrs[:1000] {
+			id, err := db.AddRecord(ctx, rr)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer db.DeleteRecord(ctx, id)
+		}
+		start := time.Now()
+		b.ResetTimer()
+		for i := range b.N {
+			if _, err := lookupRepository(db, rrs[i%1000].Domain); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "qps")
+	})
+}
@@ -0,0 +1,166 @@
+// Package metrics collect metrics of the server and expose them in the Prometheus format.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/prionis/dns-server/internal/database"
+)
+
+// Sources of the DNS answers.
+const (
+	SourceLocal     = "local"
+	SourceCache     = "cache"
+	SourceForwarded = "forwarded"
+)
+
+// Metrics contain all collectors of the server.
+// Collectors are registered in the own registry, so several servers may be created in one process.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	// Queries count answered DNS queries by type, protocol and rcode.
+	Queries *prometheus.CounterVec
+	// QueryDuration observe latency of the DNS answers by source of the answer.
+	QueryDuration *prometheus.HistogramVec
+	// BlockedQueries count queries refused by the server policy by reason.
+	BlockedQueries *prometheus.CounterVec
+	// RRLSent count UDP responses sent by the response rate limiting.
+	RRLSent prometheus.Counter
+	// RRLDropped count UDP responses dropped by the response rate limiting.
+	RRLDropped prometheus.Counter
+	// RRLSlipped count truncated UDP responses sent by the response rate limiting.
+	RRLSlipped prometheus.Counter
+	// HTTPRequests count requests of the HTTP API by route, method and status.
+	HTTPRequests *prometheus.CounterVec
+	// DBQueryDuration observe latency of the database queries by name of the query.
+	DBQueryDuration *prometheus.HistogramVec
+}
+
+// New create metrics with Go runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		Queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dns",
+			Name:      "queries_total",
+			Help:      "Answered DNS queries.",
+		}, []string{"type", "protocol", "rcode"}),
+		QueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "dns",
+			Name:      "query_duration_seconds",
+			Help:      "Latency of the DNS answers by source of the answer.",
+			Buckets:   []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+		}, []string{"source"}),
+		BlockedQueries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dns",
+			Name:      "blocked_queries_total",
+			Help:      "DNS queries refused by the server policy.",
+		}, []string{"reason"}),
+		RRLSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "dns",
+			Name:      "rrl_sent_total",
+			Help:      "UDP responses sent by the response rate limiting.",
+		}),
+		RRLDropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "dns",
+			Name:      "rrl_dropped_total",
+			Help:      "UDP responses dropped by the response rate limiting.",
+		}),
+		RRLSlipped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "dns",
+			Name:      "rrl_slipped_total",
+			Help:      "Truncated UDP responses sent by the response rate limiting.",
+		}),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "http",
+			Name:      "requests_total",
+			Help:      "Requests of the HTTP API.",
+		}, []string{"route", "method", "status"}),
+		DBQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Latency of the database queries.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"query"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.Queries,
+		m.QueryDuration,
+		m.BlockedQueries,
+		m.RRLSent,
+		m.RRLDropped,
+		m.RRLSlipped,
+		m.HTTPRequests,
+		m.DBQueryDuration,
+	)
+	return m
+}
+
+// queryTypes are the types of the DNS queries counted by their names,
+// other types are counted as OTHER, so the clients can't create new series.
+var queryTypes = map[string]bool{
+	"A": true, "AAAA": true, "ANY": true, "AXFR": true, "CAA": true, "CNAME": true, "DNSKEY": true,
+	"DS": true, "HTTPS": true, "IXFR": true, "MX": true, "NAPTR": true, "NS": true, "PTR": true,
+	"SOA": true, "SRV": true, "SVCB": true, "TXT": true,
+}
+
+// QueryType return label of the DNS query type for the Queries counter.
+func QueryType(qtype string) string {
+	if queryTypes[qtype] {
+		return qtype
+	}
+	return "OTHER"
+}
+
+// Handler return HTTP handler that expose metrics for scraping.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// GaugeFunc register gauge which value is returned by f on each scrape.
+func (m *Metrics) GaugeFunc(name, help string, f func() float64) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, f))
+}
+
+// CounterFunc register counter which value is returned by f on each scrape.
+func (m *Metrics) CounterFunc(name, help string, f func() float64) {
+	m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, f))
+}
+
+// QueryTracer return tracer of the database connections that observe latency of the queries.
+func (m *Metrics) QueryTracer() pgx.QueryTracer {
+	return queryTracer{duration: m.DBQueryDuration}
+}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	name string
+	time time.Time
+}
+
+type queryTracer struct {
+	duration *prometheus.HistogramVec
+}
+
+func (t queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{name: database.QueryName(data.SQL), time: time.Now()})
+}
+
+func (t queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	t.duration.WithLabelValues(start.name).Observe(time.Since(start.time).Seconds())
+}
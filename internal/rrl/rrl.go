@@ -0,0 +1,261 @@
+// Package rrl implement response rate limiting of the DNS answers sent over UDP.
+// Source address of the UDP query can be spoofed, so without limiting the server
+// can be used for amplification of the traffic sent to the victim.
+package rrl
+
+import (
+	"cmp"
+	"errors"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Config of the response rate limiting. Zero limit disable limiting of the responses.
+type Config struct {
+	// ResponsesPerSecond is the limit of identical responses for one client network.
+	ResponsesPerSecond int
+	// NXDomainsPerSecond is the limit of NXDOMAIN responses for one client network.
+	NXDomainsPerSecond int
+	// ErrorsPerSecond is the limit of error responses for one client network.
+	ErrorsPerSecond int
+	// Window is the time while client is limited after it exceeded the limit.
+	Window time.Duration
+	// Slip is how often limited response is sent truncated instead of dropping it,
+	// so the real client can retry over TCP. Zero drop all limited responses.
+	Slip int
+	// IPv4PrefixLen is the length of the client network for IPv4, 24 if zero.
+	IPv4PrefixLen int
+	// IPv6PrefixLen is the length of the client network for IPv6, 56 if zero.
+	IPv6PrefixLen int
+}
+
+// DefaultConfig is the configuration with default window, slip and length of networks.
+var DefaultConfig = Config{
+	Window:        15 * time.Second,
+	Slip:          2,
+	IPv4PrefixLen: 24,
+	IPv6PrefixLen: 56,
+}
+
+// Validate check that the configuration can be used for limiting.
+func (c Config) Validate() error {
+	switch {
+	case c.ResponsesPerSecond < 0 || c.NXDomainsPerSecond < 0 || c.ErrorsPerSecond < 0:
+		return errors.New("limits of the responses can't be negative")
+	case c.Window < 0:
+		return errors.New("window can't be negative")
+	case c.Slip < 0:
+		return errors.New("slip can't be negative")
+	case c.IPv4PrefixLen < 0 || c.IPv4PrefixLen > 32:
+		return errors.New("length of the IPv4 network must be from 0 to 32")
+	case c.IPv6PrefixLen < 0 || c.IPv6PrefixLen > 128:
+		return errors.New("length of the IPv6 network must be from 0 to 128")
+	}
+	return nil
+}
+
+// Action that need to be done with the response.
+type Action int
+
+const (
+	// Send the response.
+	Send Action = iota
+	// Drop the response.
+	Drop
+	// Slip send truncated response without records.
+	Slip
+)
+
+// Stats contain counters of the limited responses.
+type Stats struct {
+	// Sent is count of the responses that were not limited.
+	Sent uint64
+	// Dropped is count of the dropped responses.
+	Dropped uint64
+	// Slipped is count of the responses sent truncated.
+	Slipped uint64
+}
+
+// kind of the response that is limited separately.
+type kind uint8
+
+const (
+	kindResponse kind = iota
+	kindNXDomain
+	kindError
+)
+
+type key struct {
+	view string
+	// acl is the network of the access control list rule which limit is used.
+	acl     netip.Prefix
+	network netip.Prefix
+	kind    kind
+	// name and qtype are set only for responses, NXDOMAIN and error responses
+	// of the network are counted together.
+	name  string
+	qtype uint16
+}
+
+type bucket struct {
+	// balance of the tokens, can be negative down to -rate*window.
+	balance float64
+	last    time.Time
+	limited int
+}
+
+// Limiter limit responses for the client networks.
+type Limiter struct {
+	config   Config
+	views    map[string]Config
+	networks map[netip.Prefix]Config
+
+	mx        sync.Mutex
+	buckets   map[key]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+	slipped atomic.Uint64
+}
+
+// New create limiter with default configuration, configurations of the views by their names
+// and configurations of the access control list rules by their networks.
+func New(config Config, views map[string]Config, networks map[netip.Prefix]Config) *Limiter {
+	return &Limiter{
+		config:   config,
+		views:    views,
+		networks: networks,
+		buckets:  make(map[key]*bucket),
+		now:      time.Now,
+	}
+}
+
+// Check account response to the client in the view and return action that need
+// to be done with it. Configuration of the most specific access control list rule
+// containing the client is used first, then configuration of the view.
+func (l *Limiter) Check(addr netip.Addr, view string, m *dns.Msg) Action {
+	acl, config, ok := l.rule(addr)
+	if !ok {
+		if config, ok = l.views[view]; !ok {
+			config = l.config
+		}
+	}
+
+	k := key{view: view, acl: acl, network: network(addr, config)}
+	var rate int
+	switch {
+	case m.Rcode == dns.RcodeNameError:
+		k.kind, rate = kindNXDomain, config.NXDomainsPerSecond
+	case m.Rcode != dns.RcodeSuccess:
+		k.kind, rate = kindError, config.ErrorsPerSecond
+	default:
+		k.kind, rate = kindResponse, config.ResponsesPerSecond
+		if len(m.Question) != 0 {
+			k.name = strings.ToLower(m.Question[0].Name)
+			k.qtype = m.Question[0].Qtype
+		}
+	}
+	if rate <= 0 {
+		l.sent.Add(1)
+		return Send
+	}
+
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[k]
+	if !ok {
+		b = &bucket{balance: float64(rate), last: now}
+		l.buckets[k] = b
+	}
+	b.balance += now.Sub(b.last).Seconds() * float64(rate)
+	b.balance = min(b.balance, float64(rate))
+	b.last = now
+
+	b.balance--
+	if b.balance >= 0 {
+		b.limited = 0
+		l.sent.Add(1)
+		return Send
+	}
+	b.balance = max(b.balance, -float64(rate)*config.Window.Seconds())
+
+	b.limited++
+	if config.Slip > 0 && b.limited%config.Slip == 0 {
+		l.slipped.Add(1)
+		return Slip
+	}
+	l.dropped.Add(1)
+	return Drop
+}
+
+// Stats return counters of the limited responses.
+func (l *Limiter) Stats() Stats {
+	return Stats{
+		Sent:    l.sent.Load(),
+		Dropped: l.dropped.Load(),
+		Slipped: l.slipped.Load(),
+	}
+}
+
+// sweep remove buckets that are full again, at most once per second.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Second {
+		return
+	}
+	l.lastSweep = now
+
+	window := l.config.Window
+	for _, config := range l.views {
+		window = max(window, config.Window)
+	}
+	for _, config := range l.networks {
+		window = max(window, config.Window)
+	}
+	for k, b := range l.buckets {
+		if now.Sub(b.last) > window+time.Second {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// rule return network and configuration of the most specific access control list rule
+// containing the address.
+func (l *Limiter) rule(addr netip.Addr) (netip.Prefix, Config, bool) {
+	addr = addr.Unmap()
+	var (
+		acl    netip.Prefix
+		config Config
+		found  bool
+	)
+	for network, c := range l.networks {
+		if network.Contains(addr) && (!found || network.Bits() > acl.Bits()) {
+			acl, config, found = network, c, true
+		}
+	}
+	return acl, config, found
+}
+
+// network return network of the client which responses are limited together.
+func network(addr netip.Addr, config Config) netip.Prefix {
+	addr = addr.Unmap()
+	bits := cmp.Or(config.IPv6PrefixLen, 56)
+	if addr.Is4() {
+		bits = cmp.Or(config.IPv4PrefixLen, 24)
+	}
+	prefix, err := addr.Prefix(min(bits, addr.BitLen()))
+	if err != nil {
+		return netip.PrefixFrom(addr, addr.BitLen())
+	}
+	return prefix
+}
@@ -0,0 +1,96 @@
+package rrl
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func TestLimiter(t *testing.T) {
+	config := DefaultConfig
+	config.ResponsesPerSecond = 5
+	config.NXDomainsPerSecond = 2
+	config.Window = 5 * time.Second
+	config.Slip = 2
+	l := New(config, map[string]Config{"office": {}}, nil)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+
+	answer := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+	other := new(dns.Msg).SetQuestion("www.example.com.", dns.TypeA)
+	nxdomain := new(dns.Msg).SetQuestion("nope.example.com.", dns.TypeA)
+	nxdomain.Rcode = dns.RcodeNameError
+	client := netip.MustParseAddr("192.0.2.1")
+	neighbour := netip.MustParseAddr("192.0.2.200")
+
+	count := func(addr netip.Addr, view string, m *dns.Msg, n int) map[Action]int {
+		actions := make(map[Action]int)
+		for range n {
+			actions[l.Check(addr, view, m)]++
+		}
+		return actions
+	}
+
+	// Clients from the same network share the limit.
+	if got := count(client, "", answer, 3); got[Send] != 3 {
+		t.Fatalf("expected 3 sent responses, got %v", got)
+	}
+	if got := count(neighbour, "", answer, 6); got[Send] != 2 || got[Slip] != 2 || got[Drop] != 2 {
+		t.Fatalf("expected 2 sent, 2 slipped and 2 dropped responses, got %v", got)
+	}
+	// Other responses and views are limited separately.
+	if got := count(client, "", other, 5); got[Send] != 5 {
+		t.Fatalf("expected other responses to be sent, got %v", got)
+	}
+	if got := count(client, "office", answer, 100); got[Send] != 100 {
+		t.Fatalf("expected responses of view without limits to be sent, got %v", got)
+	}
+	if got := count(client, "", nxdomain, 3); got[Send] != 2 {
+		t.Fatalf("expected 2 NXDOMAIN responses to be sent, got %v", got)
+	}
+
+	// Client that keep sending stay limited until the debt is paid.
+	if got := count(client, "", answer, 30); got[Send] != 0 {
+		t.Fatalf("expected flood to be limited, got %v", got)
+	}
+	now = now.Add(time.Second)
+	if got := count(client, "", answer, 1); got[Send] != 0 {
+		t.Fatalf("expected client to stay limited, got %v", got)
+	}
+	now = now.Add(config.Window + 2*time.Second)
+	if got := count(client, "", answer, 5); got[Send] != 5 {
+		t.Fatalf("expected client to be unlimited after the window, got %v", got)
+	}
+
+	stats := l.Stats()
+	if stats.Sent != 117 || stats.Slipped+stats.Dropped != 36 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestLimiterACL(t *testing.T) {
+	limited := DefaultConfig
+	limited.ResponsesPerSecond = 1
+	limited.Slip = 0
+	l := New(DefaultConfig, map[string]Config{"office": DefaultConfig}, map[netip.Prefix]Config{
+		netip.MustParsePrefix("198.51.100.0/24"):   limited,
+		netip.MustParsePrefix("198.51.100.128/25"): DefaultConfig,
+	})
+	l.now = func() time.Time { return time.Unix(0, 0) }
+	answer := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+
+	// Limit of the rule is used instead of the limit of the view.
+	for i, want := range []Action{Send, Drop, Drop} {
+		if got := l.Check(netip.MustParseAddr("198.51.100.1"), "office", answer); got != want {
+			t.Fatalf("response %d: expected %v, got %v", i, want, got)
+		}
+	}
+	// The most specific rule is used.
+	for range 3 {
+		if got := l.Check(netip.MustParseAddr("::ffff:198.51.100.200"), "office", answer); got != Send {
+			t.Fatalf("expected response of more specific rule without limits to be sent, got %v", got)
+		}
+	}
+}
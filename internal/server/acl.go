@@ -0,0 +1,71 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/proto/crud/genproto/crudpb"
+)
+
+// aclRuleFromProto convert protobuf message to the normalized rule of the access control list.
+// *database.ValidationError is returned if the rule is invalid.
+func aclRuleFromProto(msg *crudpb.ACLRule) (database.ACLRule, error) {
+	rule := database.ACLRule{
+		ID:             msg.GetId(),
+		AllowQuery:     msg.GetAllowQuery(),
+		AllowRecursion: msg.GetAllowRecursion(),
+		AllowTransfer:  msg.GetAllowTransfer(),
+		AllowUpdate:    msg.GetAllowUpdate(),
+	}
+
+	network, err := netip.ParsePrefix(msg.GetNetwork())
+	if err != nil {
+		verr := &database.ValidationError{}
+		verr.Add("network", fmt.Sprintf("%q is not a valid network", msg.GetNetwork()))
+		return rule, verr
+	}
+	rule.Network = network
+
+	return database.NormalizeACLRule(rule)
+}
+
+// toProtoACLRule convert rule of the access control list to the protobuf message.
+func toProtoACLRule(rule database.ACLRule) *crudpb.ACLRule {
+	return &crudpb.ACLRule{
+		Id:             rule.ID,
+		Network:        rule.Network.String(),
+		AllowQuery:     rule.AllowQuery,
+		AllowRecursion: rule.AllowRecursion,
+		AllowTransfer:  rule.AllowTransfer,
+		AllowUpdate:    rule.AllowUpdate,
+	}
+}
+
+// writeACLError write error of the access control list change to the response.
+// Invalid fields are returned in the ValidationErrors message.
+func (s Server) writeACLError(w http.ResponseWriter, msg string, err error) {
+	s.logger.Error(msg + err.Error())
+
+	var verr *database.ValidationError
+	if errors.As(err, &verr) {
+		writeValidationErrors(w, verr)
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Rule not found", http.StatusNotFound)
+		return
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+		http.Error(w, "Rule for this network already exist", http.StatusConflict)
+		return
+	}
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
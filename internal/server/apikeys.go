@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/proto/crud/genproto/crudpb"
+)
+
+const (
+	// apiKeyPrefix start every API key, so keys are easy to find in leaked files.
+	apiKeyPrefix = "dnsk_"
+	// apiKeyPrefixLen is the length of the key prefix stored to identify the key.
+	apiKeyPrefixLen = len(apiKeyPrefix) + 8
+	// apiKeyTouchInterval limit how often time of the last use is written to the database.
+	apiKeyTouchInterval = time.Minute
+)
+
+// newAPIKey return new random API key.
+func newAPIKey() (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + token, nil
+}
+
+// hashToken return hash of the API key or the refresh token stored in the database.
+// Tokens are random, so fast hash is enough and they are checked on every request without delay.
+func hashToken(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// bearerToken return token from the Authorization header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// authenticateAPIKey return context of the request made with the API key. Key act as the user
+// with its role, changes are recorded with "key:<name>" actor and restricted to its zones.
+func (s Server) authenticateAPIKey(ctx context.Context, token string) (context.Context, error) {
+	key, err := s.db.GetAPIKeyByHash(ctx, hashToken(token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New("unknown API key")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get API key from database: %w", err)
+	}
+
+	now := time.Now()
+	if !key.RevokedAt.IsZero() {
+		return nil, fmt.Errorf("API key %s is revoked", key.Name)
+	}
+	if !key.ExpiresAt.IsZero() && now.After(key.ExpiresAt) {
+		return nil, fmt.Errorf("API key %s is expired", key.Name)
+	}
+	if now.Sub(key.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.db.TouchAPIKey(ctx, key.ID, now); err != nil {
+			s.logger.Error("can't update last use of API key " + key.Name + ": " + err.Error())
+		}
+	}
+
+	actor := "key:" + key.Name
+	ctx = context.WithValue(ctx, "user", database.User{
+		Login:       actor,
+		Role:        key.Role,
+		Zones:       key.Zones,
+		Permissions: key.Permissions,
+	})
+	ctx = database.WithActor(ctx, actor)
+	return database.WithZones(ctx, key.Zones), nil
+}
+
+// toProtoAPIKey convert API key to the protobuf message, the key itself is not set.
+func toProtoAPIKey(key database.APIKey) *crudpb.APIKey {
+	msg := &crudpb.APIKey{
+		Id:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Role:      key.Role,
+		Zones:     key.Zones,
+		CreatedBy: key.CreatedBy,
+		CreatedAt: timestamppb.New(key.CreatedAt),
+	}
+	if !key.ExpiresAt.IsZero() {
+		msg.ExpiresAt = timestamppb.New(key.ExpiresAt)
+	}
+	if !key.LastUsedAt.IsZero() {
+		msg.LastUsedAt = timestamppb.New(key.LastUsedAt)
+	}
+	if !key.RevokedAt.IsZero() {
+		msg.RevokedAt = timestamppb.New(key.RevokedAt)
+	}
+	return msg
+}
+
+// getAPIKeysHandler handle requests for all API keys.
+func (s Server) getAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.db.GetAPIKeys(r.Context())
+	if err != nil {
+		s.logger.Error("can't get API keys: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	collection := &crudpb.APIKeyCollection{}
+	for _, key := range keys {
+		collection.Keys = append(collection.Keys, toProtoAPIKey(key))
+	}
+
+	resp, err := proto.Marshal(collection)
+	if err != nil {
+		s.logger.Error("can't marshal API keys: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("GET API keys, returned %d keys", len(keys)))
+}
+
+// postAPIKeyHandler handle requests for creating API key. Name, role, zones and expiry
+// are taken from the request, response contain the key, it can't be retrieved later.
+func (s Server) postAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/protobuf" {
+		s.logger.Error("Content-Type header is set to " + r.Header.Get("Content-Type"))
+		http.Error(w, "Accept only application/protobuf Content-Type", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Error("can't read request body from " + r.RemoteAddr)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	msg := &crudpb.APIKey{}
+	if err := proto.Unmarshal(body, msg); err != nil {
+		s.logger.Error("can't unmarshal body from " + r.RemoteAddr)
+		http.Error(w, "Incorrect message format", http.StatusBadRequest)
+		return
+	}
+
+	token, err := newAPIKey()
+	if err != nil {
+		s.logger.Error(err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	key := database.APIKey{
+		Name:      msg.GetName(),
+		Prefix:    token[:apiKeyPrefixLen],
+		Role:      msg.GetRole(),
+		Zones:     msg.GetZones(),
+		CreatedBy: database.ActorFromContext(r.Context()),
+	}
+	if msg.ExpiresAt != nil {
+		key.ExpiresAt = msg.ExpiresAt.AsTime()
+	}
+
+	key, err = s.db.AddAPIKey(r.Context(), key, hashToken(token))
+	if err != nil {
+		s.logger.Error("can't add API key: " + err.Error())
+		var verr *database.ValidationError
+		if errors.As(err, &verr) {
+			writeValidationErrors(w, verr)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := toProtoAPIKey(key)
+	resp.Key = token
+	b, err := proto.Marshal(resp)
+	if err != nil {
+		s.logger.Error("can't marshal API key: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+	s.logger.Info(fmt.Sprintf("POST API key %s %s with role %s", key.Name, key.Prefix, key.Role))
+}
+
+// deleteAPIKeyHandler handle requests for revoking API key. Revoked keys are kept in the list.
+func (s Server) deleteAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	pathID := r.PathValue("id")
+	id, err := strconv.ParseInt(pathID, 10, 32)
+	if err != nil {
+		s.logger.Error("can't parse id(" + pathID + "): " + err.Error())
+		http.Error(w, "Incorrect id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.RevokeAPIKey(r.Context(), int32(id)); err != nil {
+		s.logger.Error("can't revoke API key: " + err.Error())
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "Key not found or already revoked", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	s.logger.Info("DELETE API key " + pathID)
+}
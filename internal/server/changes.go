@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prionis/dns-server/internal/database"
+)
+
+// loadIndex load all resource records and views from the database to the index.
+func (s Server) loadIndex(ctx context.Context) error {
+	rrs, err := s.db.GetAllRecords(ctx)
+	if err != nil {
+		return fmt.Errorf("can't load resource records to the index: %w", err)
+	}
+	views, err := s.db.GetAllViews(ctx)
+	if err != nil {
+		return fmt.Errorf("can't load views to the index: %w", err)
+	}
+	if err := s.index.Load(rrs, views); err != nil {
+		s.logger.Error("some resource records are not loaded to the index: " + err.Error())
+	}
+	s.logger.Info(fmt.Sprintf("%d resource records and %d views loaded to the index",
+		s.index.Len(), len(views)))
+	return nil
+}
+
+// loadACL load rules of the access control list from the database.
+func (s Server) loadACL(ctx context.Context) error {
+	rules, err := s.db.GetACLRules(ctx)
+	if err != nil {
+		return fmt.Errorf("can't load rules of the access control list: %w", err)
+	}
+	s.acl.Load(rules)
+	return nil
+}
+
+// loadViews load all views from the database to the index.
+func (s Server) loadViews(ctx context.Context) error {
+	views, err := s.db.GetAllViews(ctx)
+	if err != nil {
+		return fmt.Errorf("can't load views to the index: %w", err)
+	}
+	s.index.SetViews(views)
+	return nil
+}
+
+// watchChanges apply changes made in the database by any server instance.
+// Index and ACL are loaded once the changes are listened, so no change is lost between
+// loading and listening. Notifications sent while connection is lost can't be received
+// later, so after reconnect they are loaded again. Result of the first load is sent
+// to loaded, watching is stopped if it failed.
+func (s Server) watchChanges(ctx context.Context, loaded chan<- error) {
+	started := false
+	ready := func() error {
+		if err := s.loadIndex(ctx); err != nil {
+			return err
+		}
+		if err := s.loadACL(ctx); err != nil {
+			return err
+		}
+		if !started {
+			started = true
+			loaded <- nil
+		}
+		return nil
+	}
+
+	for {
+		err := s.db.Listen(ctx, ready, s.applyChange)
+		if !started {
+			loaded <- err
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Error("listening changes in the database: " + err.Error())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// applyChange update in-memory state of the server according to the change in the database.
+func (s Server) applyChange(change database.Change) {
+	switch change.Table {
+	case database.TableResourceRecords:
+		if change.Operation == database.OperationDelete {
+			s.index.Delete(change.ID)
+			return
+		}
+
+		rr, err := s.db.GetRecord(context.Background(), change.ID)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("can't get changed resource record %d: %s",
+				change.ID, err.Error()))
+			return
+		}
+		if err := s.index.Put(rr); err != nil {
+			s.logger.Error("can't put resource record to the index: " + err.Error())
+		}
+
+	case database.TableViews:
+		if err := s.loadViews(context.Background()); err != nil {
+			s.logger.Error(err.Error())
+		}
+
+	case database.TableACLRules:
+		if err := s.loadACL(context.Background()); err != nil {
+			s.logger.Error(err.Error())
+		}
+	}
+}
@@ -1,12 +1,41 @@
 package server
 
-import "github.com/prionis/dns-server/internal/database"
+import (
+	"log/slog"
+	"net/netip"
+	"time"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/internal/metrics"
+	"github.com/prionis/dns-server/internal/rrl"
+)
 
 type options struct {
-	dnsPort  string
-	httpPort string
-	logger   Logger
-	db       database.Repository
+	dnsAddrs       []string
+	httpAddrs      []string
+	logger         Logger
+	db             database.Repository
+	clientSubnet   []netip.Prefix
+	aclRules       []database.ACLRule
+	rateLimit      *rrl.Config
+	viewLimits     map[string]rrl.Config
+	aclLimits      map[netip.Prefix]rrl.Config
+	statsRetention time.Duration
+
+	queryLogRetention time.Duration
+	metrics           *metrics.Metrics
+	logStream         *WebSocket
+	logLevel          slog.Level
+	levelVar          *slog.LevelVar
+	reloadSource      func() ([]Option, error)
+	jwtSecret         string
+	jwtPrevious       []string
+	accessTokenTTL    time.Duration
+	refreshTokenTTL   time.Duration
+	oidc              *OIDCConfig
+	reusePort         int
+	socketActivation  bool
+	adminPassword     string
 }
 
 type Option interface {
@@ -18,7 +47,7 @@ type Option interface {
 type dnsPort string
 
 func (p dnsPort) apply(opts *options) {
-	opts.dnsPort = string(p)
+	opts.dnsAddrs = []string{string(p)}
 }
 
 func SetDNSPort(p string) Option {
@@ -30,11 +59,72 @@ func SetDNSPort(p string) Option {
 type httpPort string
 
 func (p httpPort) apply(opts *options) {
-	opts.dnsPort = string(p)
+	opts.httpAddrs = []string{string(p)}
 }
 
 func SetHTTPPort(p string) Option {
-	return dnsPort(p)
+	return httpPort(p)
+}
+
+// DNS addresses option
+
+type dnsAddrsOption []string
+
+func (a dnsAddrsOption) apply(opts *options) {
+	opts.dnsAddrs = a
+}
+
+// WithDNSAddrs set addresses of the UDP and TCP DNS listeners, it replace address set by SetDNSPort.
+// Host of the address may be the name of the network interface, e.g. "eth0:53",
+// then the listeners are bound to every address of the interface.
+func WithDNSAddrs(addrs ...string) Option {
+	return dnsAddrsOption(addrs)
+}
+
+// HTTP addresses option
+
+type httpAddrsOption []string
+
+func (a httpAddrsOption) apply(opts *options) {
+	opts.httpAddrs = a
+}
+
+// WithHTTPAddrs set addresses of the HTTP API, it replace address set by SetHTTPPort.
+// Address with "unix:" prefix is the path of the Unix socket, e.g. "unix:/run/dns-server.sock".
+// Names of the network interfaces are accepted as for WithDNSAddrs.
+func WithHTTPAddrs(addrs ...string) Option {
+	return httpAddrsOption(addrs)
+}
+
+// Reuse port option
+
+type reusePortOption int
+
+func (r reusePortOption) apply(opts *options) {
+	opts.reusePort = int(r)
+}
+
+// WithReusePort bind the provided number of UDP sockets for every DNS address with SO_REUSEPORT,
+// so the kernel spread queries between them and they are served in parallel.
+// Single socket is bound if workers is less than 2.
+func WithReusePort(workers int) Option {
+	return reusePortOption(workers)
+}
+
+// Socket activation option
+
+type socketActivationOption bool
+
+func (a socketActivationOption) apply(opts *options) {
+	opts.socketActivation = bool(a)
+}
+
+// WithSocketActivation use sockets passed by systemd instead of binding them.
+// Stream sockets named "http" by FileDescriptorName= serve the HTTP API, other sockets serve DNS.
+// Addresses are used for the protocol which has no passed sockets.
+// Passed sockets are not changed by Reload.
+func WithSocketActivation(enabled bool) Option {
+	return socketActivationOption(enabled)
 }
 
 // Logger option
@@ -64,3 +154,291 @@ func (d dbOption) apply(opts *options) {
 func WithDB(db database.Repository) Option {
 	return dbOption{db}
 }
+
+// Client subnet option
+
+type clientSubnetOption []netip.Prefix
+
+func (c clientSubnetOption) apply(opts *options) {
+	opts.clientSubnet = c
+}
+
+// WithClientSubnet enable selection of the view by EDNS Client Subnet option of the queries
+// sent by resolvers from provided networks. Option can be set by any client, so it is
+// ignored in the queries of other clients.
+func WithClientSubnet(trusted ...netip.Prefix) Option {
+	return clientSubnetOption(trusted)
+}
+
+// ACL rules option
+
+type aclRulesOption []database.ACLRule
+
+func (a aclRulesOption) apply(opts *options) {
+	opts.aclRules = append(opts.aclRules, a...)
+}
+
+// WithACLRules add static rules to the access control list of the DNS clients.
+// Rules from the database with the same network replace them.
+func WithACLRules(rules ...database.ACLRule) Option {
+	return aclRulesOption(rules)
+}
+
+// Rate limit option
+
+type rateLimitOption rrl.Config
+
+func (r rateLimitOption) apply(opts *options) {
+	config := rrl.Config(r)
+	opts.rateLimit = &config
+}
+
+// WithRateLimit enable response rate limiting of the UDP responses.
+func WithRateLimit(config rrl.Config) Option {
+	return rateLimitOption(config)
+}
+
+// View rate limit option
+
+type viewRateLimitOption struct {
+	view   string
+	config rrl.Config
+}
+
+func (v viewRateLimitOption) apply(opts *options) {
+	if opts.viewLimits == nil {
+		opts.viewLimits = make(map[string]rrl.Config)
+	}
+	opts.viewLimits[v.view] = v.config
+}
+
+// WithViewRateLimit set configuration of the response rate limiting for the view with provided name.
+// Responses of other views are limited with configuration set by WithRateLimit.
+func WithViewRateLimit(view string, config rrl.Config) Option {
+	return viewRateLimitOption{view: view, config: config}
+}
+
+// ACL rate limit option
+
+type aclRateLimitOption struct {
+	network netip.Prefix
+	config  rrl.Config
+}
+
+func (a aclRateLimitOption) apply(opts *options) {
+	if opts.aclLimits == nil {
+		opts.aclLimits = make(map[netip.Prefix]rrl.Config)
+	}
+	opts.aclLimits[a.network.Masked()] = a.config
+}
+
+// WithACLRateLimit set configuration of the response rate limiting for the clients from the network
+// of the access control list rule. It is used instead of the configuration of the view,
+// the most specific network containing the client is selected.
+func WithACLRateLimit(network netip.Prefix, config rrl.Config) Option {
+	return aclRateLimitOption{network: network, config: config}
+}
+
+// Statistics retention option
+
+type statsRetentionOption time.Duration
+
+func (r statsRetentionOption) apply(opts *options) {
+	opts.statsRetention = time.Duration(r)
+}
+
+// WithStatsRetention set how long query statistics are stored, 7 days by default.
+func WithStatsRetention(d time.Duration) Option {
+	return statsRetentionOption(d)
+}
+
+// Query log retention option
+
+type queryLogRetentionOption time.Duration
+
+func (r queryLogRetentionOption) apply(opts *options) {
+	opts.queryLogRetention = time.Duration(r)
+}
+
+// WithQueryLogRetention set how long entries of the query log are stored, 7 days by default.
+func WithQueryLogRetention(d time.Duration) Option {
+	return queryLogRetentionOption(d)
+}
+
+// Metrics option
+
+type metricsOption struct {
+	metrics *metrics.Metrics
+}
+
+func (m metricsOption) apply(opts *options) {
+	opts.metrics = m.metrics
+}
+
+// WithMetrics set metrics collected by the server.
+// It allow to share metrics with the database tracer, new metrics are created by default.
+func WithMetrics(m *metrics.Metrics) Option {
+	return metricsOption{metrics: m}
+}
+
+// Log stream option
+
+type logStreamOption struct {
+	ws *WebSocket
+}
+
+func (l logStreamOption) apply(opts *options) {
+	opts.logStream = l.ws
+}
+
+// WithLogStream set WebSocket writer of the application log, its clients are connected through /api/logs/ws.
+func WithLogStream(ws *WebSocket) Option {
+	return logStreamOption{ws: ws}
+}
+
+// Log level option
+
+type logLevelOption slog.Level
+
+func (l logLevelOption) apply(opts *options) {
+	opts.logLevel = slog.Level(l)
+}
+
+// WithLogLevel set minimal level of the application log, info by default.
+// It take effect only with WithLevelVar.
+func WithLogLevel(level slog.Level) Option {
+	return logLevelOption(level)
+}
+
+type levelVarOption struct {
+	levelVar *slog.LevelVar
+}
+
+func (l levelVarOption) apply(opts *options) {
+	opts.levelVar = l.levelVar
+}
+
+// WithLevelVar set level variable of the log handler, it is set to the level from WithLogLevel
+// on start and on every reload.
+func WithLevelVar(levelVar *slog.LevelVar) Option {
+	return levelVarOption{levelVar: levelVar}
+}
+
+// Reload source option
+
+type reloadSourceOption func() ([]Option, error)
+
+func (r reloadSourceOption) apply(opts *options) {
+	opts.reloadSource = r
+}
+
+// WithReloadSource set function that read configuration, e.g. from the file.
+// Returned options are applied after options of NewServer when the server is created
+// and on every Reload. Only listener addresses, ACL rules, rate limits, client subnet,
+// retentions, JWT secrets, token lifetimes, OIDC and log level are changed by Reload.
+func WithReloadSource(source func() ([]Option, error)) Option {
+	return reloadSourceOption(source)
+}
+
+// JWT secret option
+
+type jwtSecretOption string
+
+func (j jwtSecretOption) apply(opts *options) {
+	opts.jwtSecret = string(j)
+}
+
+// WithJWTSecret set key for signing of the API tokens, JWT_SECRET environment variable by default.
+// ID of the key is written to the kid header of the tokens.
+func WithJWTSecret(secret string) Option {
+	return jwtSecretOption(secret)
+}
+
+// Previous JWT secrets option
+
+type jwtPreviousOption []string
+
+func (j jwtPreviousOption) apply(opts *options) {
+	opts.jwtPrevious = j
+}
+
+// WithPreviousJWTSecrets set keys that are not used for signing anymore, but tokens signed
+// with them are accepted until they expire. It allow to rotate the key without logging out all users:
+// set the new key with WithJWTSecret, move the old one here and remove it after access tokens expire.
+func WithPreviousJWTSecrets(secrets ...string) Option {
+	return jwtPreviousOption(secrets)
+}
+
+// Token TTL option
+
+type tokenTTLOption struct {
+	access  time.Duration
+	refresh time.Duration
+}
+
+func (t tokenTTLOption) apply(opts *options) {
+	opts.accessTokenTTL = t.access
+	opts.refreshTokenTTL = t.refresh
+}
+
+// WithTokenTTL set lifetime of the access tokens, 15 minutes by default,
+// and of the refresh tokens, 14 days by default.
+func WithTokenTTL(access, refresh time.Duration) Option {
+	return tokenTTLOption{access: access, refresh: refresh}
+}
+
+// OIDC option
+
+// OIDCConfig contain settings of the login through the OpenID Connect provider.
+type OIDCConfig struct {
+	// Issuer is the URL of the provider, its configuration is discovered on the first login.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the URL of /auth/oidc/callback registered at the provider.
+	RedirectURL string
+	// Scopes are requested in addition to openid, "profile" and "email" by default.
+	Scopes []string
+	// LoginClaim is the claim used as login of the created user, "preferred_username" by default.
+	LoginClaim string
+	// RolesClaim is the claim with groups or roles of the user, "groups" by default.
+	// Names of the nested claims are separated by dots, e.g. "realm_access.roles".
+	RolesClaim string
+	// Roles map values of the roles claim to local roles, the first matching mapping is used.
+	Roles []OIDCRole
+	// DefaultRole is assigned if no mapping match. Users without role can't log in.
+	DefaultRole string
+}
+
+// OIDCRole map value of the roles claim to the local role.
+type OIDCRole struct {
+	Value string
+	Role  string
+}
+
+type oidcOption OIDCConfig
+
+func (o oidcOption) apply(opts *options) {
+	config := OIDCConfig(o)
+	opts.oidc = &config
+}
+
+// WithOIDC enable login through the OpenID Connect provider by the authorization code flow with PKCE.
+// User is created on the first login and its role is updated from the claims on every login.
+func WithOIDC(config OIDCConfig) Option {
+	return oidcOption(config)
+}
+
+// Admin password option
+
+type adminPasswordOption string
+
+func (a adminPasswordOption) apply(opts *options) {
+	opts.adminPassword = string(a)
+}
+
+// WithAdminPassword set password of the admin created on the first start, when there are no users.
+// If it is empty, random password is generated and logged, it must be changed on the first login.
+func WithAdminPassword(password string) Option {
+	return adminPasswordOption(password)
+}
@@ -9,16 +9,21 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/miekg/dns"
 	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/internal/tracing"
 	"github.com/prionis/dns-server/proto/crud/genproto/crudpb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -28,35 +33,69 @@ import (
 func (s Server) dnsHandler(w dns.ResponseWriter, msg *dns.Msg) {
 	m := new(dns.Msg)
 	m.SetReply(msg)
+
+	ctx := queryContext(w)
+	addr, subnet := s.clientAddr(w, msg)
+	view := s.index.SelectView(addr)
+	if subnet != nil {
+		opt := &dns.OPT{Hdr: dns.RR_Header{Name: ".", Rrtype: dns.TypeOPT}}
+		opt.SetUDPSize(msg.IsEdns0().UDPSize())
+		opt.Option = append(opt.Option, subnet)
+		m.Extra = append(m.Extra, opt)
+	}
+
 	for _, question := range m.Question {
-		answers, err := s.db.FindRecords(context.Background(), question.Name, dns.TypeToString[question.Qtype])
-		if err != nil {
-			slog.Error("can't get resource records from database: " + err.Error())
-		}
+		_, span := tracing.Tracer().Start(ctx, "index lookup", trace.WithAttributes(
+			attribute.String("dns.question.name", question.Name),
+			attribute.String("dns.view", s.index.ViewName(view)),
+		))
+		answers := s.index.Find(question.Name, dns.TypeToString[question.Qtype], view)
+		span.SetAttributes(attribute.Int("dns.answers", len(answers)))
+		span.End()
 		if len(answers) == 0 {
-			slog.Warn("domain '" + question.Name + "' and type '" +
+			slog.Debug("domain '" + question.Name + "' and type '" +
 				dns.TypeToString[question.Qtype] + "' not found")
+			// Negative answer is authoritative only inside the zone with SOA record,
+			// names outside of the zones are answered with empty NOERROR as before.
+			if soa := s.authority(question.Name, view); soa != nil {
+				m.Authoritative = true
+				m.Ns = append(m.Ns, soa)
+				if !s.index.Exists(question.Name, view) {
+					m.Rcode = dns.RcodeNameError
+				}
+			}
 		}
 		for _, answer := range answers {
-			rr, err := dns.NewRR(fmt.Sprintf("%s %d %s %s %s",
-				answer.Domain,
-				answer.TTL,
-				answer.Class,
-				answer.Type,
-				answer.Data,
-			))
-			if err != nil {
-				slog.Error("can't parse resource record from database to answer")
-			}
-			slog.Info("found answer: " + rr.String())
+			// Prepared RR is shared with other queries, so the copy is answered.
+			rr := dns.Copy(answer.RR)
+			slog.Debug("found answer: " + rr.String())
 			m.Answer = append(m.Answer, rr)
 		}
 	}
-	slog.Info(m.String())
+	slog.Debug(m.String())
 	w.WriteMsg(m)
 }
 
-// loginHandler handle login requests, accept user credentials, process and add jwt token to the response.
+// authority return SOA record of the closest zone containing the name in the view
+// for the authority section of the negative answer, nil if the name is outside of the zones.
+// TTL of the record is the negative caching TTL of the zone (RFC 2308).
+func (s Server) authority(name string, view int32) dns.RR {
+	for _, i := range dns.Split(name) {
+		entries := s.index.Find(name[i:], "SOA", view)
+		if len(entries) == 0 {
+			continue
+		}
+		soa, ok := dns.Copy(entries[0].RR).(*dns.SOA)
+		if !ok {
+			return nil
+		}
+		soa.Hdr.Ttl = min(soa.Hdr.Ttl, soa.Minttl)
+		return soa
+	}
+	return nil
+}
+
+// loginHandler handle login requests, accept user credentials and add access and refresh tokens to the response.
 func (s Server) loginHandler(w http.ResponseWriter, r *http.Request) {
 	credentials := &crudpb.Login{}
 
@@ -103,56 +142,78 @@ func (s Server) loginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":         user.ID,
-		"login":      user.Login,
-		"role":       user.Role,
-		"first_name": user.FirstName,
-		"last_name":  user.LastName,
-	})
-
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		s.logger.Error("JWT_SECRET environment variable is not set")
+	if err := s.issueTokens(r.Context(), w, user); err != nil {
+		s.logger.Error("can't issue tokens for user " + user.Login + ": " + err.Error())
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	tokenString, err := token.SignedString([]byte(secret))
+	s.writeUser(w, user)
+	s.logger.Info(fmt.Sprintf("Login for %s %s %s(%s) handled",
+		user.Role, user.FirstName, user.LastName, user.Login))
+}
+
+// registerHandler handle self-registration requests with the invitation and return created user.
+// Role and zones of the user are taken from the invitation, role of the request is ignored.
+func (s Server) registerHandler(w http.ResponseWriter, r *http.Request) {
+	credentials := &crudpb.Register{}
+
+	if r.Header.Get("Content-Type") != "application/protobuf" {
+		s.logger.Error("Content-Type header is set to " + r.Header.Get("Content-Type"))
+		http.Error(w, "Accept only application/protobuf Content-Type", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		s.logger.Error("can't sign new JWT token for user " + user.Login + ": " + err.Error())
+		s.logger.Error("can't read request body from " + r.RemoteAddr)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	defer r.Body.Close()
+
+	err = proto.Unmarshal(body, credentials)
+	if err != nil {
+		s.logger.Error("can't unmarshal body from " + r.RemoteAddr)
+		http.Error(w, "Incorrect message format", http.StatusBadRequest)
+		return
+	}
 
-	cookie := http.Cookie{
-		Name:     "jwt",
-		Value:    tokenString,
-		Path:     "/",
-		HttpOnly: true,
-		MaxAge:   14 * 24 * 60 * 60,
-		SameSite: http.SameSiteStrictMode,
+	if credentials.Invitation == "" {
+		s.logger.Error("registration of " + credentials.Login + " without invitation")
+		http.Error(w, "Invitation is required", http.StatusForbidden)
+		return
 	}
-	http.SetCookie(w, &cookie)
 
-	u := &crudpb.User{
-		Id:        user.ID,
-		Login:     user.Login,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Role:      user.Role,
+	_, err = s.db.RegisterUser(r.Context(),
+		database.User{
+			Login:     credentials.Login,
+			FirstName: credentials.FirstName,
+			LastName:  credentials.LastName,
+		}, credentials.Password, hashToken(credentials.Invitation))
+	if err != nil {
+		s.logger.Error("can't register new user " +
+			credentials.Login + ": " +
+			err.Error())
+		writeUserError(w, err)
+		return
 	}
-	b, err := proto.Marshal(u)
 
-	w.WriteHeader(http.StatusOK)
-	w.Header().Add("Content-Type", "application/protobuf")
-	w.Write(b)
-	s.logger.Info(fmt.Sprintf("Login for %s %s %s(%s) handled",
+	user, err := s.db.GetUser(r.Context(), credentials.Login)
+	if err != nil {
+		s.logger.Error("can't retrive user from database: " + err.Error())
+		http.Error(w, "Internal error, try later", http.StatusInternalServerError)
+		return
+	}
+
+	s.writeUser(w, user)
+	s.logger.Info(fmt.Sprintf("Register new user: %s %s %s(%s)",
 		user.Role, user.FirstName, user.LastName, user.Login))
 }
 
-// registerHandler handle add user requests and return created user.
-func (s Server) registerHandler(w http.ResponseWriter, r *http.Request) {
+// postUserHandler handle requests of the admin for adding user and return created user.
+// Password of the user is temporary, it must be changed on the first login.
+func (s Server) postUserHandler(w http.ResponseWriter, r *http.Request) {
 	credentials := &crudpb.Register{}
 
 	if r.Header.Get("Content-Type") != "application/protobuf" {
@@ -176,49 +237,133 @@ func (s Server) registerHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := s.db.AddUser(r.Context(),
-		database.User{
-			Login:     credentials.Login,
-			FirstName: credentials.FirstName,
-			LastName:  credentials.LastName,
-			Role:      credentials.Role,
-		}, credentials.Password)
+	user := database.User{
+		Login:              credentials.Login,
+		FirstName:          credentials.FirstName,
+		LastName:           credentials.LastName,
+		Role:               credentials.Role,
+		MustChangePassword: true,
+	}
+	user.ID, err = s.db.AddUser(r.Context(), user, credentials.Password)
 	if err != nil {
-		s.logger.Error("can't register new user " +
+		s.logger.Error("can't add new user " +
 			credentials.Login + ": " +
 			err.Error())
-		var pgErr *pgconn.PgError
-		var errStr string
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case "23502", "23503": // not_null_violation
-				errStr = "Uknown role"
+		writeUserError(w, err)
+		return
+	}
 
-			case "23505": // unique_violation
-				errStr = "Already exist"
+	s.writeUser(w, user)
+	s.logger.Info(fmt.Sprintf("POST user: %s %s %s(%s)",
+		user.Role, user.FirstName, user.LastName, user.Login))
+}
 
-			default:
-				errStr = "Can't update user"
-			}
+// writeUserError write response for the error of adding the user.
+func writeUserError(w http.ResponseWriter, err error) {
+	var verr *database.ValidationError
+	if errors.As(err, &verr) {
+		writeValidationErrors(w, verr)
+		return
+	}
+	if errors.Is(err, database.ErrInvalidInvitation) {
+		http.Error(w, "Invitation is invalid or expired", http.StatusForbidden)
+		return
+	}
+
+	var pgErr *pgconn.PgError
+	var errStr string
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23502", "23503": // not_null_violation
+			errStr = "Uknown role"
+
+		case "23505": // unique_violation
+			errStr = "Already exist"
+
+		default:
+			errStr = "Can't update user"
 		}
-		http.Error(w, errStr, http.StatusInternalServerError)
+	}
+	http.Error(w, errStr, http.StatusInternalServerError)
+}
+
+// changePasswordHandler handle password change of the logged in user. Other sessions of the user
+// are ended: its refresh tokens are revoked and new tokens are returned for this session.
+func (s Server) changePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(database.User)
+	if !ok {
+		s.logger.Error("can't get user from context")
+		http.Error(w, "Internal error, try later", http.StatusInternalServerError)
+		return
+	}
+	if user.ID == 0 {
+		s.logger.Error("password change of " + user.Login + " requested with API key")
+		http.Error(w, "API keys have no password", http.StatusForbidden)
 		return
 	}
 
-	u := &crudpb.User{
-		Id:        id,
-		Login:     credentials.Login,
-		FirstName: credentials.FirstName,
-		LastName:  credentials.LastName,
-		Role:      credentials.Role,
+	if r.Header.Get("Content-Type") != "application/protobuf" {
+		s.logger.Error("Content-Type header is set to " + r.Header.Get("Content-Type"))
+		http.Error(w, "Accept only application/protobuf Content-Type", http.StatusBadRequest)
+		return
 	}
-	b, err := proto.Marshal(u)
 
-	w.WriteHeader(http.StatusOK)
-	w.Header().Add("Content-Type", "application/protobuf")
-	w.Write(b)
-	s.logger.Info(fmt.Sprintf("Register new user: %s %s %s(%s)",
-		credentials.Role, credentials.FirstName, credentials.LastName, credentials.Login))
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Error("can't read request body from " + r.RemoteAddr)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	msg := &crudpb.ChangePassword{}
+	if err := proto.Unmarshal(body, msg); err != nil {
+		s.logger.Error("can't unmarshal body from " + r.RemoteAddr)
+		http.Error(w, "Incorrect message format", http.StatusBadRequest)
+		return
+	}
+
+	_, err = s.db.CheckUserPassword(r.Context(), user.Login, msg.OldPassword)
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		s.logger.Error("incorrect old password of " + user.Login)
+		http.Error(w, "Incorrect password", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		s.logger.Error("can't check password of " + user.Login + ": " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if msg.NewPassword == msg.OldPassword {
+		http.Error(w, "New password must differ from the old one", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.SetUserPassword(r.Context(), user.ID, msg.NewPassword, false); err != nil {
+		s.logger.Error("can't change password of " + user.Login + ": " + err.Error())
+		var verr *database.ValidationError
+		if errors.As(err, &verr) {
+			writeValidationErrors(w, verr)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := s.db.RevokeUserRefreshTokens(r.Context(), user.ID); err != nil {
+		s.logger.Error("can't revoke refresh tokens of " + user.Login + ": " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	user.MustChangePassword = false
+	if err := s.issueTokens(r.Context(), w, user); err != nil {
+		s.logger.Error("can't issue tokens for user " + user.Login + ": " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.writeUser(w, user)
+	s.logger.Info("Password of " + user.Login + " changed")
 }
 
 // getRecordHandler handle get request for resource records.
@@ -241,15 +386,7 @@ func (s Server) getRecordHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
-	protoRR := &crudpb.ResourceRecord{
-		Id:         rr.ID,
-		Domain:     rr.Domain,
-		Data:       rr.Data,
-		Type:       rr.Type,
-		Class:      rr.Class,
-		TimeToLive: rr.TTL,
-	}
-	body, err := proto.Marshal(protoRR)
+	body, err := proto.Marshal(toProtoRecord(rr))
 	if err != nil {
 		s.logger.Error("can't marshal resource record message: " + err.Error())
 	}
@@ -272,14 +409,7 @@ func (s Server) getAllRecordsHandler(w http.ResponseWriter, r *http.Request) {
 
 	records := &crudpb.ResourceRecordCollection{}
 	for _, rr := range rrs {
-		records.Records = append(records.Records, &crudpb.ResourceRecord{
-			Id:         rr.ID,
-			Domain:     rr.Domain,
-			Data:       rr.Data,
-			Class:      rr.Class,
-			Type:       rr.Type,
-			TimeToLive: int32(rr.TTL),
-		})
+		records.Records = append(records.Records, toProtoRecord(rr))
 	}
 
 	resp, err := proto.Marshal(records)
@@ -296,6 +426,83 @@ func (s Server) getAllRecordsHandler(w http.ResponseWriter, r *http.Request) {
 		strconv.FormatInt(int64(len(rrs)), 10) + " records")
 }
 
+// searchRecordsHandler handle search requests for resource records and return one page of found records.
+func (s Server) searchRecordsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.RecordFilter{
+		Domain: query.Get("domain"),
+		Type:   query.Get("type"),
+		Class:  query.Get("class"),
+		Data:   query.Get("data"),
+		Zone:   query.Get("zone"),
+		Query:  query.Get("q"),
+		SortBy: query.Get("sort"),
+		Cursor: query.Get("cursor"),
+		Limit:  100,
+	}
+
+	switch filter.SortBy {
+	case "", "id", "domain", "type", "class", "data", "ttl":
+	default:
+		s.logger.Error("unknown sort field " + filter.SortBy)
+		http.Error(w, "Unknown sort field", http.StatusBadRequest)
+		return
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		s.logger.Error("unknown sort order " + query.Get("order"))
+		http.Error(w, "Order must be asc or desc", http.StatusBadRequest)
+		return
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || limit < 1 || limit > 1000 {
+			s.logger.Error("incorrect limit " + limitStr)
+			http.Error(w, "Limit must be a number from 1 to 1000", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = int32(limit)
+	}
+
+	rrs, cursor, err := s.db.SearchRecords(r.Context(), filter)
+	if err != nil {
+		s.logger.Error("can't search records in database: " + err.Error())
+		if errors.Is(err, database.ErrCursorMismatch) {
+			http.Error(w, "Cursor doesn't match sort and order", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, database.ErrInvalidCursor) {
+			http.Error(w, "Incorrect cursor", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	page := &crudpb.ResourceRecordPage{NextCursor: cursor}
+	for _, rr := range rrs {
+		page.Records = append(page.Records, toProtoRecord(rr))
+	}
+
+	resp, err := proto.Marshal(page)
+	if err != nil {
+		s.logger.Error("can't marshal page of records: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info("GET search resource records, returned " +
+		strconv.FormatInt(int64(len(rrs)), 10) + " records")
+}
+
 // getUserHandler handle get user requests and return user with provided ID.
 func (s Server) getUserHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
@@ -312,11 +519,13 @@ func (s Server) getUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	u := &crudpb.User{
-		Id:        user.ID,
-		Login:     user.Login,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Role:      user.Role,
+		Id:          user.ID,
+		Login:       user.Login,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		Role:        user.Role,
+		Zones:       user.Zones,
+		Permissions: user.Permissions,
 	}
 	b, err := proto.Marshal(u)
 
@@ -343,6 +552,7 @@ func (s Server) getAllUsersHandler(w http.ResponseWriter, r *http.Request) {
 			FirstName: user.FirstName,
 			LastName:  user.LastName,
 			Role:      user.Role,
+			Zones:     user.Zones,
 		})
 	}
 
@@ -434,9 +644,15 @@ func (s Server) patchUserHandler(w http.ResponseWriter, r *http.Request) {
 		FirstName: user.FirstName,
 		LastName:  user.LastName,
 		Role:      user.Role,
+		Zones:     user.Zones,
 	}, user.Password)
 	if err != nil {
 		s.logger.Error("can't update user: " + err.Error())
+		var verr *database.ValidationError
+		if errors.As(err, &verr) {
+			writeValidationErrors(w, verr)
+			return
+		}
 		var pgErr *pgconn.PgError
 		var errStr string
 		if errors.As(err, &pgErr) {
@@ -455,6 +671,11 @@ func (s Server) patchUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Password is changed by every update, so sessions of the user are ended.
+	if err := s.db.RevokeUserRefreshTokens(r.Context(), user.Id); err != nil {
+		s.logger.Error("can't revoke refresh tokens of the user: " + err.Error())
+	}
+
 	w.WriteHeader(http.StatusOK)
 	s.logger.Info(fmt.Sprintf("PATCH user, user %d was updated: %s %s %s(%s)",
 		user.Id, user.Role, user.FirstName, user.LastName, user.Login))
@@ -479,9 +700,18 @@ func (s Server) deleteRRHandler(w http.ResponseWriter, r *http.Request) {
 	err = s.db.DeleteRecord(r.Context(), int32(id))
 	if err != nil {
 		s.logger.Error("can't delete resource record: " + err.Error())
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "Resource record not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, database.ErrZoneNotAllowed) {
+			http.Error(w, recordErrorMessage(err), http.StatusForbidden)
+			return
+		}
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	s.index.Delete(int32(id))
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Resource record with id " + pathID + "successfull deleted"))
 	slog.Info("DELETE resource record " + pathID)
@@ -511,57 +741,106 @@ func (s Server) postRRHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := s.db.AddRecord(r.Context(),
-		database.ResourceRecord{
-			Domain: rr.Domain,
-			Data:   rr.Data,
-			Type:   rr.Type,
-			Class:  rr.Class,
-			TTL:    rr.TimeToLive,
-		})
+	record, err := recordFromProto(rr)
 	if err != nil {
-		s.logger.Error("can't add resource record: " + err.Error())
-		var pgErr *pgconn.PgError
-		var errStr string
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case "23502", "23503": // not_null_violation
-				errStr = "Uknown type or class"
-
-			case "23505": // unique_violation
-				errStr = "Already exist"
-
-			default:
-				errStr = "Can't update"
-			}
-		}
-		http.Error(w, errStr, http.StatusInternalServerError)
+		s.writeRecordError(w, "can't add resource record: ", err)
 		return
 	}
 
-	protoRR := &crudpb.ResourceRecord{
-		Id:         id,
-		Domain:     rr.Domain,
-		Data:       rr.Data,
-		Type:       rr.Type,
-		Class:      rr.Class,
-		TimeToLive: rr.TimeToLive,
+	id, err := s.db.AddRecord(r.Context(), record)
+	if err != nil {
+		s.writeRecordError(w, "can't add resource record: ", err)
+		return
+	}
+	record.ID = id
+	if err := s.index.Put(record); err != nil {
+		s.logger.Error("can't put resource record to the index: " + err.Error())
 	}
 
-	result, err := proto.Marshal(protoRR)
+	result, err := proto.Marshal(toProtoRecord(record))
 
-	w.WriteHeader(http.StatusOK)
 	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
 	w.Write(result)
 	s.logger.Info(fmt.Sprintf("POST resource record: %s %d %s %s %s",
-		rr.Domain, rr.TimeToLive, rr.Class, rr.Type, rr.Data,
+		record.Domain, record.TTL, record.Class, record.Type, record.Data,
 	))
 }
 
-// patchRRHandler handle update of the resource record requests.
-func (s Server) patchRRHandler(w http.ResponseWriter, r *http.Request) {
-	rr := &crudpb.ResourceRecord{}
+// writeRecordError write error of the resource record change to the response.
+// Invalid fields are returned in the ValidationErrors message.
+func (s Server) writeRecordError(w http.ResponseWriter, msg string, err error) {
+	s.logger.Error(msg + err.Error())
+
+	var verr *database.ValidationError
+	if errors.As(err, &verr) {
+		writeValidationErrors(w, verr)
+		return
+	}
+	if errors.Is(err, database.ErrZoneNotAllowed) {
+		http.Error(w, recordErrorMessage(err), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, recordErrorMessage(err), http.StatusNotFound)
+		return
+	}
+
+	http.Error(w, recordErrorMessage(err), http.StatusInternalServerError)
+}
+
+// writeValidationErrors write invalid fields in the ValidationErrors message to the response.
+func writeValidationErrors(w http.ResponseWriter, verr *database.ValidationError) {
+	errs := &crudpb.ValidationErrors{}
+	for _, field := range verr.Fields {
+		errs.Errors = append(errs.Errors, &crudpb.FieldError{
+			Field:   field.Field,
+			Message: field.Message,
+		})
+	}
+	b, err := proto.Marshal(errs)
+	if err != nil {
+		http.Error(w, verr.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(b)
+}
+
+// recordErrorMessage return message about failed change of the resource record for the client.
+func recordErrorMessage(err error) string {
+	var verr *database.ValidationError
+	if errors.As(err, &verr) {
+		return verr.Error()
+	}
+	if errors.Is(err, database.ErrZoneNotAllowed) {
+		return "Zone is not allowed"
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "Not found"
+	}
+
+	var pgErr *pgconn.PgError
+	var errStr string
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23502", "23503": // not_null_violation
+			errStr = "Uknown type or class"
+
+		case "23505": // unique_violation
+			errStr = "Already exist"
+
+		default:
+			errStr = "Can't update"
+		}
+	}
+	return errStr
+}
 
+// batchRRHandler handle requests with batch of operations with resource records.
+// Operations are applied all together or none of them.
+func (s Server) batchRRHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Header.Get("Content-Type") != "application/protobuf" {
 		s.logger.Error("Content-Type header is set to " + r.Header.Get("Content-Type"))
 		http.Error(w, "Accept only application/protobuf Content-Type", http.StatusBadRequest)
@@ -576,32 +855,877 @@ func (s Server) patchRRHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	err = proto.Unmarshal(body, rr)
+	batch := &crudpb.RecordBatch{}
+	err = proto.Unmarshal(body, batch)
 	if err != nil {
 		s.logger.Error("can't unmarshal body from " + r.RemoteAddr)
 		http.Error(w, "Incorrect message format", http.StatusBadRequest)
 		return
 	}
 
-	err = s.db.UpdateRecord(r.Context(),
-		database.ResourceRecord{
-			ID:     rr.Id,
-			Domain: rr.Domain,
-			Data:   rr.Data,
-			Type:   rr.Type,
-			Class:  rr.Class,
-			TTL:    rr.TimeToLive,
-		})
-	if err != nil {
-		s.logger.Error("can't update user: " + err.Error())
+	if len(batch.Operations) == 0 || len(batch.Operations) > 1000 {
+		s.logger.Error(fmt.Sprintf("batch with %d operations from %s",
+			len(batch.Operations), r.RemoteAddr))
+		http.Error(w, "Batch must contain from 1 to 1000 operations", http.StatusBadRequest)
+		return
+	}
+
+	ops := make([]database.RecordOperation, 0, len(batch.Operations))
+	// Invalid records are reported without applying the batch.
+	checked := make([]database.RecordOperationResult, 0, len(batch.Operations))
+	valid := true
+	for _, op := range batch.Operations {
+		operation := database.RecordOperation{
+			Record: database.ResourceRecord{ID: op.GetRecord().GetId()},
+		}
+		switch op.Action {
+		case crudpb.RecordOperation_ACTION_CREATE:
+			operation.Action = database.ActionCreate
+		case crudpb.RecordOperation_ACTION_UPDATE:
+			operation.Action = database.ActionUpdate
+		case crudpb.RecordOperation_ACTION_DELETE:
+			operation.Action = database.ActionDelete
+		}
+
+		result := database.RecordOperationResult{ID: operation.Record.ID}
+		if operation.Action == database.ActionCreate || operation.Action == database.ActionUpdate {
+			operation.Record, result.Err = recordFromProto(op.GetRecord())
+			valid = valid && result.Err == nil
+		}
+		checked = append(checked, result)
+		ops = append(ops, operation)
+	}
+
+	results, err := checked, database.ErrBatchFailed
+	if valid {
+		results, err = s.db.ApplyRecordBatch(r.Context(), ops, batch.DryRun)
+	}
+	if err != nil && !errors.Is(err, database.ErrBatchFailed) {
+		s.logger.Error("can't apply batch of operations: " + err.Error())
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	failed := err != nil
+	applied := !failed && !batch.DryRun
+	resp := &crudpb.RecordBatchResult{Applied: applied}
+	for i, result := range results {
+		res := &crudpb.RecordOperationResult{Id: result.ID}
+		if result.Err != nil {
+			res.Error = recordErrorMessage(result.Err)
+			if res.Error == "" {
+				res.Error = result.Err.Error()
+			}
+		} else if applied {
+			switch ops[i].Action {
+			case database.ActionCreate, database.ActionUpdate:
+				rr := ops[i].Record
+				rr.ID = result.ID
+				if err := s.index.Put(rr); err != nil {
+					s.logger.Error("can't put resource record to the index: " + err.Error())
+				}
+			case database.ActionDelete:
+				s.index.Delete(result.ID)
+			}
+		}
+		resp.Results = append(resp.Results, res)
+	}
+
+	b, err := proto.Marshal(resp)
+	if err != nil {
+		s.logger.Error("can't marshal batch result: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if failed {
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(status)
+	w.Write(b)
+	s.logger.Info(fmt.Sprintf("POST batch of %d resource record operations, dry run: %t, applied: %t",
+		len(ops), batch.DryRun, applied))
+}
+
+// patchRRHandler handle update of the resource record requests.
+func (s Server) patchRRHandler(w http.ResponseWriter, r *http.Request) {
+	rr := &crudpb.ResourceRecord{}
+
+	if r.Header.Get("Content-Type") != "application/protobuf" {
+		s.logger.Error("Content-Type header is set to " + r.Header.Get("Content-Type"))
+		http.Error(w, "Accept only application/protobuf Content-Type", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Error("can't read request body from " + r.RemoteAddr)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	err = proto.Unmarshal(body, rr)
+	if err != nil {
+		s.logger.Error("can't unmarshal body from " + r.RemoteAddr)
+		http.Error(w, "Incorrect message format", http.StatusBadRequest)
+		return
+	}
+
+	record, err := recordFromProto(rr)
+	if err != nil {
+		s.writeRecordError(w, "can't update resource record: ", err)
+		return
+	}
+
+	err = s.db.UpdateRecord(r.Context(), record)
+	if err != nil {
+		s.writeRecordError(w, "can't update resource record: ", err)
+		return
+	}
+	if err := s.index.Put(record); err != nil {
+		s.logger.Error("can't put resource record to the index: " + err.Error())
+	}
+
+	w.WriteHeader(http.StatusOK)
 	s.logger.Info(fmt.Sprintf("PATCH resource record, "+
 		"resource record with id %d was updated: %s %d %s %s %s",
-		rr.Id, rr.Domain, rr.TimeToLive, rr.Class, rr.Type, rr.Data))
+		record.ID, record.Domain, record.TTL, record.Class, record.Type, record.Data))
+}
+
+// getRecordHistoryHandler handle requests for all changes of the resource record.
+func (s Server) getRecordHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	pathID := r.PathValue("id")
+	id, err := strconv.ParseInt(pathID, 10, 32)
+	if err != nil {
+		s.logger.Error("can't parse id(" + pathID + "): " + err.Error())
+		http.Error(w, "Incorrect id", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := s.db.GetRecordHistory(r.Context(), int32(id))
+	if err != nil {
+		s.logger.Error("can't get history of resource record: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := proto.Marshal(toProtoChanges(changes))
+	if err != nil {
+		s.logger.Error("can't marshal history of resource record: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("GET history of resource record %d, %d changes returned",
+		id, len(changes)))
+}
+
+// getZoneChangelogHandler handle requests for last changes of resource records in the zone.
+func (s Server) getZoneChangelogHandler(w http.ResponseWriter, r *http.Request) {
+	zone := r.PathValue("zone")
+
+	limit := int64(100)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || limit < 1 || limit > 1000 {
+			s.logger.Error("incorrect limit " + limitStr)
+			http.Error(w, "Limit must be a number from 1 to 1000", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !database.InZones(dns.Fqdn(strings.ToLower(zone)), database.ZonesFromContext(r.Context())) {
+		s.logger.Error("zone " + zone + " is not allowed")
+		http.Error(w, "Zone is not allowed", http.StatusForbidden)
+		return
+	}
+
+	changes, err := s.db.GetZoneHistory(r.Context(), zone, int32(limit))
+	if err != nil {
+		s.logger.Error("can't get changelog of zone: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := proto.Marshal(toProtoChanges(changes))
+	if err != nil {
+		s.logger.Error("can't marshal changelog of zone: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("GET changelog of zone %s, %d changes returned", zone, len(changes)))
+}
+
+// revertChangeHandler handle requests for reverting one change of the resource record.
+func (s Server) revertChangeHandler(w http.ResponseWriter, r *http.Request) {
+	pathID := r.PathValue("changeID")
+	id, err := strconv.ParseInt(pathID, 10, 32)
+	if err != nil {
+		s.logger.Error("can't parse id of change(" + pathID + "): " + err.Error())
+		http.Error(w, "Incorrect id of change", http.StatusBadRequest)
+		return
+	}
+
+	change, err := s.db.RevertChange(r.Context(), int32(id))
+	if err != nil {
+		s.logger.Error("can't revert change: " + err.Error())
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "Change not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, database.ErrZoneNotAllowed) {
+			http.Error(w, recordErrorMessage(err), http.StatusForbidden)
+			return
+		}
+		errStr := recordErrorMessage(err)
+		if errStr == "" {
+			errStr = "Internal server error"
+		}
+		http.Error(w, errStr, http.StatusInternalServerError)
+		return
+	}
+	s.restoreIndex(change)
+
+	w.WriteHeader(http.StatusOK)
+	s.logger.Info(fmt.Sprintf("POST revert change %d of resource record", id))
+}
+
+// rollbackZoneHandler handle requests for returning resource records of the zone to the state at provided time.
+func (s Server) rollbackZoneHandler(w http.ResponseWriter, r *http.Request) {
+	zone := r.PathValue("zone")
+
+	if r.Header.Get("Content-Type") != "application/protobuf" {
+		s.logger.Error("Content-Type header is set to " + r.Header.Get("Content-Type"))
+		http.Error(w, "Accept only application/protobuf Content-Type", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Error("can't read request body from " + r.RemoteAddr)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	rollback := &crudpb.ZoneRollback{}
+	err = proto.Unmarshal(body, rollback)
+	if err != nil || rollback.Time == nil {
+		s.logger.Error("can't unmarshal body from " + r.RemoteAddr)
+		http.Error(w, "Incorrect message format", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := s.db.RollbackZone(r.Context(), zone, rollback.Time.AsTime())
+	if err != nil {
+		s.logger.Error("can't rollback zone " + zone + ": " + err.Error())
+		if errors.Is(err, database.ErrZoneNotAllowed) {
+			http.Error(w, recordErrorMessage(err), http.StatusForbidden)
+			return
+		}
+		errStr := recordErrorMessage(err)
+		if errStr == "" {
+			errStr = "Internal server error"
+		}
+		http.Error(w, errStr, http.StatusInternalServerError)
+		return
+	}
+	s.restoreIndex(changes...)
+
+	resp, err := proto.Marshal(&crudpb.ZoneRollbackResult{Restored: int32(len(changes))})
+	if err != nil {
+		s.logger.Error("can't marshal result of rollback: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("POST rollback zone %s to %s, %d resource records restored",
+		zone, rollback.Time.AsTime().Format(time.RFC3339), len(changes)))
+}
+
+// restoreIndex put states of the resource records before the reverted changes to the index.
+// Records that didn't exist before the changes are deleted from it.
+func (s Server) restoreIndex(changes ...database.RecordChange) {
+	for _, change := range changes {
+		if change.Before == nil {
+			s.index.Delete(change.RecordID)
+			continue
+		}
+		rr := *change.Before
+		rr.ID = change.RecordID
+		if err := s.index.Put(rr); err != nil {
+			s.logger.Error("can't put resource record to the index: " + err.Error())
+		}
+	}
+}
+
+// toProtoChanges convert changes of the resource records to the protobuf message.
+func toProtoChanges(changes []database.RecordChange) *crudpb.RecordChangeCollection {
+	collection := &crudpb.RecordChangeCollection{}
+	for _, change := range changes {
+		c := &crudpb.RecordChange{
+			Id:        change.ID,
+			RecordId:  change.RecordID,
+			Operation: change.Operation,
+			Actor:     change.Actor,
+			Time:      timestamppb.New(change.Time),
+		}
+		if change.Before != nil {
+			c.Before = toProtoRecord(*change.Before)
+		}
+		if change.After != nil {
+			c.After = toProtoRecord(*change.After)
+		}
+		collection.Changes = append(collection.Changes, c)
+	}
+	return collection
+}
+
+// getAllViewsHandler handle requests for all views.
+func (s Server) getAllViewsHandler(w http.ResponseWriter, r *http.Request) {
+	views, err := s.db.GetAllViews(r.Context())
+	if err != nil {
+		s.logger.Error("can't get views from database: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	collection := &crudpb.ViewCollection{}
+	for _, view := range views {
+		collection.Views = append(collection.Views, toProtoView(view))
+	}
+
+	resp, err := proto.Marshal(collection)
+	if err != nil {
+		s.logger.Error("can't marshal views: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("GET all views, returned %d views", len(views)))
+}
+
+// getViewHandler handle requests for one view.
+func (s Server) getViewHandler(w http.ResponseWriter, r *http.Request) {
+	pathID := r.PathValue("id")
+	id, err := strconv.ParseInt(pathID, 10, 32)
+	if err != nil {
+		s.logger.Error("can't parse id(" + pathID + "): " + err.Error())
+		http.Error(w, "Incorrect id", http.StatusBadRequest)
+		return
+	}
+
+	view, err := s.db.GetView(r.Context(), int32(id))
+	if err != nil {
+		s.writeViewError(w, "can't get view: ", err)
+		return
+	}
+
+	resp, err := proto.Marshal(toProtoView(view))
+	if err != nil {
+		s.logger.Error("can't marshal view: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info("GET view " + view.Name)
+}
+
+// postViewHandler handle requests for creation of the view.
+func (s Server) postViewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/protobuf" {
+		s.logger.Error("Content-Type header is set to " + r.Header.Get("Content-Type"))
+		http.Error(w, "Accept only application/protobuf Content-Type", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Error("can't read request body from " + r.RemoteAddr)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	msg := &crudpb.View{}
+	if err := proto.Unmarshal(body, msg); err != nil {
+		s.logger.Error("can't unmarshal body from " + r.RemoteAddr)
+		http.Error(w, "Incorrect message format", http.StatusBadRequest)
+		return
+	}
+
+	view, err := viewFromProto(msg)
+	if err != nil {
+		s.writeViewError(w, "can't add view: ", err)
+		return
+	}
+
+	view.ID, err = s.db.AddView(r.Context(), view)
+	if err != nil {
+		s.writeViewError(w, "can't add view: ", err)
+		return
+	}
+	if err := s.loadViews(r.Context()); err != nil {
+		s.logger.Error(err.Error())
+	}
+
+	resp, err := proto.Marshal(toProtoView(view))
+	if err != nil {
+		s.logger.Error("can't marshal view: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("POST view %s with %d networks, %d zones and %d resource records",
+		view.Name, len(view.Networks), len(view.Zones), len(view.Records)))
+}
+
+// patchViewHandler handle requests for replacing of the view.
+func (s Server) patchViewHandler(w http.ResponseWriter, r *http.Request) {
+	pathID := r.PathValue("id")
+	id, err := strconv.ParseInt(pathID, 10, 32)
+	if err != nil {
+		s.logger.Error("can't parse id(" + pathID + "): " + err.Error())
+		http.Error(w, "Incorrect id", http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/protobuf" {
+		s.logger.Error("Content-Type header is set to " + r.Header.Get("Content-Type"))
+		http.Error(w, "Accept only application/protobuf Content-Type", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Error("can't read request body from " + r.RemoteAddr)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	msg := &crudpb.View{}
+	if err := proto.Unmarshal(body, msg); err != nil {
+		s.logger.Error("can't unmarshal body from " + r.RemoteAddr)
+		http.Error(w, "Incorrect message format", http.StatusBadRequest)
+		return
+	}
+	msg.Id = int32(id)
+
+	view, err := viewFromProto(msg)
+	if err != nil {
+		s.writeViewError(w, "can't update view: ", err)
+		return
+	}
+
+	if err := s.db.UpdateView(r.Context(), view); err != nil {
+		s.writeViewError(w, "can't update view: ", err)
+		return
+	}
+	if err := s.loadViews(r.Context()); err != nil {
+		s.logger.Error(err.Error())
+	}
+
+	w.WriteHeader(http.StatusOK)
+	s.logger.Info(fmt.Sprintf("PATCH view %d, now it is %s with %d networks, %d zones and %d resource records",
+		view.ID, view.Name, len(view.Networks), len(view.Zones), len(view.Records)))
+}
+
+// deleteViewHandler handle requests for deletion of the view.
+// Resource records of the view are not deleted and become answered in all views.
+func (s Server) deleteViewHandler(w http.ResponseWriter, r *http.Request) {
+	pathID := r.PathValue("id")
+	id, err := strconv.ParseInt(pathID, 10, 32)
+	if err != nil {
+		s.logger.Error("can't parse id(" + pathID + "): " + err.Error())
+		http.Error(w, "Incorrect id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.DeleteView(r.Context(), int32(id)); err != nil {
+		s.writeViewError(w, "can't delete view: ", err)
+		return
+	}
+	if err := s.loadViews(r.Context()); err != nil {
+		s.logger.Error(err.Error())
+	}
+
+	w.WriteHeader(http.StatusOK)
+	s.logger.Info("DELETE view " + pathID)
+}
+
+// getACLRulesHandler handle requests for all rules of the access control list.
+func (s Server) getACLRulesHandler(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.db.GetACLRules(r.Context())
+	if err != nil {
+		s.logger.Error("can't get rules of the access control list: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	collection := &crudpb.ACLRuleCollection{}
+	for _, rule := range rules {
+		collection.Rules = append(collection.Rules, toProtoACLRule(rule))
+	}
+
+	resp, err := proto.Marshal(collection)
+	if err != nil {
+		s.logger.Error("can't marshal rules of the access control list: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("GET access control list, returned %d rules", len(rules)))
+}
+
+// postACLRuleHandler handle requests for adding rule to the access control list.
+func (s Server) postACLRuleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/protobuf" {
+		s.logger.Error("Content-Type header is set to " + r.Header.Get("Content-Type"))
+		http.Error(w, "Accept only application/protobuf Content-Type", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Error("can't read request body from " + r.RemoteAddr)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	msg := &crudpb.ACLRule{}
+	if err := proto.Unmarshal(body, msg); err != nil {
+		s.logger.Error("can't unmarshal body from " + r.RemoteAddr)
+		http.Error(w, "Incorrect message format", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := aclRuleFromProto(msg)
+	if err != nil {
+		s.writeACLError(w, "can't add rule of the access control list: ", err)
+		return
+	}
+
+	rule.ID, err = s.db.AddACLRule(r.Context(), rule)
+	if err != nil {
+		s.writeACLError(w, "can't add rule of the access control list: ", err)
+		return
+	}
+	if err := s.loadACL(r.Context()); err != nil {
+		s.logger.Error(err.Error())
+	}
+
+	resp, err := proto.Marshal(toProtoACLRule(rule))
+	if err != nil {
+		s.logger.Error("can't marshal rule of the access control list: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("POST rule of the access control list for %s", rule.Network))
+}
+
+// patchACLRuleHandler handle requests for replacing rule of the access control list.
+func (s Server) patchACLRuleHandler(w http.ResponseWriter, r *http.Request) {
+	pathID := r.PathValue("id")
+	id, err := strconv.ParseInt(pathID, 10, 32)
+	if err != nil {
+		s.logger.Error("can't parse id(" + pathID + "): " + err.Error())
+		http.Error(w, "Incorrect id", http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/protobuf" {
+		s.logger.Error("Content-Type header is set to " + r.Header.Get("Content-Type"))
+		http.Error(w, "Accept only application/protobuf Content-Type", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Error("can't read request body from " + r.RemoteAddr)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	msg := &crudpb.ACLRule{}
+	if err := proto.Unmarshal(body, msg); err != nil {
+		s.logger.Error("can't unmarshal body from " + r.RemoteAddr)
+		http.Error(w, "Incorrect message format", http.StatusBadRequest)
+		return
+	}
+	msg.Id = int32(id)
+
+	rule, err := aclRuleFromProto(msg)
+	if err != nil {
+		s.writeACLError(w, "can't update rule of the access control list: ", err)
+		return
+	}
+
+	if err := s.db.UpdateACLRule(r.Context(), rule); err != nil {
+		s.writeACLError(w, "can't update rule of the access control list: ", err)
+		return
+	}
+	if err := s.loadACL(r.Context()); err != nil {
+		s.logger.Error(err.Error())
+	}
+
+	w.WriteHeader(http.StatusOK)
+	s.logger.Info(fmt.Sprintf("PATCH rule %d of the access control list for %s", rule.ID, rule.Network))
+}
+
+// deleteACLRuleHandler handle requests for deleting rule of the access control list.
+func (s Server) deleteACLRuleHandler(w http.ResponseWriter, r *http.Request) {
+	pathID := r.PathValue("id")
+	id, err := strconv.ParseInt(pathID, 10, 32)
+	if err != nil {
+		s.logger.Error("can't parse id(" + pathID + "): " + err.Error())
+		http.Error(w, "Incorrect id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.DeleteACLRule(r.Context(), int32(id)); err != nil {
+		s.writeACLError(w, "can't delete rule of the access control list: ", err)
+		return
+	}
+	if err := s.loadACL(r.Context()); err != nil {
+		s.logger.Error(err.Error())
+	}
+
+	w.WriteHeader(http.StatusOK)
+	s.logger.Info("DELETE rule of the access control list " + pathID)
+}
+
+// statTopHandler return handler of the requests for top of the query statistics.
+// Query parameters: period(24h by default), limit(10 by default), type and rcode.
+func (s Server) statTopHandler(name string,
+	get func(context.Context, database.TopFilter) ([]database.TopItem, error),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		period, err := durationParam(query.Get("period"), 24*time.Hour)
+		if err != nil || period <= 0 {
+			s.logger.Error("incorrect period " + query.Get("period"))
+			http.Error(w, "Period must be a positive duration, e.g. 24h", http.StatusBadRequest)
+			return
+		}
+
+		limit := int64(10)
+		if limitStr := query.Get("limit"); limitStr != "" {
+			limit, err = strconv.ParseInt(limitStr, 10, 32)
+			if err != nil || limit < 1 || limit > 1000 {
+				s.logger.Error("incorrect limit " + limitStr)
+				http.Error(w, "Limit must be a number from 1 to 1000", http.StatusBadRequest)
+				return
+			}
+		}
+
+		items, err := get(r.Context(), database.TopFilter{
+			Since: time.Now().Add(-period),
+			Type:  query.Get("type"),
+			Rcode: query.Get("rcode"),
+			Limit: int32(limit),
+		})
+		if err != nil {
+			s.logger.Error("can't get top " + name + ": " + err.Error())
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		top := &crudpb.StatTop{}
+		for _, item := range items {
+			top.Items = append(top.Items, &crudpb.StatItem{Key: item.Key, Count: item.Count})
+		}
+		resp, err := proto.Marshal(top)
+		if err != nil {
+			s.logger.Error("can't marshal top " + name + ": " + err.Error())
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/protobuf")
+		w.WriteHeader(http.StatusOK)
+		w.Write(resp)
+		s.logger.Info(fmt.Sprintf("GET top %s for %s, returned %d items", name, period, len(items)))
+	}
+}
+
+// queryVolumeHandler handle requests for count of the queries in time intervals.
+// Query parameters: period(24h by default) and step(1h by default, 1m minimum).
+func (s Server) queryVolumeHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	period, err := durationParam(query.Get("period"), 24*time.Hour)
+	if err != nil || period <= 0 {
+		s.logger.Error("incorrect period " + query.Get("period"))
+		http.Error(w, "Period must be a positive duration, e.g. 24h", http.StatusBadRequest)
+		return
+	}
+	step, err := durationParam(query.Get("step"), time.Hour)
+	if err != nil || step < time.Minute || period/step > 10000 {
+		s.logger.Error("incorrect step " + query.Get("step"))
+		http.Error(w, "Step must be at least 1m and divide period in at most 10000 intervals",
+			http.StatusBadRequest)
+		return
+	}
+
+	points, err := s.db.GetQueryVolume(r.Context(), time.Now().Add(-period), step)
+	if err != nil {
+		s.logger.Error("can't get query volume: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	volume := &crudpb.QueryVolume{}
+	for _, point := range points {
+		volume.Points = append(volume.Points, &crudpb.VolumePoint{
+			Time:    timestamppb.New(point.Time),
+			Total:   point.Total,
+			Blocked: point.Blocked,
+		})
+	}
+	resp, err := proto.Marshal(volume)
+	if err != nil {
+		s.logger.Error("can't marshal query volume: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("GET query volume for %s by %s, returned %d points", period, step, len(points)))
+}
+
+// searchQueryLogHandler return one page of the query log entries that match the filter from the query.
+func (s Server) searchQueryLogHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.QueryLogFilter{
+		Domain: query.Get("domain"),
+		Type:   query.Get("type"),
+		Rcode:  query.Get("rcode"),
+		Cursor: query.Get("cursor"),
+		Limit:  100,
+	}
+
+	if client := query.Get("client"); client != "" {
+		network, err := netip.ParsePrefix(client)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(client)
+			if addrErr != nil {
+				s.logger.Error("incorrect client " + client)
+				http.Error(w, "Client must be an IP address or a network", http.StatusBadRequest)
+				return
+			}
+			network = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		filter.Network = network
+	}
+
+	if blockedStr := query.Get("blocked"); blockedStr != "" {
+		blocked, err := strconv.ParseBool(blockedStr)
+		if err != nil {
+			s.logger.Error("incorrect blocked " + blockedStr)
+			http.Error(w, "Blocked must be true or false", http.StatusBadRequest)
+			return
+		}
+		filter.Blocked = &blocked
+	}
+
+	for _, param := range []struct {
+		name string
+		t    *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			s.logger.Error("incorrect " + param.name + " " + value)
+			http.Error(w, "Since and until must be RFC 3339 times", http.StatusBadRequest)
+			return
+		}
+		*param.t = t
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || limit < 1 || limit > 1000 {
+			s.logger.Error("incorrect limit " + limitStr)
+			http.Error(w, "Limit must be a number from 1 to 1000", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = int32(limit)
+	}
+
+	entries, cursor, err := s.db.SearchQueryLog(r.Context(), filter)
+	if err != nil {
+		s.logger.Error("can't search query log in database: " + err.Error())
+		if errors.Is(err, database.ErrInvalidCursor) {
+			http.Error(w, "Incorrect cursor", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	page := &crudpb.QueryLogPage{NextCursor: cursor}
+	for _, entry := range entries {
+		page.Entries = append(page.Entries, toProtoQueryLogEntry(entry))
+	}
+
+	resp, err := proto.Marshal(page)
+	if err != nil {
+		s.logger.Error("can't marshal page of query log: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("GET search query log, returned %d entries", len(entries)))
+}
+
+// durationParam parse duration from the query parameter or return def if it is empty.
+func durationParam(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	return time.ParseDuration(value)
 }
 
 func (s Server) getAllLogsHandler(w http.ResponseWriter, r *http.Request) {
@@ -1,9 +1,31 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/miekg/dns"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/internal/rrl"
+	"github.com/prionis/dns-server/proto/crud/genproto/crudpb"
 )
 
 type MockDB struct{}
@@ -51,3 +73,667 @@ func (db MockDB) DeleteUser(ctx context.Context, id int32) error {
 func (db MockDB) UpdateUser(ctx context.Context, user database.User) error {
 	return nil
 }
+
+// testResponseWriter is dns.ResponseWriter that keep the last written message.
+type testResponseWriter struct {
+	msg *dns.Msg
+}
+
+func (w *testResponseWriter) LocalAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 53}
+}
+
+func (w *testResponseWriter) RemoteAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 40000}
+}
+
+func (w *testResponseWriter) WriteMsg(m *dns.Msg) error {
+	w.msg = m
+	return nil
+}
+
+func (w *testResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *testResponseWriter) Close() error        { return nil }
+func (w *testResponseWriter) TsigStatus() error   { return nil }
+func (w *testResponseWriter) TsigTimersOnly(bool) {}
+func (w *testResponseWriter) Hijack()             {}
+
+func newTestServer(tb testing.TB, rrs []database.ResourceRecord, opts ...Option) Server {
+	tb.Helper()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	s, err := NewServer(append([]Option{WithLogger(slog.Default())}, opts...)...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := s.index.Load(rrs, nil); err != nil {
+		tb.Fatal(err)
+	}
+	return s
+}
+
+func TestDNSHandler(t *testing.T) {
+	s := newTestServer(t, []database.ResourceRecord{
+		{ID: 1, Domain: "intranet.example.com.", Type: "A", Class: "IN", Data: "10.0.0.1", TTL: 60},
+	})
+
+	w := &testResponseWriter{}
+	s.dnsHandler(w, new(dns.Msg).SetQuestion("intranet.example.com.", dns.TypeA))
+	if len(w.msg.Answer) != 1 || w.msg.Answer[0].(*dns.A).A.String() != "10.0.0.1" {
+		t.Fatalf("unexpected answer: %v", w.msg.Answer)
+	}
+
+	// Answer must be a copy of the prepared RR.
+	w.msg.Answer[0].Header().Ttl = 0
+	s.dnsHandler(w, new(dns.Msg).SetQuestion("intranet.example.com.", dns.TypeA))
+	if w.msg.Answer[0].Header().Ttl != 60 {
+		t.Fatal("prepared RR was modified through the answer")
+	}
+
+	s.dnsHandler(w, new(dns.Msg).SetQuestion("intranet.example.com.", dns.TypeAAAA))
+	if w.msg.Rcode != dns.RcodeSuccess || len(w.msg.Answer) != 0 {
+		t.Fatalf("expected empty answer for existing name, got %v", w.msg)
+	}
+	s.dnsHandler(w, new(dns.Msg).SetQuestion("missing.example.com.", dns.TypeA))
+	if w.msg.Rcode != dns.RcodeSuccess || w.msg.Authoritative || len(w.msg.Ns) != 0 {
+		t.Fatalf("expected not authoritative empty answer outside of the zones, got %v", w.msg)
+	}
+}
+
+func TestDNSHandlerNegative(t *testing.T) {
+	s := newTestServer(t, nil)
+	err := s.index.Load([]database.ResourceRecord{
+		{ID: 1, Domain: "example.com.", Type: "SOA", Class: "IN",
+			Data: "ns1.example.com. hostmaster.example.com. 1 7200 3600 1209600 300", TTL: 3600},
+		{ID: 2, Domain: "host.intranet.example.com.", Type: "A", Class: "IN", Data: "10.0.0.1", TTL: 60},
+		{ID: 3, Domain: "office.example.com.", Type: "A", Class: "IN", Data: "10.0.0.2", TTL: 60},
+	}, []database.View{
+		{ID: 1, Name: "office", Networks: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, Records: []int32{3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		qtype uint16
+		rcode int
+	}{
+		{"host.intranet.example.com.", dns.TypeAAAA, dns.RcodeSuccess},
+		// Empty non-terminal name exists.
+		{"intranet.example.com.", dns.TypeA, dns.RcodeSuccess},
+		{"missing.example.com.", dns.TypeA, dns.RcodeNameError},
+		// Name with records only in the other view doesn't exist.
+		{"office.example.com.", dns.TypeA, dns.RcodeNameError},
+	}
+	w := &testResponseWriter{}
+	for _, tt := range tests {
+		s.dnsHandler(w, new(dns.Msg).SetQuestion(tt.name, tt.qtype))
+		if w.msg.Rcode != tt.rcode || len(w.msg.Answer) != 0 || !w.msg.Authoritative {
+			t.Fatalf("%s: expected authoritative answer with %s, got %v", tt.name, dns.RcodeToString[tt.rcode], w.msg)
+		}
+		if len(w.msg.Ns) != 1 || w.msg.Ns[0].Header().Name != "example.com." || w.msg.Ns[0].Header().Ttl != 300 {
+			t.Fatalf("%s: expected SOA of the zone with negative TTL in authority section, got %v", tt.name, w.msg.Ns)
+		}
+	}
+}
+
+func TestDNSHandlerViews(t *testing.T) {
+	s := newTestServer(t, nil, WithClientSubnet(netip.MustParsePrefix("127.0.0.0/8")))
+	err := s.index.Load([]database.ResourceRecord{
+		{ID: 1, Domain: "intranet.example.com.", Type: "A", Class: "IN", Data: "203.0.113.10", TTL: 60},
+		{ID: 2, Domain: "intranet.example.com.", Type: "A", Class: "IN", Data: "10.0.0.10", TTL: 60},
+	}, []database.View{
+		{ID: 1, Name: "office", Networks: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, Records: []int32{2}},
+		{ID: 2, Name: "lab", Networks: []netip.Prefix{netip.MustParsePrefix("10.1.128.0/17")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &testResponseWriter{}
+	s.dnsHandler(w, new(dns.Msg).SetQuestion("intranet.example.com.", dns.TypeA))
+	if len(w.msg.Answer) != 1 || w.msg.Answer[0].(*dns.A).A.String() != "203.0.113.10" {
+		t.Fatalf("expected public answer, got %v", w.msg.Answer)
+	}
+
+	msg := new(dns.Msg).SetQuestion("intranet.example.com.", dns.TypeA)
+	msg.SetEdns0(1232, false)
+	msg.IsEdns0().Option = append(msg.IsEdns0().Option, &dns.EDNS0_SUBNET{
+		Code:          dns.EDNS0SUBNET,
+		Family:        1,
+		SourceNetmask: 24,
+		Address:       net.ParseIP("10.1.2.0").To4(),
+	})
+	s.dnsHandler(w, msg)
+	if len(w.msg.Answer) != 1 || w.msg.Answer[0].(*dns.A).A.String() != "10.0.0.10" {
+		t.Fatalf("expected office answer, got %v", w.msg.Answer)
+	}
+	opt := w.msg.IsEdns0()
+	// Scope exclude the network of the lab view next to the client.
+	if opt == nil || len(opt.Option) != 1 || opt.Option[0].(*dns.EDNS0_SUBNET).SourceScope != 17 {
+		t.Fatalf("expected client subnet with scope of the view in the answer, got %v", opt)
+	}
+
+	// Option of the untrusted client is ignored.
+	s = newTestServer(t, nil, WithClientSubnet(netip.MustParsePrefix("192.0.2.0/24")))
+	if err := s.index.Load(nil, []database.View{
+		{ID: 1, Name: "office", Networks: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if addr, subnet := s.clientAddr(w, msg); addr.String() != "127.0.0.1" || subnet != nil {
+		t.Fatalf("expected client subnet of untrusted client to be ignored, got %s, %v", addr, subnet)
+	}
+}
+
+func BenchmarkDNSHandler(b *testing.B) {
+	const n = 10000
+	rrs := make([]database.ResourceRecord, 0, n)
+	for i := range n {
+		rrs = append(rrs, database.ResourceRecord{
+			ID:     int32(i + 1),
+			Domain: fmt.Sprintf("host%d.bench.example.", i),
+			Type:   "A",
+			Class:  "IN",
+			Data:   fmt.Sprintf("10.%d.%d.%d", i>>16&0xff, i>>8&0xff, i&0xff),
+			TTL:    300,
+		})
+	}
+	s := newTestServer(b, rrs)
+
+	b.ReportAllocs()
+	start := time.Now()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		w := &testResponseWriter{}
+		i := 0
+		for pb.Next() {
+			s.dnsHandler(w, new(dns.Msg).SetQuestion(rrs[i%n].Domain, dns.TypeA))
+			i++
+		}
+	})
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "qps")
+}
+
+// pingRepository is the repository which only Ping is used.
+type pingRepository struct {
+	database.Repository
+	err error
+}
+
+func (r pingRepository) Ping(context.Context) error {
+	return r.err
+}
+
+func TestReadyzHandler(t *testing.T) {
+	limit := rrl.DefaultConfig
+	limit.ResponsesPerSecond, limit.ErrorsPerSecond, limit.Slip = 1, 1, 0
+	s := newTestServer(t, nil, WithDB(pingRepository{}), WithRateLimit(limit))
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &dns.Server{PacketConn: conn, Handler: s.dnsMux()}
+	go server.ActivateAndServe()
+	defer server.Shutdown()
+	s.run.listeners = &listeners{dnsServers: []*dns.Server{server}}
+
+	rec := httptest.NewRecorder()
+	s.readyzHandler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before listeners are started and index is loaded, got %d", rec.Code)
+	}
+
+	s.health.setListener(listenerName(server), nil)
+	s.health.indexLoaded.Store(true)
+	// Self-test queries are not limited by the response rate limiting.
+	for range 3 {
+		rec = httptest.NewRecorder()
+		s.readyzHandler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	if len(s.queryLog) != 0 || len(s.stats.Take()) != 0 {
+		t.Fatal("expected self-test queries not to be recorded")
+	}
+
+	s.db = pingRepository{err: errors.New("connection refused")}
+	rec = httptest.NewRecorder()
+	s.readyzHandler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `{"name":"database","ok":false}`) {
+		t.Fatalf("expected 503 with failed database check, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "connection refused") {
+		t.Fatalf("expected error details not to be reported, got %s", rec.Body.String())
+	}
+}
+
+// userRepository is the session repository which also store passwords and invitations.
+type userRepository struct {
+	*sessionRepository
+	passwords   map[string]string
+	invitations map[string]string
+}
+
+func newUserRepository(users ...database.User) *userRepository {
+	return &userRepository{
+		sessionRepository: newSessionRepository(users...),
+		passwords:         make(map[string]string),
+		invitations:       make(map[string]string),
+	}
+}
+
+func (r *userRepository) CountUsers(context.Context) (int64, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return int64(len(r.users)), nil
+}
+
+func (r *userRepository) AddUser(_ context.Context, user database.User, password string) (int32, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	user.ID = int32(len(r.users) + 1)
+	r.users[user.Login] = user
+	r.passwords[user.Login] = password
+	return user.ID, nil
+}
+
+func (r *userRepository) RegisterUser(ctx context.Context, user database.User, password string, invitation []byte) (int32, error) {
+	r.mx.Lock()
+	role, ok := r.invitations[string(invitation)]
+	delete(r.invitations, string(invitation))
+	r.mx.Unlock()
+	if !ok {
+		return 0, database.ErrInvalidInvitation
+	}
+	user.Role = role
+	return r.AddUser(ctx, user, password)
+}
+
+func (r *userRepository) CheckUserPassword(ctx context.Context, login, password string) (database.User, error) {
+	user, err := r.GetUser(ctx, login)
+	if err == nil && r.passwords[login] != password {
+		err = bcrypt.ErrMismatchedHashAndPassword
+	}
+	return user, err
+}
+
+func (r *userRepository) SetUserPassword(_ context.Context, id int32, password string, mustChange bool) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	for login, user := range r.users {
+		if user.ID == id {
+			user.MustChangePassword = mustChange
+			r.users[login] = user
+			r.passwords[login] = password
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func TestBootstrap(t *testing.T) {
+	repo := newUserRepository()
+	var log, stderr bytes.Buffer
+	s := newTestServer(t, nil, WithDB(repo), WithLogger(slog.New(slog.NewTextHandler(&log, nil))))
+	s.stderr = &stderr
+	for range 2 {
+		if err := s.bootstrap(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	admin, err := repo.GetUser(context.Background(), "admin")
+	if err != nil || len(repo.users) != 1 {
+		t.Fatalf("expected only admin to be created, got %v, %v", repo.users, err)
+	}
+	password := repo.passwords["admin"]
+	if !admin.MustChangePassword || len(password) < 32 {
+		t.Fatalf("expected random temporary password, got %+v with %q", admin, password)
+	}
+	if !strings.Contains(stderr.String(), password) {
+		t.Fatalf("expected generated password to be written to stderr, got %q", stderr.String())
+	}
+	if strings.Contains(log.String(), password) {
+		t.Fatalf("expected generated password to be kept out of the log, got %q", log.String())
+	}
+
+	repo = newUserRepository()
+	s = newTestServer(t, nil, WithDB(repo), WithAdminPassword("configured"))
+	if err := s.bootstrap(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if admin, _ := repo.GetUser(context.Background(), "admin"); admin.MustChangePassword || repo.passwords["admin"] != "configured" {
+		t.Fatalf("expected configured password, got %+v with %q", admin, repo.passwords["admin"])
+	}
+}
+
+func TestRegisterHandler(t *testing.T) {
+	repo := newUserRepository()
+	repo.invitations[string(hashToken("invitation"))] = "user"
+	s := newTestServer(t, nil, WithDB(repo), WithJWTSecret("secret"))
+
+	register := func(invitation string) int {
+		b, err := proto.Marshal(&crudpb.Register{
+			Login: "bob", FirstName: "Bob", LastName: "Smith", Password: "password",
+			Role: "admin", Invitation: invitation,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/protobuf")
+		rec := httptest.NewRecorder()
+		s.router().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := register(""); code != http.StatusForbidden {
+		t.Fatalf("expected registration without invitation to be rejected, got %d", code)
+	}
+	if code := register("invitation"); code != http.StatusOK {
+		t.Fatalf("expected registration with invitation to succeed, got %d", code)
+	}
+	if user, _ := repo.GetUser(context.Background(), "bob"); user.Role != "user" {
+		t.Fatalf("expected role of the invitation, got %q", user.Role)
+	}
+	delete(repo.users, "bob")
+	if code := register("invitation"); code != http.StatusForbidden {
+		t.Fatalf("expected used invitation to be rejected, got %d", code)
+	}
+}
+
+func TestChangePasswordHandler(t *testing.T) {
+	user := database.User{
+		ID:                 1,
+		Login:              "alice",
+		Role:               "user",
+		Permissions:        []string{database.PermRecordsRead},
+		MustChangePassword: true,
+	}
+	repo := newUserRepository(user)
+	repo.passwords["alice"] = "temporary"
+	s := newTestServer(t, nil, WithDB(passwordRepository{repo}), WithJWTSecret("secret"))
+	token, err := s.signAccessToken(user, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(method, path string, msg proto.Message) *httptest.ResponseRecorder {
+		var body io.Reader
+		if msg != nil {
+			b, err := proto.Marshal(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = bytes.NewReader(b)
+		}
+		req := httptest.NewRequest(method, path, body)
+		req.Header.Set("Content-Type", "application/protobuf")
+		req.AddCookie(&http.Cookie{Name: accessCookie, Value: token})
+		rec := httptest.NewRecorder()
+		s.router().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request(http.MethodGet, "/api/rrs/1/history", nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected requests to be forbidden until password is changed, got %d", rec.Code)
+	}
+	rec := request(http.MethodPost, "/api/password", &crudpb.ChangePassword{OldPassword: "wrong", NewPassword: "changed"})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected wrong old password to be rejected, got %d", rec.Code)
+	}
+	rec = request(http.MethodPost, "/api/password", &crudpb.ChangePassword{OldPassword: "temporary", NewPassword: "changed"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected password change to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodGet, "/api/rrs/1/history", nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected requests to be allowed after password change, got %d", rec.Code)
+	}
+}
+
+// passwordRepository is the user repository with empty history of the resource records.
+type passwordRepository struct {
+	*userRepository
+}
+
+func (passwordRepository) GetRecordHistory(context.Context, int32) ([]database.RecordChange, error) {
+	return nil, nil
+}
+
+// changeLogRepository keep changes of the resource records in memory, oldest first.
+type changeLogRepository struct {
+	database.Repository
+	changes []database.RecordChange
+}
+
+func (r changeLogRepository) GetRecordHistory(_ context.Context, id int32) ([]database.RecordChange, error) {
+	var changes []database.RecordChange
+	for _, change := range slices.Backward(r.changes) {
+		if change.RecordID == id {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (r changeLogRepository) RevertChange(_ context.Context, id int32) (database.RecordChange, error) {
+	for _, change := range r.changes {
+		if change.ID == id {
+			return change, nil
+		}
+	}
+	return database.RecordChange{}, pgx.ErrNoRows
+}
+
+func (r changeLogRepository) RollbackZone(_ context.Context, zone string, t time.Time) ([]database.RecordChange, error) {
+	var changes []database.RecordChange
+	for _, change := range r.changes {
+		if change.Time.After(t) && !slices.ContainsFunc(changes, func(c database.RecordChange) bool {
+			return c.RecordID == change.RecordID
+		}) {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func TestHistoryHandlers(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := database.ResourceRecord{ID: 1, Domain: "www.example.com.", Type: "A", Class: "IN", Data: "10.0.0.1", TTL: 60}
+	updated := first
+	updated.Data = "10.0.0.2"
+	added := database.ResourceRecord{ID: 2, Domain: "mail.example.com.", Type: "A", Class: "IN", Data: "10.0.0.3", TTL: 60}
+	repo := changeLogRepository{changes: []database.RecordChange{
+		{ID: 1, RecordID: 1, Operation: database.OperationInsert, Time: start, After: &first},
+		{ID: 2, RecordID: 1, Operation: database.OperationUpdate, Time: start.Add(2 * time.Hour), Before: &first, After: &updated},
+		{ID: 3, RecordID: 2, Operation: database.OperationInsert, Time: start.Add(3 * time.Hour), After: &added},
+	}}
+	current := []database.ResourceRecord{updated, added}
+	s := newTestServer(t, current, WithDB(repo))
+
+	find := func(name string) []string {
+		var data []string
+		for _, entry := range s.index.Find(name, "A", 0) {
+			data = append(data, entry.Record.Data)
+		}
+		return data
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/rrs/1/history", nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	s.getRecordHistoryHandler(rec, req)
+	history := &crudpb.RecordChangeCollection{}
+	if err := proto.Unmarshal(rec.Body.Bytes(), history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Changes) != 2 || history.Changes[0].Id != 2 || history.Changes[0].Before.GetData() != "10.0.0.1" ||
+		history.Changes[1].Before != nil {
+		t.Fatalf("unexpected history %v", history.Changes)
+	}
+
+	// Reverted state is answered at once, without waiting for the change notification.
+	req = httptest.NewRequest(http.MethodPost, "/api/rrs/history/2/revert", nil)
+	req.SetPathValue("changeID", "2")
+	rec = httptest.NewRecorder()
+	s.revertChangeHandler(rec, req)
+	if rec.Code != http.StatusOK || !slices.Equal(find("www.example.com."), []string{"10.0.0.1"}) {
+		t.Fatalf("expected revert to restore the record in the index, got %d and %v", rec.Code, find("www.example.com."))
+	}
+	req.SetPathValue("changeID", "99")
+	rec = httptest.NewRecorder()
+	s.revertChangeHandler(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown change to be not found, got %d", rec.Code)
+	}
+
+	if err := s.index.Load(current, nil); err != nil {
+		t.Fatal(err)
+	}
+	body, err := proto.Marshal(&crudpb.ZoneRollback{Time: timestamppb.New(start.Add(time.Hour))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/api/zones/example.com/rollback", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/protobuf")
+	req.SetPathValue("zone", "example.com")
+	rec = httptest.NewRecorder()
+	s.rollbackZoneHandler(rec, req)
+	result := &crudpb.ZoneRollbackResult{}
+	if err := proto.Unmarshal(rec.Body.Bytes(), result); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || result.Restored != 2 {
+		t.Fatalf("expected 2 restored records, got %d and %v", rec.Code, result)
+	}
+	// Record added after the time of the rollback is deleted.
+	if !slices.Equal(find("www.example.com."), []string{"10.0.0.1"}) || find("mail.example.com.") != nil {
+		t.Fatalf("expected index to contain state of the zone at the time of the rollback, got %v and %v",
+			find("www.example.com."), find("mail.example.com."))
+	}
+}
+
+// batchRepository apply batches to the records in memory with semantics of the database:
+// nothing is changed if any operation failed or the batch is dry run.
+type batchRepository struct {
+	database.Repository
+	records map[int32]database.ResourceRecord
+	nextID  int32
+	calls   int
+}
+
+func (r *batchRepository) ApplyRecordBatch(_ context.Context, ops []database.RecordOperation, dryRun bool) ([]database.RecordOperationResult, error) {
+	r.calls++
+	records := maps.Clone(r.records)
+	nextID := r.nextID
+	failed := false
+	var results []database.RecordOperationResult
+	for _, op := range ops {
+		result := database.RecordOperationResult{ID: op.Record.ID}
+		_, exists := records[op.Record.ID]
+		switch {
+		case op.Action == database.ActionCreate:
+			nextID++
+			result.ID = nextID
+			op.Record.ID = nextID
+			records[nextID] = op.Record
+		case !exists:
+			result.Err = pgx.ErrNoRows
+		case op.Action == database.ActionUpdate:
+			records[op.Record.ID] = op.Record
+		case op.Action == database.ActionDelete:
+			delete(records, op.Record.ID)
+		}
+		failed = failed || result.Err != nil
+		results = append(results, result)
+	}
+	if failed {
+		return results, database.ErrBatchFailed
+	}
+	if !dryRun {
+		r.records, r.nextID = records, nextID
+	}
+	return results, nil
+}
+
+func TestBatchRRHandler(t *testing.T) {
+	existing := database.ResourceRecord{ID: 1, Domain: "www.example.com.", Type: "A", Class: "IN", Data: "10.0.0.1", TTL: 60}
+	repo := &batchRepository{records: map[int32]database.ResourceRecord{1: existing}, nextID: 1}
+	s := newTestServer(t, []database.ResourceRecord{existing}, WithDB(repo))
+
+	batch := func(dryRun bool, ops ...*crudpb.RecordOperation) (*httptest.ResponseRecorder, *crudpb.RecordBatchResult) {
+		body, err := proto.Marshal(&crudpb.RecordBatch{Operations: ops, DryRun: dryRun})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/rrs/batch", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/protobuf")
+		rec := httptest.NewRecorder()
+		s.batchRRHandler(rec, req)
+		result := &crudpb.RecordBatchResult{}
+		if rec.Code == http.StatusOK || rec.Code == http.StatusUnprocessableEntity {
+			if err := proto.Unmarshal(rec.Body.Bytes(), result); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec, result
+	}
+	create := &crudpb.RecordOperation{
+		Action: crudpb.RecordOperation_ACTION_CREATE,
+		Record: &crudpb.ResourceRecord{Domain: "mail.example.com.", Type: "A", Data: "10.0.0.2", TimeToLive: 60},
+	}
+	update := &crudpb.RecordOperation{
+		Action: crudpb.RecordOperation_ACTION_UPDATE,
+		Record: &crudpb.ResourceRecord{Id: 1, Domain: "www.example.com.", Type: "A", Data: "10.0.0.3", TimeToLive: 60},
+	}
+	deleteMissing := &crudpb.RecordOperation{
+		Action: crudpb.RecordOperation_ACTION_DELETE,
+		Record: &crudpb.ResourceRecord{Id: 42},
+	}
+	answers := func(name string) int { return len(s.index.Find(name, "A", 0)) }
+
+	// Deleting record that doesn't exist fails the operation, so the whole batch is rolled back.
+	rec, result := batch(false, create, update, deleteMissing)
+	if rec.Code != http.StatusUnprocessableEntity || result.Applied || len(result.Results) != 3 ||
+		result.Results[0].Error != "" || result.Results[2].Error != "Not found" {
+		t.Fatalf("expected failed batch with error of the last operation, got %d and %v", rec.Code, result)
+	}
+	if len(repo.records) != 1 || repo.records[1].Data != "10.0.0.1" || answers("mail.example.com.") != 0 {
+		t.Fatalf("expected earlier operations to be rolled back, got %v", repo.records)
+	}
+
+	rec, result = batch(true, create, update)
+	if rec.Code != http.StatusOK || result.Applied || len(result.Results) != 2 || result.Results[0].Error != "" {
+		t.Fatalf("expected successful dry run, got %d and %v", rec.Code, result)
+	}
+	if len(repo.records) != 1 || answers("mail.example.com.") != 0 {
+		t.Fatalf("expected dry run to change nothing, got %v", repo.records)
+	}
+
+	rec, result = batch(false, create, update)
+	if rec.Code != http.StatusOK || !result.Applied || result.Results[0].Id != 2 {
+		t.Fatalf("expected applied batch, got %d and %v", rec.Code, result)
+	}
+	if answers("mail.example.com.") != 1 || s.index.Find("www.example.com.", "A", 0)[0].Record.Data != "10.0.0.3" {
+		t.Fatal("expected applied operations to be put to the index")
+	}
+
+	calls := repo.calls
+	if rec, _ := batch(false, slices.Repeat([]*crudpb.RecordOperation{update}, 1001)...); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected batch over 1000 operations to be rejected, got %d", rec.Code)
+	}
+	if rec, _ := batch(false); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected empty batch to be rejected, got %d", rec.Code)
+	}
+	if rec, _ := batch(true, slices.Repeat([]*crudpb.RecordOperation{update}, 1000)...); rec.Code != http.StatusOK {
+		t.Fatalf("expected batch of 1000 operations to be accepted, got %d", rec.Code)
+	}
+	if repo.calls != calls+1 {
+		t.Fatalf("expected only batch within the limit to reach the database, got %d calls", repo.calls-calls)
+	}
+}
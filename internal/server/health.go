@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// healthCheckTimeout limit duration of every readiness check.
+	healthCheckTimeout = 2 * time.Second
+	// selfTestName is the name asked by the self-test, answer for it is not needed.
+	selfTestName = "selftest.dns-server.invalid."
+)
+
+// health keep state of the server parts that is reported by health endpoints.
+type health struct {
+	mx sync.RWMutex
+	// listeners contain error of every DNS listener by its name, nil while it is serving.
+	listeners map[string]error
+	// indexLoaded is set after the index is loaded from the database for the first time.
+	indexLoaded atomic.Bool
+}
+
+func newHealth() *health {
+	return &health{listeners: make(map[string]error)}
+}
+
+func (h *health) setListener(name string, err error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.listeners[name] = err
+}
+
+// removeListener remove stopped listener from the checks.
+func (h *health) removeListener(name string) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	delete(h.listeners, name)
+}
+
+// healthCheck is the result of one check in the health report.
+// Health endpoints are public, so the error is only logged and not reported.
+type healthCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"-"`
+}
+
+// healthReport is the body of the health endpoints.
+type healthReport struct {
+	Status string        `json:"status"`
+	Checks []healthCheck `json:"checks"`
+}
+
+// healthzHandler report whether the server is alive, it is when all DNS listeners are serving.
+func (s Server) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeHealthReport(w, s.listenerChecks())
+}
+
+// readyzHandler report whether the server is ready to answer queries: DNS listeners are serving,
+// database is reachable, index is loaded and the server answer the query sent to its own listener.
+func (s Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	checks := s.listenerChecks()
+	checks = append(checks, newHealthCheck("database", s.db.Ping(ctx)))
+
+	var indexErr error
+	if !s.health.indexLoaded.Load() {
+		indexErr = fmt.Errorf("index is not loaded")
+	}
+	checks = append(checks, newHealthCheck("index", indexErr))
+	checks = append(checks, newHealthCheck("self-test", s.selfTest(ctx)))
+
+	s.writeHealthReport(w, checks)
+}
+
+// listenerChecks return checks of the DNS listeners.
+func (s Server) listenerChecks() []healthCheck {
+	s.health.mx.RLock()
+	defer s.health.mx.RUnlock()
+
+	if len(s.health.listeners) == 0 {
+		return []healthCheck{newHealthCheck("dns", fmt.Errorf("no listeners are started"))}
+	}
+	checks := make([]healthCheck, 0, len(s.health.listeners))
+	for _, name := range slices.Sorted(maps.Keys(s.health.listeners)) {
+		checks = append(checks, newHealthCheck("dns "+name, s.health.listeners[name]))
+	}
+	return checks
+}
+
+// selfTest send query to the own UDP listener and check that it is answered.
+// Any answer is accepted, because the loopback may be refused by the access control list.
+func (s Server) selfTest(ctx context.Context) error {
+	l := s.run.current()
+	if l == nil {
+		return fmt.Errorf("listener is not started")
+	}
+	i := slices.IndexFunc(l.dnsServers, func(d *dns.Server) bool { return d.PacketConn != nil })
+	if i < 0 {
+		return fmt.Errorf("UDP listener is not started")
+	}
+	addr, err := selfAddr(l.dnsServers[i].PacketConn.LocalAddr().String())
+	if err != nil {
+		return err
+	}
+	client := &dns.Client{Net: "udp", Timeout: healthCheckTimeout}
+	_, _, err = client.ExchangeContext(ctx, new(dns.Msg).SetQuestion(selfTestName, dns.TypeA), addr)
+	return err
+}
+
+// isSelfTest report whether the query is sent by the self-test, such queries are not
+// recorded in the query log, statistics and metrics.
+func isSelfTest(w dns.ResponseWriter, msg *dns.Msg) bool {
+	return len(msg.Question) == 1 && strings.EqualFold(msg.Question[0].Name, selfTestName) &&
+		remoteAddr(w).IsLoopback()
+}
+
+// selfAddr return address for queries to the listener with provided address.
+// Listeners on all interfaces are queried through the loopback.
+func selfAddr(listen string) (string, error) {
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", fmt.Errorf("can't parse address of the listener: %w", err)
+	}
+	switch host {
+	case "", "0.0.0.0":
+		host = "127.0.0.1"
+	case "::":
+		host = "::1"
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+func newHealthCheck(name string, err error) healthCheck {
+	check := healthCheck{Name: name, OK: err == nil}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// writeHealthReport write report of the checks, status is 503 if any check failed.
+func (s Server) writeHealthReport(w http.ResponseWriter, checks []healthCheck) {
+	report := healthReport{Status: "ok", Checks: checks}
+	status := http.StatusOK
+	for _, check := range checks {
+		if !check.OK {
+			report.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+			s.logger.Error(fmt.Sprintf("health check %s failed: %s", check.Name, check.Error))
+		}
+	}
+
+	resp, err := json.Marshal(report)
+	if err != nil {
+		s.logger.Error("can't marshal health report: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(resp)
+}
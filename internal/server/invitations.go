@@ -0,0 +1,155 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/proto/crud/genproto/crudpb"
+)
+
+// invitationTTL is the lifetime of the invitations created without expiry time.
+const invitationTTL = 7 * 24 * time.Hour
+
+// toProtoInvitation convert invitation to the Invitation message.
+func toProtoInvitation(invitation database.Invitation) *crudpb.Invitation {
+	msg := &crudpb.Invitation{
+		Id:        invitation.ID,
+		Role:      invitation.Role,
+		Zones:     invitation.Zones,
+		CreatedBy: invitation.CreatedBy,
+		CreatedAt: timestamppb.New(invitation.CreatedAt),
+		ExpiresAt: timestamppb.New(invitation.ExpiresAt),
+		UsedBy:    invitation.UsedBy,
+	}
+	if !invitation.UsedAt.IsZero() {
+		msg.UsedAt = timestamppb.New(invitation.UsedAt)
+	}
+	return msg
+}
+
+// getInvitationsHandler handle requests for all invitations.
+func (s Server) getInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	invitations, err := s.db.GetInvitations(r.Context())
+	if err != nil {
+		s.logger.Error("can't get invitations: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	collection := &crudpb.InvitationCollection{}
+	for _, invitation := range invitations {
+		collection.Invitations = append(collection.Invitations, toProtoInvitation(invitation))
+	}
+
+	resp, err := proto.Marshal(collection)
+	if err != nil {
+		s.logger.Error("can't marshal invitations: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("GET invitations, returned %d invitations", len(invitations)))
+}
+
+// postInvitationHandler handle requests for creating invitation. Role, zones and expiry
+// are taken from the request, response contain the token, it can't be retrieved later.
+func (s Server) postInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/protobuf" {
+		s.logger.Error("Content-Type header is set to " + r.Header.Get("Content-Type"))
+		http.Error(w, "Accept only application/protobuf Content-Type", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Error("can't read request body from " + r.RemoteAddr)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	msg := &crudpb.Invitation{}
+	if err := proto.Unmarshal(body, msg); err != nil {
+		s.logger.Error("can't unmarshal body from " + r.RemoteAddr)
+		http.Error(w, "Incorrect message format", http.StatusBadRequest)
+		return
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		s.logger.Error(err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	invitation := database.Invitation{
+		Role:      msg.GetRole(),
+		Zones:     msg.GetZones(),
+		CreatedBy: database.ActorFromContext(r.Context()),
+		ExpiresAt: time.Now().Add(invitationTTL),
+	}
+	if msg.ExpiresAt != nil {
+		invitation.ExpiresAt = msg.ExpiresAt.AsTime()
+	}
+
+	invitation, err = s.db.AddInvitation(r.Context(), invitation, hashToken(token))
+	if err != nil {
+		s.logger.Error("can't add invitation: " + err.Error())
+		var verr *database.ValidationError
+		if errors.As(err, &verr) {
+			writeValidationErrors(w, verr)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := toProtoInvitation(invitation)
+	resp.Token = token
+	b, err := proto.Marshal(resp)
+	if err != nil {
+		s.logger.Error("can't marshal invitation: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+	s.logger.Info(fmt.Sprintf("POST invitation %d with role %s", invitation.ID, invitation.Role))
+}
+
+// deleteInvitationHandler handle requests for deleting invitation.
+func (s Server) deleteInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	pathID := r.PathValue("id")
+	id, err := strconv.ParseInt(pathID, 10, 32)
+	if err != nil {
+		s.logger.Error("can't parse id(" + pathID + "): " + err.Error())
+		http.Error(w, "Incorrect id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.DeleteInvitation(r.Context(), int32(id)); err != nil {
+		s.logger.Error("can't delete invitation: " + err.Error())
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "Invitation not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	s.logger.Info("DELETE invitation " + pathID)
+}
@@ -0,0 +1,377 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// unixPrefix mark address of the Unix socket.
+const unixPrefix = "unix:"
+
+// validateAddrs check addresses of the listeners. Empty list is allowed only with socket activation.
+func validateAddrs(name string, addrs []string, unixAllowed, activation bool) error {
+	if len(addrs) == 0 && !activation {
+		return fmt.Errorf("no %s addresses", name)
+	}
+	var errs []error
+	for _, addr := range addrs {
+		if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+			switch {
+			case !unixAllowed:
+				errs = append(errs, fmt.Errorf("incorrect %s address %q: Unix sockets are not supported", name, addr))
+			case path == "":
+				errs = append(errs, fmt.Errorf("incorrect %s address %q: empty path", name, addr))
+			}
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			errs = append(errs, fmt.Errorf("incorrect %s address %q: %w", name, addr, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// expandAddr return addresses of the network interface if host of the address is its name,
+// otherwise the address is returned as is.
+func expandAddr(addr string) ([]string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("incorrect address %q: %w", addr, err)
+	}
+	if _, err := netip.ParseAddr(host); host == "" || err == nil {
+		return []string{addr}, nil
+	}
+	iface, err := net.InterfaceByName(host)
+	if err != nil {
+		// It is the host name.
+		return []string{addr}, nil
+	}
+	ifaceAddrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("can't get addresses of the interface %s: %w", iface.Name, err)
+	}
+
+	var addrs []string
+	for _, ifaceAddr := range ifaceAddrs {
+		prefix, err := netip.ParsePrefix(ifaceAddr.String())
+		if err != nil {
+			continue
+		}
+		ip := prefix.Addr()
+		if ip.Is6() && ip.IsLinkLocalUnicast() {
+			ip = ip.WithZone(iface.Name)
+		}
+		addrs = append(addrs, net.JoinHostPort(ip.String(), port))
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("interface %s has no addresses", iface.Name)
+	}
+	return addrs, nil
+}
+
+// listenDNS bind UDP and TCP listeners of the DNS server on every address.
+// Every address get the provided number of UDP sockets, see WithReusePort.
+func (s Server) listenDNS(l *listeners, addrs []string, workers int) error {
+	if len(addrs) == 0 {
+		return errors.New("no DNS addresses and no DNS sockets are passed by systemd")
+	}
+	handler := s.dnsMux()
+	var bound []dnsBinding
+	for _, addr := range addrs {
+		b, err := bindDNS(addr, workers, handler)
+		if err != nil {
+			for _, b := range bound {
+				closeDNSServers(b.servers)
+			}
+			return err
+		}
+		bound = append(bound, b)
+	}
+	l.dnsWorkers = workers
+	l.dnsBound = bound
+	l.collect()
+	return nil
+}
+
+// bindDNS bind UDP and TCP listeners of the DNS server on the configured address.
+func bindDNS(configured string, workers int, handler dns.Handler) (dnsBinding, error) {
+	expanded, err := expandAddr(configured)
+	if err != nil {
+		return dnsBinding{}, fmt.Errorf("can't listen DNS on %s: %w", configured, err)
+	}
+	b := dnsBinding{addr: configured}
+	for _, addr := range expanded {
+		udpServers, err := bindUDP(addr, addr, workers, handler)
+		if err != nil {
+			closeDNSServers(b.servers)
+			return dnsBinding{}, err
+		}
+		b.servers = append(b.servers, udpServers...)
+		tcpListener, err := net.Listen("tcp", addr)
+		if err != nil {
+			closeDNSServers(b.servers)
+			return dnsBinding{}, fmt.Errorf("can't listen DNS on %s/tcp: %w", addr, err)
+		}
+		b.servers = append(b.servers, &dns.Server{Net: "tcp", Addr: addr, Listener: tcpListener, Handler: handler})
+	}
+	return b, nil
+}
+
+// rebindUDP bind the provided number of UDP sockets in place of the UDP listeners of the address,
+// TCP listeners are kept. Sockets are bound on the same ports, so the old ones must be
+// stopped after the new ones are started. Only new UDP servers are returned with the binding.
+func rebindUDP(b dnsBinding, workers int, handler dns.Handler) (dnsBinding, []*dns.Server, error) {
+	rebound := dnsBinding{addr: b.addr}
+	var started []*dns.Server
+	bound := make(map[string]bool)
+	for _, dnsServer := range b.servers {
+		if dnsServer.Net != "udp" {
+			rebound.servers = append(rebound.servers, dnsServer)
+			continue
+		}
+		if bound[dnsServer.Addr] {
+			continue
+		}
+		bound[dnsServer.Addr] = true
+		udpServers, err := bindUDP(dnsServer.Addr, dnsServer.PacketConn.LocalAddr().String(), workers, handler)
+		if err != nil {
+			closeDNSServers(started)
+			return dnsBinding{}, nil, err
+		}
+		started = append(started, udpServers...)
+		rebound.servers = append(rebound.servers, udpServers...)
+	}
+	return rebound, started, nil
+}
+
+// bindUDP bind UDP listeners of the DNS server on the address, addr is the address
+// the servers are configured with, it may have port chosen by the system.
+func bindUDP(addr, bindAddr string, workers int, handler dns.Handler) ([]*dns.Server, error) {
+	conns, err := listenUDP(bindAddr, workers)
+	if err != nil {
+		return nil, fmt.Errorf("can't listen DNS on %s/udp: %w", bindAddr, err)
+	}
+	servers := make([]*dns.Server, 0, len(conns))
+	for _, conn := range conns {
+		servers = append(servers, &dns.Server{Net: "udp", Addr: addr, PacketConn: conn, Handler: handler})
+	}
+	return servers, nil
+}
+
+// listenUDP bind UDP sockets on the address. Sockets are bound with SO_REUSEPORT where it is
+// supported, so more than one socket share the address and sockets can be rebound on reload
+// while the old ones still serve queries.
+func listenUDP(addr string, workers int) ([]net.PacketConn, error) {
+	var lc net.ListenConfig
+	if reusePortSupported {
+		lc.Control = reusePort
+	}
+	conns := make([]net.PacketConn, 0, max(workers, 1))
+	for range max(workers, 1) {
+		conn, err := lc.ListenPacket(context.Background(), "udp", addr)
+		if err != nil {
+			for _, conn := range conns {
+				conn.Close()
+			}
+			return nil, err
+		}
+		// Next sockets must be bound to the same port if it is chosen by the system.
+		addr = conn.LocalAddr().String()
+		conns = append(conns, conn)
+	}
+	return conns, nil
+}
+
+// listenHTTP bind listeners of the HTTP API on every address.
+func (s Server) listenHTTP(l *listeners, addrs []string) error {
+	if len(addrs) == 0 {
+		return errors.New("no HTTP addresses and no HTTP sockets are passed by systemd")
+	}
+	var bound []httpBinding
+	for _, addr := range addrs {
+		b, err := bindHTTP(addr)
+		if err != nil {
+			for _, b := range bound {
+				closeHTTPListeners(b.listeners)
+			}
+			return err
+		}
+		bound = append(bound, b)
+	}
+	l.httpBound = bound
+	l.httpServer = s.httpServer()
+	l.collect()
+	return nil
+}
+
+// bindHTTP bind listeners of the HTTP API on the configured address.
+func bindHTTP(configured string) (httpBinding, error) {
+	b := httpBinding{addr: configured}
+	if path, ok := strings.CutPrefix(configured, unixPrefix); ok {
+		ln, err := listenUnix(path)
+		if err != nil {
+			return httpBinding{}, fmt.Errorf("can't listen HTTP on %s: %w", configured, err)
+		}
+		b.listeners = append(b.listeners, ln)
+		return b, nil
+	}
+
+	expanded, err := expandAddr(configured)
+	if err != nil {
+		return httpBinding{}, fmt.Errorf("can't listen HTTP on %s: %w", configured, err)
+	}
+	for _, addr := range expanded {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			closeHTTPListeners(b.listeners)
+			return httpBinding{}, fmt.Errorf("can't listen HTTP on %s: %w", addr, err)
+		}
+		b.listeners = append(b.listeners, ln)
+	}
+	return b, nil
+}
+
+// listenUnix bind Unix socket on the path. Socket left by the previous run is removed,
+// other files are not replaced.
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode().Type() == fs.ModeSocket {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("can't remove old socket: %w", err)
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+func (s Server) dnsMux() dns.Handler {
+	handler := dns.NewServeMux()
+	handler.HandleFunc(".", s.queryMiddleware(s.rrlMiddleware(s.aclMiddleware(s.dnsHandler))))
+	return handler
+}
+
+func (s Server) httpServer() *http.Server {
+	return &http.Server{
+		Handler:      s.router(),
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+}
+
+// closeDNSServers close sockets of the DNS servers that are not started.
+func closeDNSServers(servers []*dns.Server) {
+	for _, dnsServer := range servers {
+		if dnsServer.PacketConn != nil {
+			dnsServer.PacketConn.Close()
+		}
+		if dnsServer.Listener != nil {
+			dnsServer.Listener.Close()
+		}
+	}
+}
+
+// closeHTTPListeners close HTTP listeners, connections accepted by them are served
+// until they are closed by the HTTP server.
+func closeHTTPListeners(httpListeners []net.Listener) {
+	for _, ln := range httpListeners {
+		ln.Close()
+	}
+}
+
+// listenerName return name of the DNS listener used in logs and health checks.
+func listenerName(dnsServer *dns.Server) string {
+	addr := dnsServer.Addr
+	switch {
+	case dnsServer.PacketConn != nil:
+		addr = dnsServer.PacketConn.LocalAddr().String()
+	case dnsServer.Listener != nil:
+		addr = dnsServer.Listener.Addr().String()
+	}
+	return addr + "/" + dnsServer.Net
+}
+
+// listenFdsStart is the first file descriptor passed by systemd, see sd_listen_fds(3).
+const listenFdsStart = 3
+
+// activatedFiles return sockets passed by systemd socket activation with names set by
+// FileDescriptorName=. Variables of the protocol are unset, so sockets are taken only once.
+func activatedFiles() []*os.File {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	files := make([]*os.File, 0, n)
+	for i := range n {
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		files = append(files, os.NewFile(uintptr(listenFdsStart+i), name))
+	}
+	return files
+}
+
+// listenActivated use sockets passed by systemd. Stream sockets named "http" serve
+// the HTTP API, other stream sockets serve DNS over TCP and datagram sockets serve DNS over UDP.
+func (s Server) listenActivated(l *listeners) error {
+	files := activatedFiles()
+	if len(files) == 0 {
+		return nil
+	}
+
+	handler := s.dnsMux()
+	var servers []*dns.Server
+	var httpListeners []net.Listener
+	var errs []error
+	for _, file := range files {
+		if ln, err := net.FileListener(file); err == nil {
+			if file.Name() == "http" {
+				httpListeners = append(httpListeners, ln)
+			} else {
+				servers = append(servers, &dns.Server{Net: "tcp", Listener: ln, Handler: handler})
+			}
+		} else if conn, err := net.FilePacketConn(file); err == nil {
+			servers = append(servers, &dns.Server{Net: "udp", PacketConn: conn, Handler: handler})
+		} else {
+			errs = append(errs, fmt.Errorf("can't use socket %s passed by systemd: %w", file.Name(), err))
+		}
+		// Listeners use duplicates of the descriptors.
+		file.Close()
+	}
+	if err := errors.Join(errs...); err != nil {
+		closeDNSServers(servers)
+		closeHTTPListeners(httpListeners)
+		return err
+	}
+
+	if len(servers) != 0 {
+		l.dnsActivated = true
+		l.dnsServers = servers
+	}
+	if len(httpListeners) != 0 {
+		l.httpActivated = true
+		l.httpListeners = httpListeners
+		l.httpServer = s.httpServer()
+	}
+	s.logger.Info(fmt.Sprintf("%d DNS and %d HTTP sockets are passed by systemd", len(servers), len(httpListeners)))
+	return nil
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/internal/metrics"
+)
+
+// registerMetrics register metrics which values are taken from the server state on each scrape.
+func (s Server) registerMetrics() {
+	s.metrics.GaugeFunc("dns_index_records", "Resource records loaded to the index for answering.",
+		func() float64 { return float64(s.index.Len()) })
+	s.metrics.GaugeFunc("dns_query_log_queue_length", "Query log entries waiting for saving to the database.",
+		func() float64 { return float64(len(s.queryLog)) })
+	s.metrics.GaugeFunc("dns_query_stream_subscribers", "Connected subscribers of the live query stream.",
+		func() float64 {
+			s.queryStream.mx.RLock()
+			defer s.queryStream.mx.RUnlock()
+			return float64(len(s.queryStream.subscribers))
+		})
+}
+
+// observeQuery update metrics of the answered query.
+func (s Server) observeQuery(entry database.QueryLogEntry) {
+	s.metrics.Queries.WithLabelValues(metrics.QueryType(entry.Type), entry.Protocol, entry.Rcode).Inc()
+
+	source := metrics.SourceLocal
+	switch {
+	case entry.Upstream != "":
+		source = metrics.SourceForwarded
+	case entry.CacheHit:
+		source = metrics.SourceCache
+	}
+	s.metrics.QueryDuration.WithLabelValues(source).Observe(entry.Latency.Seconds())
+
+	if entry.BlockReason != "" {
+		s.metrics.BlockedQueries.WithLabelValues(entry.BlockReason).Inc()
+	}
+}
+
+// metricsMiddleware count requests of the HTTP API by route pattern, so IDs in the path
+// don't create new series.
+func (s Server) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		s.metrics.HTTPRequests.WithLabelValues(route, r.Method, strconv.Itoa(status)).Inc()
+	})
+}
@@ -2,16 +2,203 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
-	"os"
+	"net/netip"
 	"slices"
+	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/miekg/dns"
+	"github.com/prionis/dns-server/internal/acl"
 	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/internal/metrics"
+	"github.com/prionis/dns-server/internal/rrl"
+	"github.com/prionis/dns-server/internal/stats"
+	"github.com/prionis/dns-server/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// rrlMiddleware limit rate of the responses sent over UDP.
+// TCP responses are not limited, because source address of the TCP client can't be spoofed.
+// It is called inside queryMiddleware, so dropped responses are not recorded
+// and truncated ones are recorded as they are sent.
+func (s Server) rrlMiddleware(next dns.HandlerFunc) dns.HandlerFunc {
+	return func(w dns.ResponseWriter, msg *dns.Msg) {
+		limiter := s.current().rrl
+		// Self-test of the readiness check must not be limited, or the server would report
+		// itself unavailable while it is flooded.
+		if _, ok := w.RemoteAddr().(*net.UDPAddr); !ok || limiter == nil || isSelfTest(w, msg) {
+			next(w, msg)
+			return
+		}
+		// View is selected by the source address, because the client subnet option is
+		// supplied by the client and would let it choose the limit it is subject to.
+		addr := remoteAddr(w)
+		next(&rrlWriter{
+			ResponseWriter: w,
+			limiter:        limiter,
+			metrics:        s.metrics,
+			addr:           addr,
+			view:           s.index.ViewName(s.index.SelectView(addr)),
+		}, msg)
+	}
+}
+
+// rrlWriter drop or truncate responses that exceed the limit.
+type rrlWriter struct {
+	dns.ResponseWriter
+	limiter *rrl.Limiter
+	// metrics count actions of the limiter, so they are not reset when limiter is replaced by reload.
+	metrics *metrics.Metrics
+	addr    netip.Addr
+	view    string
+}
+
+func (w *rrlWriter) WriteMsg(m *dns.Msg) error {
+	switch w.limiter.Check(w.addr, w.view, m) {
+	case rrl.Drop:
+		w.metrics.RRLDropped.Inc()
+		return nil
+	case rrl.Slip:
+		w.metrics.RRLSlipped.Inc()
+		truncated := new(dns.Msg)
+		truncated.MsgHdr = m.MsgHdr
+		truncated.Truncated = true
+		truncated.Question = m.Question
+		return w.ResponseWriter.WriteMsg(truncated)
+	}
+	w.metrics.RRLSent.Inc()
+	return w.ResponseWriter.WriteMsg(m)
+}
+
+// queryMiddleware record answered queries for the statistics, metrics and the query log.
+func (s Server) queryMiddleware(next dns.HandlerFunc) dns.HandlerFunc {
+	return func(w dns.ResponseWriter, msg *dns.Msg) {
+		qw := &queryWriter{ResponseWriter: w, start: time.Now()}
+		ctx, span := tracing.Tracer().Start(context.Background(), "dns query",
+			trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+		qw.ctx = ctx
+
+		next(qw, msg)
+		if qw.msg == nil || len(msg.Question) == 0 || isSelfTest(w, msg) {
+			return
+		}
+
+		question := msg.Question[0]
+		client := remoteAddr(w)
+		qtype := dns.Type(question.Qtype).String()
+		rcode := dns.RcodeToString[qw.msg.Rcode]
+		if span.IsRecording() {
+			span.SetAttributes(
+				attribute.String("dns.question.name", question.Name),
+				attribute.String("dns.question.type", qtype),
+				attribute.String("dns.response.rcode", rcode),
+				attribute.String("client.address", client.String()),
+				attribute.String("network.transport", protocolOf(w)),
+			)
+			if qw.blockReason != "" {
+				span.SetAttributes(attribute.String("dns.block_reason", qw.blockReason))
+			}
+		}
+		s.stats.Add(stats.Query{
+			Time:    qw.start,
+			Client:  client,
+			Domain:  question.Name,
+			Type:    qtype,
+			Rcode:   rcode,
+			Blocked: qw.blockReason != "",
+		})
+		entry := database.QueryLogEntry{
+			Time:        qw.start,
+			Client:      client,
+			Protocol:    protocolOf(w),
+			Name:        strings.ToLower(question.Name),
+			Type:        qtype,
+			Rcode:       rcode,
+			Answer:      answerSummary(qw.msg),
+			Upstream:    qw.upstream,
+			Latency:     time.Since(qw.start),
+			CacheHit:    qw.cacheHit,
+			BlockReason: qw.blockReason,
+		}
+		s.observeQuery(entry)
+		s.logQuery(entry)
+	}
+}
+
+// queryWriter keep the response and details of answering of the query.
+type queryWriter struct {
+	dns.ResponseWriter
+	// ctx contain span of the query.
+	ctx   context.Context
+	start time.Time
+	msg   *dns.Msg
+	// blockReason is set when query is refused by the server policy.
+	blockReason string
+	// upstream is set when query is answered by the upstream server.
+	upstream string
+	// cacheHit is set when query is answered from the cache.
+	cacheHit bool
+}
+
+func (w *queryWriter) WriteMsg(m *dns.Msg) error {
+	w.msg = m
+	return w.ResponseWriter.WriteMsg(m)
+}
+
+// setBlockReason mark query as blocked if it is answered through queryWriter.
+func setBlockReason(w dns.ResponseWriter, reason string) {
+	if qw, ok := queryWriterOf(w); ok {
+		qw.blockReason = reason
+	}
+}
+
+// queryWriterOf return queryWriter of the query, it may be wrapped by rrlWriter.
+func queryWriterOf(w dns.ResponseWriter) (*queryWriter, bool) {
+	if rw, ok := w.(*rrlWriter); ok {
+		w = rw.ResponseWriter
+	}
+	qw, ok := w.(*queryWriter)
+	return qw, ok
+}
+
+// aclMiddleware refuse DNS requests that are not allowed for the client by the access control list
+// and answer allowed transfers and updates as not implemented.
+func (s Server) aclMiddleware(next dns.HandlerFunc) dns.HandlerFunc {
+	return func(w dns.ResponseWriter, msg *dns.Msg) {
+		addr := remoteAddr(w)
+		action := acl.ActionOf(msg)
+		_, span := tracing.Tracer().Start(queryContext(w), "acl check",
+			trace.WithAttributes(attribute.String("acl.action", action.String())))
+		allowed := s.acl.Allowed(addr, action)
+		span.SetAttributes(attribute.Bool("acl.allowed", allowed))
+		span.End()
+		if !allowed {
+			m := new(dns.Msg)
+			m.SetRcode(msg, dns.RcodeRefused)
+			setBlockReason(w, "acl")
+			w.WriteMsg(m)
+			// Refused queries are counted by the metrics, logging each of them would flood the log.
+			s.logger.Debug(fmt.Sprintf("%s from %s refused by access control list", action, addr))
+			return
+		}
+		if action == acl.Transfer || action == acl.Update {
+			// Allowed transfers and updates must not be answered as queries by the next handler.
+			m := new(dns.Msg)
+			m.SetRcode(msg, dns.RcodeNotImplemented)
+			w.WriteMsg(m)
+			return
+		}
+		next(w, msg)
+	}
+}
+
 func (s Server) loggerMiddleware() func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -44,7 +231,9 @@ func (s Server) timeoutMiddleware(t time.Duration) func(http.Handler) http.Handl
 	}
 }
 
-func (s Server) authorizationMiddleware(allowed []string) func(next http.Handler) http.Handler {
+// authorizationMiddleware allow requests only of the users and API keys which role has the permission.
+// Users with temporary password are rejected until they change it.
+func (s Server) authorizationMiddleware(permission string) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user, ok := r.Context().Value("user").(database.User)
@@ -54,8 +243,13 @@ func (s Server) authorizationMiddleware(allowed []string) func(next http.Handler
 				return
 			}
 
-			if !slices.Contains(allowed, user.Role) {
-				s.logger.Error("user " + user.Login + " don't have rights")
+			if user.MustChangePassword {
+				s.logger.Error("user " + user.Login + " must change password")
+				http.Error(w, "Password must be changed", http.StatusForbidden)
+				return
+			}
+			if !slices.Contains(user.Permissions, permission) {
+				s.logger.Error("user " + user.Login + " don't have " + permission + " permission")
 				http.Error(w, "Not enough rights for this", http.StatusForbidden)
 				return
 			}
@@ -64,47 +258,69 @@ func (s Server) authorizationMiddleware(allowed []string) func(next http.Handler
 	}
 }
 
+// authenticationMiddleware authenticate requests with API key from the Authorization header
+// or with JWT token from the cookie.
 func (s Server) authenticationMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie("jwt")
+		if key, ok := bearerToken(r); ok {
+			ctx, err := s.authenticateAPIKey(r.Context(), key)
+			if err != nil {
+				s.logger.Error("can't authenticate API key: " + err.Error())
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		cookie, err := r.Cookie(accessCookie)
 		if err != nil {
 			s.logger.Error("getting jwt token cookie from request: " + err.Error())
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			s.logger.Error("JWT_SECRET environment variable is not set")
-			next.ServeHTTP(w, r)
+		claims, err := s.parseAccessToken(cookie.Value)
+		if errors.Is(err, errNoJWTSecret) {
+			s.logger.Error(err.Error())
+			http.Error(w, "Internal error, try later", http.StatusInternalServerError)
 			return
 		}
-
-		token, err := jwt.Parse(cookie.Value, func(t *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 		if err != nil {
 			s.logger.Error("can't parse JWT token: " + err.Error())
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			s.logger.Error("can't retrive claims from token: " + err.Error())
+		revoked, err := s.db.IsTokenRevoked(r.Context(), claims["jti"].(string))
+		if err != nil {
+			s.logger.Error("can't check revocation of JWT token: " + err.Error())
+			http.Error(w, "Internal error, try later", http.StatusInternalServerError)
+			return
+		}
+		if revoked {
+			s.logger.Error("JWT token of " + claims["login"].(string) + " is revoked")
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		login := claims["login"].(string)
-		user, err := s.db.GetUser(context.Background(), login)
+		// Role is taken from the database, so changes of the user apply to issued tokens.
+		user, err := s.db.GetUser(r.Context(), claims["login"].(string))
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Error("user " + claims["login"].(string) + " of JWT token doesn't exist")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
 		if err != nil {
 			s.logger.Error("can't retrive user from database: " + err.Error())
 			http.Error(w, "Internal error, try later", http.StatusInternalServerError)
 			return
 		}
 
-		r = r.WithContext(context.WithValue(r.Context(), "user", user))
+		ctx := context.WithValue(r.Context(), "user", user)
+		ctx = database.WithActor(ctx, user.Login)
+		ctx = database.WithZones(ctx, user.Zones)
+		r = r.WithContext(ctx)
 		next.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,342 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/miekg/dns"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/internal/rrl"
+)
+
+func TestACLMiddleware(t *testing.T) {
+	s := newTestServer(t, []database.ResourceRecord{
+		{ID: 1, Domain: "example.com.", Type: "A", Class: "IN", Data: "10.0.0.1", TTL: 60},
+	}, WithACLRules(database.ACLRule{Network: netip.MustParsePrefix("127.0.0.0/8"), AllowQuery: true}))
+	handler := s.aclMiddleware(s.dnsHandler)
+
+	w := &testResponseWriter{}
+	handler(w, new(dns.Msg).SetQuestion("example.com.", dns.TypeA))
+	if w.msg.Rcode != dns.RcodeSuccess || len(w.msg.Answer) != 1 {
+		t.Fatalf("expected answer for allowed query, got %v", w.msg)
+	}
+
+	handler(w, new(dns.Msg).SetAxfr("example.com."))
+	if w.msg.Rcode != dns.RcodeRefused {
+		t.Fatalf("expected transfer to be refused, got %v", w.msg)
+	}
+
+	s = newTestServer(t, nil, WithACLRules(database.ACLRule{
+		Network: netip.MustParsePrefix("127.0.0.0/8"), AllowQuery: true, AllowTransfer: true, AllowUpdate: true,
+	}))
+	handler = s.aclMiddleware(s.dnsHandler)
+	handler(w, new(dns.Msg).SetAxfr("example.com."))
+	if w.msg.Rcode != dns.RcodeNotImplemented {
+		t.Fatalf("expected allowed transfer to be not implemented, got %v", w.msg)
+	}
+	handler(w, new(dns.Msg).SetUpdate("example.com."))
+	if w.msg.Rcode != dns.RcodeNotImplemented {
+		t.Fatalf("expected allowed update to be not implemented, got %v", w.msg)
+	}
+}
+
+func TestRRLMiddleware(t *testing.T) {
+	config := rrl.DefaultConfig
+	config.ResponsesPerSecond = 5
+	config.Slip = 2
+	unlimited := config
+	unlimited.ResponsesPerSecond = 1000
+	s := newTestServer(t, nil, WithRateLimit(config), WithViewRateLimit("office", unlimited),
+		WithClientSubnet(netip.MustParsePrefix("127.0.0.0/8")))
+	err := s.index.Load([]database.ResourceRecord{
+		{ID: 1, Domain: "example.com.", Type: "A", Class: "IN", Data: "10.0.0.1", TTL: 60},
+	}, []database.View{
+		{ID: 1, Name: "office", Networks: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &dns.Server{
+		PacketConn: conn,
+		Handler:    s.dnsMux(),
+	}
+	go server.ActivateAndServe()
+	defer server.Shutdown()
+
+	// Every query is sent from the new socket, so source ports are different,
+	// but all of them are in the same client network. Client subnet of the office view
+	// doesn't lift the limit of the source address.
+	msg := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+	msg.SetEdns0(1232, false)
+	msg.IsEdns0().Option = append(msg.IsEdns0().Option, &dns.EDNS0_SUBNET{
+		Code:          dns.EDNS0SUBNET,
+		Family:        1,
+		SourceNetmask: 24,
+		Address:       net.ParseIP("10.1.2.0").To4(),
+	})
+	client := &dns.Client{Timeout: 100 * time.Millisecond}
+	var answered, truncated, dropped int
+	for range 20 {
+		m, _, err := client.Exchange(msg.Copy(), conn.LocalAddr().String())
+		switch {
+		case err != nil:
+			dropped++
+		case m.Truncated:
+			truncated++
+		case len(m.Answer) == 1:
+			answered++
+		}
+	}
+	if answered != 5 || truncated == 0 || dropped == 0 || answered+truncated+dropped != 20 {
+		t.Fatalf("expected 5 answered and the rest truncated or dropped, got %d answered, %d truncated and %d dropped",
+			answered, truncated, dropped)
+	}
+
+	stats := s.current().rrl.Stats()
+	if stats.Sent != 5 || stats.Slipped != uint64(truncated) || stats.Dropped != uint64(dropped) {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	// Dropped responses are not sent, so they are not recorded.
+	if len(s.queryLog) != answered+truncated {
+		t.Fatalf("expected %d sent responses in the query log, got %d", answered+truncated, len(s.queryLog))
+	}
+
+	// Counters are kept when the limiter is replaced by reload.
+	set := *s.current()
+	set.rrl = rrl.New(config, nil, nil)
+	s.settings.Store(&set)
+	rec := httptest.NewRecorder()
+	s.metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		fmt.Sprintf("dns_rrl_sent_total %d", answered),
+		fmt.Sprintf("dns_rrl_slipped_total %d", truncated),
+		fmt.Sprintf("dns_rrl_dropped_total %d", dropped),
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected metrics to contain %s", want)
+		}
+	}
+}
+
+func TestQueryMiddleware(t *testing.T) {
+	s := newTestServer(t, []database.ResourceRecord{
+		{ID: 1, Domain: "example.com.", Type: "A", Class: "IN", Data: "10.0.0.1", TTL: 60},
+	}, WithACLRules(database.ACLRule{Network: netip.MustParsePrefix("127.0.0.0/8"), AllowQuery: true}))
+	handler := s.queryMiddleware(s.aclMiddleware(s.dnsHandler))
+
+	w := &testResponseWriter{}
+	handler(w, new(dns.Msg).SetQuestion("Example.com.", dns.TypeA))
+	handler(w, new(dns.Msg).SetQuestion("example.com.", dns.TypeA))
+	handler(w, new(dns.Msg).SetAxfr("example.com."))
+
+	var allowed, blocked int64
+	for _, stat := range s.stats.Take() {
+		if stat.Domain != "example.com." || stat.Client.String() != "127.0.0.1" {
+			t.Fatalf("unexpected statistics %+v", stat)
+		}
+		if stat.Blocked {
+			blocked += stat.Count
+			if stat.Rcode != "REFUSED" || stat.Type != "AXFR" {
+				t.Fatalf("unexpected statistics of blocked query %+v", stat)
+			}
+		} else {
+			allowed += stat.Count
+		}
+	}
+	if allowed != 2 || blocked != 1 {
+		t.Fatalf("expected 2 allowed and 1 blocked queries, got %d and %d", allowed, blocked)
+	}
+
+	if len(s.queryLog) != 3 {
+		t.Fatalf("expected 3 query log entries, got %d", len(s.queryLog))
+	}
+	entry := <-s.queryLog
+	if entry.Name != "example.com." || entry.Protocol != "udp" || entry.Answer != "A 10.0.0.1" ||
+		entry.BlockReason != "" {
+		t.Fatalf("unexpected query log entry %+v", entry)
+	}
+	<-s.queryLog
+	if entry := <-s.queryLog; entry.BlockReason != "acl" || entry.Rcode != "REFUSED" {
+		t.Fatalf("unexpected query log entry of blocked query %+v", entry)
+	}
+
+	// Unknown types don't create new series.
+	handler(w, new(dns.Msg).SetQuestion("example.com.", dns.TypeNULL))
+	handler(w, new(dns.Msg).SetQuestion("example.com.", 65280))
+
+	rec := httptest.NewRecorder()
+	s.metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`dns_queries_total{protocol="udp",rcode="NOERROR",type="A"} 2`,
+		`dns_queries_total{protocol="udp",rcode="NOERROR",type="OTHER"} 2`,
+		`dns_blocked_queries_total{reason="acl"} 1`,
+		`dns_index_records 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected metrics to contain %s", want)
+		}
+	}
+}
+
+func TestQueryTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	s := newTestServer(t, []database.ResourceRecord{
+		{ID: 1, Domain: "example.com.", Type: "A", Class: "IN", Data: "10.0.0.1", TTL: 60},
+	}, WithACLRules(database.ACLRule{Network: netip.MustParsePrefix("127.0.0.0/8"), AllowQuery: true}))
+	s.queryMiddleware(s.aclMiddleware(s.dnsHandler))(&testResponseWriter{},
+		new(dns.Msg).SetQuestion("example.com.", dns.TypeA))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	root := spans[len(spans)-1]
+	if root.Name != "dns query" {
+		t.Fatalf("expected query span to end last, got %s", root.Name)
+	}
+	for _, span := range spans[:2] {
+		if span.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("expected %s span to be child of the query span", span.Name)
+		}
+	}
+}
+
+// apiKeyRepository is the repository which contain one API key.
+type apiKeyRepository struct {
+	database.Repository
+	key  database.APIKey
+	hash []byte
+}
+
+func (r apiKeyRepository) GetAPIKeyByHash(_ context.Context, hash []byte) (database.APIKey, error) {
+	if !bytes.Equal(hash, r.hash) {
+		return database.APIKey{}, pgx.ErrNoRows
+	}
+	return r.key, nil
+}
+
+func (r apiKeyRepository) TouchAPIKey(context.Context, int32, time.Time) error {
+	return nil
+}
+
+func TestAuthenticationMiddlewareAPIKey(t *testing.T) {
+	token, err := newAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := database.APIKey{ID: 1, Name: "ci", Role: "user", Zones: []string{"example.com."}}
+
+	var user database.User
+	var zones []string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ = r.Context().Value("user").(database.User)
+		zones = database.ZonesFromContext(r.Context())
+	})
+
+	tests := []struct {
+		name   string
+		token  string
+		modify func(*database.APIKey)
+		code   int
+	}{
+		{name: "valid", token: token, code: http.StatusOK},
+		{name: "unknown", token: apiKeyPrefix + "unknown", code: http.StatusUnauthorized},
+		{name: "revoked", token: token, modify: func(k *database.APIKey) { k.RevokedAt = time.Now() }, code: http.StatusUnauthorized},
+		{name: "expired", token: token, modify: func(k *database.APIKey) { k.ExpiresAt = time.Now().Add(-time.Hour) }, code: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := key
+			if tt.modify != nil {
+				tt.modify(&k)
+			}
+			s := newTestServer(t, nil, WithDB(apiKeyRepository{key: k, hash: hashToken(token)}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/rrs/all", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			s.authenticationMiddleware(next).ServeHTTP(rec, req)
+			if rec.Code != tt.code {
+				t.Fatalf("expected %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if user.Login != "key:ci" || user.Role != "user" || !slices.Equal(zones, key.Zones) {
+		t.Fatalf("expected key user with its role and zones, got %+v and %v", user, zones)
+	}
+}
+
+func TestAuthorizationPermissions(t *testing.T) {
+	user := database.User{
+		ID:          1,
+		Login:       "viewer",
+		Role:        "viewer",
+		Zones:       []string{"example.com."},
+		Permissions: []string{database.PermRecordsRead},
+	}
+	s := newTestServer(t, nil, WithDB(historyRepository{newSessionRepository(user)}), WithJWTSecret("secret"))
+	token, err := s.signAccessToken(user, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodGet, "/api/rrs/1/history", http.StatusOK},
+		{http.MethodDelete, "/api/rrs/1", http.StatusForbidden},
+		{http.MethodPost, "/api/rrs/batch", http.StatusForbidden},
+		{http.MethodGet, "/api/roles/", http.StatusForbidden},
+		{http.MethodGet, "/api/zones/example.com/changelog", http.StatusOK},
+		{http.MethodGet, "/api/zones/example.org/changelog", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.AddCookie(&http.Cookie{Name: accessCookie, Value: token})
+		rec := httptest.NewRecorder()
+		s.router().ServeHTTP(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("%s %s: expected %d, got %d: %s", tt.method, tt.path, tt.code, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// historyRepository is the session repository without changes of the resource records.
+type historyRepository struct {
+	*sessionRepository
+}
+
+func (historyRepository) GetRecordHistory(context.Context, int32) ([]database.RecordChange, error) {
+	return nil, nil
+}
+
+func (historyRepository) GetZoneHistory(context.Context, string, int32) ([]database.RecordChange, error) {
+	return nil, nil
+}
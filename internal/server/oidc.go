@@ -0,0 +1,325 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/oauth2"
+
+	"github.com/prionis/dns-server/internal/database"
+)
+
+const (
+	// oidcCookie contain state, PKCE verifier and nonce of the login in progress.
+	oidcCookie = "oidc"
+	// oidcLoginTimeout is the time for the user to log in at the provider.
+	oidcLoginTimeout = 10 * time.Minute
+	// Limits of the users table.
+	maxLoginLen = 16
+	maxNameLen  = 20
+)
+
+var (
+	errOIDCNoRole      = errors.New("No role is mapped for the user")
+	errOIDCLogin       = errors.New("Login claim is missing or too long")
+	errOIDCLoginExists = errors.New("Login is taken by the local user")
+)
+
+// oidcProvider is the OpenID Connect provider used for login.
+type oidcProvider struct {
+	config OIDCConfig
+
+	mx       sync.Mutex
+	provider *oidc.Provider
+}
+
+// newOIDCProvider return provider with defaults applied to the config.
+// Configuration of the provider is discovered on the first login.
+func newOIDCProvider(config OIDCConfig) *oidcProvider {
+	if config.Scopes == nil {
+		config.Scopes = []string{"profile", "email"}
+	}
+	if config.LoginClaim == "" {
+		config.LoginClaim = "preferred_username"
+	}
+	if config.RolesClaim == "" {
+		config.RolesClaim = "groups"
+	}
+	return &oidcProvider{config: config}
+}
+
+// discover return provider with the discovered configuration.
+// Failed discovery is repeated on the next login.
+func (p *oidcProvider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if p.provider != nil {
+		return p.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.config.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	p.provider = provider
+	return provider, nil
+}
+
+// oauth2 return OAuth 2.0 configuration of the client.
+func (p *oidcProvider) oauth2(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       append([]string{oidc.ScopeOpenID}, p.config.Scopes...),
+	}
+}
+
+// role return local role of the user with provided claims.
+// It is empty if no mapping match and there is no default role.
+func (p *oidcProvider) role(claims map[string]any) string {
+	var values []string
+	switch v := claim(claims, p.config.RolesClaim).(type) {
+	case string:
+		values = []string{v}
+	case []any:
+		for _, value := range v {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	for _, mapping := range p.config.Roles {
+		for _, value := range values {
+			if value == mapping.Value {
+				return mapping.Role
+			}
+		}
+	}
+	return p.config.DefaultRole
+}
+
+// claim return value of the claim with provided name. Names of the nested claims are separated by dots.
+func claim(claims map[string]any, name string) any {
+	var value any = claims
+	for _, key := range strings.Split(name, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// truncate return first n runes of the string.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// oidcLoginHandler redirect user to the provider. State, PKCE verifier and nonce
+// are kept in the cookie until the callback.
+func (s Server) oidcLoginHandler(w http.ResponseWriter, r *http.Request) {
+	p := s.current().oidc
+	if p == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	provider, err := p.discover(r.Context())
+	if err != nil {
+		s.logger.Error("can't discover OIDC provider: " + err.Error())
+		http.Error(w, "Identity provider is unavailable", http.StatusBadGateway)
+		return
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		s.logger.Error(err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		s.logger.Error(err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookie,
+		Value:    strings.Join([]string{state, verifier, nonce}, "."),
+		Path:     "/auth/oidc",
+		HttpOnly: true,
+		MaxAge:   int(oidcLoginTimeout.Seconds()),
+		// Callback is the cross-site navigation from the provider.
+		SameSite: http.SameSiteLaxMode,
+	})
+	url := p.oauth2(provider).AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oidc.Nonce(nonce))
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// oidcCallbackHandler handle redirect from the provider. Code is exchanged for the ID token,
+// user is found by its subject or created, and the session is started as by the login.
+func (s Server) oidcCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	p := s.current().oidc
+	if p == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	cookie, err := r.Cookie(oidcCookie)
+	if err != nil {
+		s.logger.Error("getting OIDC cookie from request: " + err.Error())
+		http.Error(w, "Login is expired, try again", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcCookie, Path: "/auth/oidc", HttpOnly: true, MaxAge: -1})
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 {
+		http.Error(w, "Login is expired, try again", http.StatusBadRequest)
+		return
+	}
+	state, verifier, nonce := parts[0], parts[1], parts[2]
+
+	query := r.URL.Query()
+	if e := query.Get("error"); e != "" {
+		s.logger.Error("OIDC provider returned error: " + e + ": " + query.Get("error_description"))
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state)) != 1 {
+		s.logger.Error("OIDC state mismatch")
+		http.Error(w, "Invalid state", http.StatusBadRequest)
+		return
+	}
+
+	provider, err := p.discover(r.Context())
+	if err != nil {
+		s.logger.Error("can't discover OIDC provider: " + err.Error())
+		http.Error(w, "Identity provider is unavailable", http.StatusBadGateway)
+		return
+	}
+	token, err := p.oauth2(provider).Exchange(r.Context(), query.Get("code"), oauth2.VerifierOption(verifier))
+	if err != nil {
+		s.logger.Error("can't exchange OIDC code: " + err.Error())
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		s.logger.Error("OIDC token response has no ID token")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.config.ClientID}).Verify(r.Context(), rawIDToken)
+	if err != nil {
+		s.logger.Error("can't verify OIDC ID token: " + err.Error())
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		s.logger.Error("OIDC nonce mismatch")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		s.logger.Error("can't parse OIDC claims: " + err.Error())
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := s.oidcUser(r.Context(), p, idToken, claims)
+	if err != nil {
+		s.logger.Error("can't get user of OIDC subject " + idToken.Subject + ": " + err.Error())
+		switch {
+		case errors.Is(err, errOIDCNoRole), errors.Is(err, errOIDCLogin):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, errOIDCLoginExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	if err := s.issueTokens(r.Context(), w, user); err != nil {
+		s.logger.Error("can't issue tokens for user " + user.Login + ": " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/", http.StatusFound)
+	s.logger.Info("OIDC login of " + user.Login + " handled")
+}
+
+// oidcUser return local user of the verified ID token. User is created on the first login
+// and its role is updated from the claims on every login.
+func (s Server) oidcUser(ctx context.Context, p *oidcProvider, token *oidc.IDToken, claims map[string]any) (database.User, error) {
+	role := p.role(claims)
+	if role == "" {
+		return database.User{}, errOIDCNoRole
+	}
+
+	subject := token.Issuer + " " + token.Subject
+	user, err := s.db.GetUserBySubject(ctx, subject)
+	switch {
+	case err == nil:
+		if user.Role == role {
+			return user, nil
+		}
+		if err := s.db.SetUserRole(ctx, user.ID, role); err != nil {
+			return database.User{}, fmt.Errorf("can't update role: %w", err)
+		}
+		return s.db.GetUserBySubject(ctx, subject)
+	case !errors.Is(err, pgx.ErrNoRows):
+		return database.User{}, err
+	}
+
+	login, _ := claim(claims, p.config.LoginClaim).(string)
+	if login == "" || utf8.RuneCountInString(login) > maxLoginLen {
+		return database.User{}, errOIDCLogin
+	}
+	_, err = s.db.GetUser(ctx, login)
+	if err == nil {
+		return database.User{}, errOIDCLoginExists
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return database.User{}, err
+	}
+
+	firstName, _ := claims["given_name"].(string)
+	if firstName == "" {
+		firstName, _ = claims["name"].(string)
+	}
+	if firstName == "" {
+		firstName = login
+	}
+	lastName, _ := claims["family_name"].(string)
+	_, err = s.db.AddOIDCUser(ctx, database.User{
+		Login:     login,
+		FirstName: truncate(firstName, maxNameLen),
+		LastName:  truncate(lastName, maxNameLen),
+		Role:      role,
+	}, subject)
+	if err != nil {
+		return database.User{}, err
+	}
+	s.logger.Info("user " + login + " is created by OIDC login")
+	return s.db.GetUserBySubject(ctx, subject)
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/coreos/go-oidc/v3/oidc/oidctest"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/prionis/dns-server/internal/database"
+)
+
+// oidcRepository is the session repository which also store users created by OIDC login.
+type oidcRepository struct {
+	*sessionRepository
+	subjects map[string]string
+}
+
+func (r *oidcRepository) GetUserBySubject(ctx context.Context, subject string) (database.User, error) {
+	login, ok := r.subjects[subject]
+	if !ok {
+		return database.User{}, pgx.ErrNoRows
+	}
+	return r.GetUser(ctx, login)
+}
+
+func (r *oidcRepository) AddOIDCUser(_ context.Context, user database.User, subject string) (int32, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	user.ID = int32(len(r.users) + 1)
+	r.users[user.Login] = user
+	r.subjects[subject] = user.Login
+	return user.ID, nil
+}
+
+func (r *oidcRepository) SetUserRole(_ context.Context, id int32, role string) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	for login, user := range r.users {
+		if user.ID == id {
+			user.Role = role
+			r.users[login] = user
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+// newOIDCIssuer start provider which issue ID token with provided groups for any code.
+// Code verifier is checked against the PKCE challenge sent to the authorization endpoint.
+func newOIDCIssuer(t *testing.T, groups *[]string) *httptest.Server {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &oidctest.Server{
+		PublicKeys: []oidctest.PublicKey{{PublicKey: key.Public(), KeyID: "test", Algorithm: oidc.RS256}},
+	}
+
+	var challenge, nonce string
+	mux := http.NewServeMux()
+	mux.Handle("/", provider)
+	mux.HandleFunc("/auth", func(w http.ResponseWriter, r *http.Request) {
+		challenge, nonce = r.URL.Query().Get("code_challenge"), r.URL.Query().Get("nonce")
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		groupsJSON, _ := json.Marshal(*groups)
+		claims := fmt.Sprintf(`{"iss":%q,"sub":"42","aud":"dns","exp":%d,"iat":%d,"nonce":%q,`+
+			`"preferred_username":"bob","given_name":"Bob","groups":%s}`,
+			"http://"+r.Host, time.Now().Add(time.Minute).Unix(), time.Now().Unix(), nonce, groupsJSON)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   60,
+			"id_token":     oidctest.SignIDToken(key, "test", oidc.RS256, claims),
+		})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	provider.SetIssuer(server.URL)
+	return server
+}
+
+// oidcLogin go through the login at the issuer and return the callback response.
+func oidcLogin(t *testing.T, s Server, state string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.oidcLoginHandler(rec, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected redirect to the provider, got %d: %s", rec.Code, rec.Body.String())
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(location.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if state == "" {
+		state = location.Query().Get("state")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?code=code&state="+url.QueryEscape(state), nil)
+	req.AddCookie(cookie(t, rec, oidcCookie))
+	rec = httptest.NewRecorder()
+	s.oidcCallbackHandler(rec, req)
+	return rec
+}
+
+func TestOIDCLogin(t *testing.T) {
+	groups := []string{"staff", "dns-admins"}
+	issuer := newOIDCIssuer(t, &groups)
+	repo := &oidcRepository{sessionRepository: newSessionRepository(), subjects: make(map[string]string)}
+	s := newTestServer(t, nil, WithDB(repo), WithJWTSecret("secret"), WithOIDC(OIDCConfig{
+		Issuer:      issuer.URL,
+		ClientID:    "dns",
+		RedirectURL: "http://dns.test/auth/oidc/callback",
+		Roles:       []OIDCRole{{Value: "dns-admins", Role: "admin"}, {Value: "staff", Role: "user"}},
+	}))
+
+	if rec := oidcLogin(t, s, "forged"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected callback with wrong state to be rejected, got %d", rec.Code)
+	}
+
+	rec := oidcLogin(t, s, "")
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected login to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if code := authenticate(s, cookie(t, rec, accessCookie)); code != http.StatusOK {
+		t.Fatalf("expected issued access token to be accepted, got %d", code)
+	}
+	user, err := repo.GetUserBySubject(context.Background(), issuer.URL+" 42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Login != "bob" || user.FirstName != "Bob" || user.Role != "admin" {
+		t.Fatalf("unexpected provisioned user %+v", user)
+	}
+
+	groups = []string{"staff"}
+	if rec := oidcLogin(t, s, ""); rec.Code != http.StatusFound {
+		t.Fatalf("expected second login to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if user, _ := repo.GetUser(context.Background(), "bob"); user.Role != "user" {
+		t.Fatalf("expected role to be updated from groups, got %q", user.Role)
+	}
+
+	groups = nil
+	if rec := oidcLogin(t, s, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected login without mapped role to be rejected, got %d", rec.Code)
+	}
+}
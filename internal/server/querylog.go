@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/proto/crud/genproto/crudpb"
+)
+
+const (
+	// queryLogBuffer is how many entries wait for saving before new entries are dropped.
+	queryLogBuffer = 10000
+	// queryLogBatch is the maximum count of entries saved to the database at once.
+	queryLogBatch = 1000
+	// queryLogFlushInterval is how long entries wait for the batch to be filled.
+	queryLogFlushInterval = time.Second
+	// maxAnswerSummary limit length of the answer summary in the query log.
+	maxAnswerSummary = 512
+)
+
+// logQuery queue the entry for saving to the query log and send it to the live stream.
+// Entry is dropped if the database can't keep up, so answering is never blocked by logging.
+func (s Server) logQuery(entry database.QueryLogEntry) {
+	s.queryStream.publish(entry)
+	select {
+	case s.queryLog <- entry:
+	default:
+	}
+}
+
+// saveQueryLog save queued entries of the query log to the database in batches
+// and delete entries that are older than retention. Queued entries are saved when ctx is done.
+func (s Server) saveQueryLog(ctx context.Context) {
+	flush := time.NewTicker(queryLogFlushInterval)
+	defer flush.Stop()
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	batch := make([]database.QueryLogEntry, 0, queryLogBatch)
+	save := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.db.AddQueryLog(ctx, batch); err != nil {
+			s.logger.Error(fmt.Sprintf("can't save %d query log entries: %s", len(batch), err.Error()))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flushTimeout)
+			defer cancel()
+			for {
+				select {
+				case entry := <-s.queryLog:
+					batch = append(batch, entry)
+					if len(batch) == queryLogBatch {
+						save(flushCtx)
+					}
+				default:
+					save(flushCtx)
+					return
+				}
+			}
+
+		case entry := <-s.queryLog:
+			batch = append(batch, entry)
+			if len(batch) == queryLogBatch {
+				save(ctx)
+			}
+
+		case <-flush.C:
+			save(ctx)
+
+		case <-cleanup.C:
+			deleted, err := s.db.DeleteQueryLogBefore(ctx, time.Now().Add(-s.current().queryLogRetention))
+			if err != nil {
+				s.logger.Error("can't delete old query log entries: " + err.Error())
+				continue
+			}
+			s.logger.Info(fmt.Sprintf("%d old query log entries deleted", deleted))
+		}
+	}
+}
+
+// protocolOf return transport protocol of the query.
+func protocolOf(w dns.ResponseWriter) string {
+	if _, ok := w.RemoteAddr().(*net.TCPAddr); ok {
+		return "tcp"
+	}
+	return "udp"
+}
+
+// answerSummary return short text of the answer records, e.g. "A 10.0.0.1, A 10.0.0.2".
+func answerSummary(m *dns.Msg) string {
+	var b strings.Builder
+	for i, rr := range m.Answer {
+		if i != 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(dns.TypeToString[rr.Header().Rrtype])
+		b.WriteString(" ")
+		b.WriteString(strings.TrimPrefix(rr.String(), rr.Header().String()))
+		if b.Len() > maxAnswerSummary {
+			return b.String()[:maxAnswerSummary]
+		}
+	}
+	return b.String()
+}
+
+// toProtoQueryLogEntry convert entry of the query log to the protobuf message.
+func toProtoQueryLogEntry(entry database.QueryLogEntry) *crudpb.QueryLogEntry {
+	return &crudpb.QueryLogEntry{
+		Id:          entry.ID,
+		Time:        timestamppb.New(entry.Time),
+		Client:      entry.Client.String(),
+		Protocol:    entry.Protocol,
+		Name:        entry.Name,
+		Type:        entry.Type,
+		Rcode:       entry.Rcode,
+		Answer:      entry.Answer,
+		Upstream:    entry.Upstream,
+		LatencyUs:   entry.Latency.Microseconds(),
+		CacheHit:    entry.CacheHit,
+		BlockReason: entry.BlockReason,
+	}
+}
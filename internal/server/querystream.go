@@ -0,0 +1,243 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/miekg/dns"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/proto/crud/genproto/crudpb"
+)
+
+const (
+	// streamQueueSize is how many events wait for sending to one subscriber
+	// before new events for it are dropped.
+	streamQueueSize = 256
+	// streamWriteWait is how long one frame may be written to the subscriber.
+	streamWriteWait = 10 * time.Second
+	// streamPongWait is how long the subscriber may be silent before it is disconnected.
+	streamPongWait = 60 * time.Second
+	// streamPingPeriod is how often the subscriber is pinged, it must be less than streamPongWait.
+	streamPingPeriod = streamPongWait * 9 / 10
+	// streamMaxMessage limit size of the subscription filter sent by the subscriber.
+	streamMaxMessage = 4096
+)
+
+// queryFilter select queries that are sent to the subscriber.
+// Zero fields match all queries.
+type queryFilter struct {
+	network     netip.Prefix
+	domain      string
+	qtype       string
+	blockedOnly bool
+}
+
+// queryFilterFromProto convert subscription message to the filter.
+func queryFilterFromProto(msg *crudpb.QueryLogSubscription) (queryFilter, error) {
+	filter := queryFilter{
+		qtype:       strings.ToUpper(msg.GetType()),
+		blockedOnly: msg.GetBlockedOnly(),
+	}
+	if client := msg.GetClient(); client != "" {
+		network, err := netip.ParsePrefix(client)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(client)
+			if addrErr != nil {
+				return filter, fmt.Errorf("%q is not a valid network", client)
+			}
+			network = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		filter.network = network.Masked()
+	}
+	if domain := msg.GetDomain(); domain != "" {
+		filter.domain = strings.ToLower(dns.Fqdn(domain))
+	}
+	return filter, nil
+}
+
+// match report whether the entry pass the filter.
+func (f queryFilter) match(entry database.QueryLogEntry) bool {
+	if f.network.IsValid() && !f.network.Contains(entry.Client) {
+		return false
+	}
+	if f.domain != "" && f.domain != "." && entry.Name != f.domain &&
+		!strings.HasSuffix(entry.Name, "."+f.domain) {
+		return false
+	}
+	if f.qtype != "" && entry.Type != f.qtype {
+		return false
+	}
+	if f.blockedOnly && entry.BlockReason == "" {
+		return false
+	}
+	return true
+}
+
+// queryStream send answered queries to the connected subscribers.
+type queryStream struct {
+	mx          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+// subscriber is one connection of the live query stream.
+type subscriber struct {
+	conn  *websocket.Conn
+	send  chan []byte
+	mx    sync.RWMutex
+	query queryFilter
+	// dropped count events that were not sent because the subscriber is too slow.
+	dropped int
+}
+
+func newQueryStream() *queryStream {
+	return &queryStream{subscribers: make(map[*subscriber]struct{})}
+}
+
+func (qs *queryStream) add(sub *subscriber) {
+	qs.mx.Lock()
+	defer qs.mx.Unlock()
+	qs.subscribers[sub] = struct{}{}
+}
+
+func (qs *queryStream) remove(sub *subscriber) {
+	qs.mx.Lock()
+	defer qs.mx.Unlock()
+	delete(qs.subscribers, sub)
+}
+
+// close send close message to all subscribers and disconnect them.
+func (qs *queryStream) close() {
+	qs.mx.RLock()
+	defer qs.mx.RUnlock()
+	for sub := range qs.subscribers {
+		sub.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"),
+			time.Now().Add(streamWriteWait))
+		sub.conn.Close()
+	}
+}
+
+// publish queue the entry for all subscribers which filter it pass.
+// Event is dropped for subscribers which queue is full, so answering never waits for them.
+func (qs *queryStream) publish(entry database.QueryLogEntry) {
+	qs.mx.RLock()
+	defer qs.mx.RUnlock()
+	if len(qs.subscribers) == 0 {
+		return
+	}
+
+	var frame []byte
+	for sub := range qs.subscribers {
+		sub.mx.RLock()
+		ok := sub.query.match(entry)
+		sub.mx.RUnlock()
+		if !ok {
+			continue
+		}
+
+		if frame == nil {
+			var err error
+			frame, err = proto.Marshal(toProtoQueryLogEntry(entry))
+			if err != nil {
+				return
+			}
+		}
+		select {
+		case sub.send <- frame:
+		default:
+			sub.mx.Lock()
+			sub.dropped++
+			sub.mx.Unlock()
+		}
+	}
+}
+
+// queryStreamHandler stream answered queries to the WebSocket connection.
+// Client may send QueryLogSubscription messages at any time to change the filter.
+func (s Server) queryStreamHandler(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.logger.Error("can't upgrade connection " + err.Error())
+		return
+	}
+
+	sub := &subscriber{conn: conn, send: make(chan []byte, streamQueueSize)}
+	s.queryStream.add(sub)
+	s.logger.Info("new query stream subscriber " + conn.RemoteAddr().String())
+
+	done := make(chan struct{})
+	go s.writeQueryStream(sub, done)
+	s.readQueryStream(sub)
+
+	s.queryStream.remove(sub)
+	close(done)
+	conn.Close()
+	s.logger.Info(fmt.Sprintf("query stream subscriber %s disconnected, %d events dropped",
+		conn.RemoteAddr().String(), sub.dropped))
+}
+
+// readQueryStream read subscription filters from the subscriber until the connection is closed.
+func (s Server) readQueryStream(sub *subscriber) {
+	sub.conn.SetReadLimit(streamMaxMessage)
+	sub.conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	sub.conn.SetPongHandler(func(string) error {
+		return sub.conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	})
+
+	for {
+		_, data, err := sub.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		msg := &crudpb.QueryLogSubscription{}
+		if err := proto.Unmarshal(data, msg); err != nil {
+			s.logger.Error("can't unmarshal query stream subscription: " + err.Error())
+			continue
+		}
+		filter, err := queryFilterFromProto(msg)
+		if err != nil {
+			s.logger.Error("incorrect query stream subscription: " + err.Error())
+			continue
+		}
+		sub.mx.Lock()
+		sub.query = filter
+		sub.mx.Unlock()
+	}
+}
+
+// writeQueryStream send queued events and pings to the subscriber until done is closed.
+// Connection is closed on write error, so the reader stop too.
+func (s Server) writeQueryStream(sub *subscriber, done <-chan struct{}) {
+	ping := time.NewTicker(streamPingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+
+		case frame := <-sub.send:
+			sub.conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			if err := sub.conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+				sub.conn.Close()
+				return
+			}
+
+		case <-ping.C:
+			sub.conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			if err := sub.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				sub.conn.Close()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/netip"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/proto/crud/genproto/crudpb"
+)
+
+func TestQueryStream(t *testing.T) {
+	filter, err := queryFilterFromProto(&crudpb.QueryLogSubscription{
+		Client:      "10.0.0.0/8",
+		Domain:      "Example.com",
+		Type:        "a",
+		BlockedOnly: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qs := newQueryStream()
+	sub := &subscriber{send: make(chan []byte, 1), query: filter}
+	qs.add(sub)
+
+	entry := database.QueryLogEntry{
+		Client:      netip.MustParseAddr("10.1.2.3"),
+		Name:        "www.example.com.",
+		Type:        "A",
+		BlockReason: "acl",
+	}
+	for _, skipped := range []func(e *database.QueryLogEntry){
+		func(e *database.QueryLogEntry) { e.Client = netip.MustParseAddr("192.0.2.1") },
+		func(e *database.QueryLogEntry) { e.Name = "www.notexample.com." },
+		func(e *database.QueryLogEntry) { e.Type = "AAAA" },
+		func(e *database.QueryLogEntry) { e.BlockReason = "" },
+	} {
+		e := entry
+		skipped(&e)
+		qs.publish(e)
+	}
+	qs.publish(entry)
+	// Queue of the subscriber is full, so the event is dropped instead of blocking.
+	qs.publish(entry)
+
+	if len(sub.send) != 1 || sub.dropped != 1 {
+		t.Fatalf("expected 1 queued and 1 dropped events, got %d and %d", len(sub.send), sub.dropped)
+	}
+	msg := &crudpb.QueryLogEntry{}
+	if err := proto.Unmarshal(<-sub.send, msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.GetName() != "www.example.com." || msg.GetClient() != "10.1.2.3" {
+		t.Fatalf("unexpected event %v", msg)
+	}
+
+	qs.remove(sub)
+	qs.publish(entry)
+	if len(sub.send) != 0 {
+		t.Fatal("expected removed subscriber to get no events")
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/proto/crud/genproto/crudpb"
+)
+
+// recordFromProto convert protobuf message to the normalized resource record.
+// If message contain typed payload, type and data of the record are built from it.
+// *database.ValidationError is returned if the record is invalid.
+func recordFromProto(msg *crudpb.ResourceRecord) (database.ResourceRecord, error) {
+	rr := database.ResourceRecord{
+		ID:     msg.GetId(),
+		Domain: msg.GetDomain(),
+		Data:   msg.GetData(),
+		Type:   msg.GetType(),
+		Class:  msg.GetClass(),
+		TTL:    msg.GetTimeToLive(),
+	}
+	if rr.Class == "" {
+		rr.Class = "IN"
+	}
+
+	if msg.GetPayload() != nil {
+		verr := &database.ValidationError{}
+		payload := payloadRR(msg, verr)
+		if len(verr.Fields) != 0 {
+			return rr, verr
+		}
+
+		rrType := dns.TypeToString[payload.Header().Rrtype]
+		if rr.Type != "" && !strings.EqualFold(rr.Type, rrType) {
+			verr.Add("type", fmt.Sprintf("type %s don't match %s payload", rr.Type, rrType))
+			return rr, verr
+		}
+		rr.Type = rrType
+		rr.Data = strings.TrimPrefix(payload.String(), payload.Header().String())
+	}
+
+	return database.NormalizeRecord(rr)
+}
+
+// payloadRR build resource record with data from the typed payload.
+// Invalid fields of the payload are added to verr.
+func payloadRR(msg *crudpb.ResourceRecord, verr *database.ValidationError) dns.RR {
+	hdr := func(t uint16) dns.RR_Header {
+		return dns.RR_Header{Name: ".", Rrtype: t, Class: dns.ClassINET}
+	}
+	checkUint16 := func(field string, v uint32) uint16 {
+		if v > 0xffff {
+			verr.Add(field, "must be less than 65536")
+		}
+		return uint16(v)
+	}
+	checkName := func(field, name string) string {
+		if _, ok := dns.IsDomainName(name); !ok || name == "" {
+			verr.Add(field, fmt.Sprintf("%q is not a valid domain name", name))
+		}
+		return dns.Fqdn(name)
+	}
+
+	switch p := msg.GetPayload().(type) {
+	case *crudpb.ResourceRecord_A:
+		ip := net.ParseIP(p.A.GetAddress())
+		if ip == nil || ip.To4() == nil {
+			verr.Add("a.address", fmt.Sprintf("%q is not a valid IPv4 address", p.A.GetAddress()))
+		}
+		return &dns.A{Hdr: hdr(dns.TypeA), A: ip}
+	case *crudpb.ResourceRecord_Aaaa:
+		ip := net.ParseIP(p.Aaaa.GetAddress())
+		if ip == nil || ip.To4() != nil {
+			verr.Add("aaaa.address", fmt.Sprintf("%q is not a valid IPv6 address", p.Aaaa.GetAddress()))
+		}
+		return &dns.AAAA{Hdr: hdr(dns.TypeAAAA), AAAA: ip}
+	case *crudpb.ResourceRecord_Mx:
+		return &dns.MX{
+			Hdr:        hdr(dns.TypeMX),
+			Preference: checkUint16("mx.preference", p.Mx.GetPreference()),
+			Mx:         checkName("mx.exchange", p.Mx.GetExchange()),
+		}
+	case *crudpb.ResourceRecord_Srv:
+		return &dns.SRV{
+			Hdr:      hdr(dns.TypeSRV),
+			Priority: checkUint16("srv.priority", p.Srv.GetPriority()),
+			Weight:   checkUint16("srv.weight", p.Srv.GetWeight()),
+			Port:     checkUint16("srv.port", p.Srv.GetPort()),
+			Target:   checkName("srv.target", p.Srv.GetTarget()),
+		}
+	case *crudpb.ResourceRecord_Txt:
+		if len(p.Txt.GetText()) == 0 {
+			verr.Add("txt.text", "must contain at least one string")
+		}
+		for _, txt := range p.Txt.GetText() {
+			if len(txt) > 255 {
+				verr.Add("txt.text", "every string must be shorter than 256 bytes")
+				break
+			}
+		}
+		return &dns.TXT{Hdr: hdr(dns.TypeTXT), Txt: p.Txt.GetText()}
+	case *crudpb.ResourceRecord_Caa:
+		if p.Caa.GetTag() == "" {
+			verr.Add("caa.tag", "must not be empty")
+		}
+		if p.Caa.GetFlag() > 0xff {
+			verr.Add("caa.flag", "must be less than 256")
+		}
+		return &dns.CAA{
+			Hdr:   hdr(dns.TypeCAA),
+			Flag:  uint8(p.Caa.GetFlag()),
+			Tag:   p.Caa.GetTag(),
+			Value: p.Caa.GetValue(),
+		}
+	case *crudpb.ResourceRecord_Cname:
+		return &dns.CNAME{Hdr: hdr(dns.TypeCNAME), Target: checkName("cname.target", p.Cname.GetTarget())}
+	case *crudpb.ResourceRecord_Ns:
+		return &dns.NS{Hdr: hdr(dns.TypeNS), Ns: checkName("ns.host", p.Ns.GetHost())}
+	case *crudpb.ResourceRecord_Ptr:
+		return &dns.PTR{Hdr: hdr(dns.TypePTR), Ptr: checkName("ptr.domain", p.Ptr.GetDomain())}
+	}
+
+	verr.Add("payload", "unknown payload")
+	return nil
+}
+
+// toProtoRecord convert resource record to the protobuf message with typed payload if it is supported for the type.
+func toProtoRecord(rr database.ResourceRecord) *crudpb.ResourceRecord {
+	msg := &crudpb.ResourceRecord{
+		Id:         rr.ID,
+		Domain:     rr.Domain,
+		Data:       rr.Data,
+		Type:       rr.Type,
+		Class:      rr.Class,
+		TimeToLive: rr.TTL,
+	}
+
+	parsed, err := dns.NewRR(fmt.Sprintf(". 0 %s %s %s", rr.Class, rr.Type, rr.Data))
+	if err != nil || parsed == nil {
+		return msg
+	}
+
+	switch v := parsed.(type) {
+	case *dns.A:
+		msg.Payload = &crudpb.ResourceRecord_A{A: &crudpb.AData{Address: v.A.String()}}
+	case *dns.AAAA:
+		msg.Payload = &crudpb.ResourceRecord_Aaaa{Aaaa: &crudpb.AAAAData{Address: v.AAAA.String()}}
+	case *dns.MX:
+		msg.Payload = &crudpb.ResourceRecord_Mx{Mx: &crudpb.MXData{
+			Preference: uint32(v.Preference),
+			Exchange:   v.Mx,
+		}}
+	case *dns.SRV:
+		msg.Payload = &crudpb.ResourceRecord_Srv{Srv: &crudpb.SRVData{
+			Priority: uint32(v.Priority),
+			Weight:   uint32(v.Weight),
+			Port:     uint32(v.Port),
+			Target:   v.Target,
+		}}
+	case *dns.TXT:
+		msg.Payload = &crudpb.ResourceRecord_Txt{Txt: &crudpb.TXTData{Text: v.Txt}}
+	case *dns.CAA:
+		msg.Payload = &crudpb.ResourceRecord_Caa{Caa: &crudpb.CAAData{
+			Flag:  uint32(v.Flag),
+			Tag:   v.Tag,
+			Value: v.Value,
+		}}
+	case *dns.CNAME:
+		msg.Payload = &crudpb.ResourceRecord_Cname{Cname: &crudpb.CNAMEData{Target: v.Target}}
+	case *dns.NS:
+		msg.Payload = &crudpb.ResourceRecord_Ns{Ns: &crudpb.NSData{Host: v.Ns}}
+	case *dns.PTR:
+		msg.Payload = &crudpb.ResourceRecord_Ptr{Ptr: &crudpb.PTRData{Domain: v.Ptr}}
+	}
+	return msg
+}
@@ -0,0 +1,340 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"os"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prionis/dns-server/internal/rrl"
+)
+
+// settings contain options of the server that are changed by Reload.
+type settings struct {
+	dnsAddrs  []string
+	httpAddrs []string
+	reusePort int
+	// socketActivation is used only by Start.
+	socketActivation bool
+	// adminPassword is used only by Start.
+	adminPassword string
+	// clientSubnet is the networks of the resolvers which EDNS Client Subnet option select the view.
+	clientSubnet []netip.Prefix
+	// rrl limit responses sent over UDP, nil if limiting is disabled.
+	rrl               *rrl.Limiter
+	rateLimit         *rrl.Config
+	viewLimits        map[string]rrl.Config
+	aclLimits         map[netip.Prefix]rrl.Config
+	statsRetention    time.Duration
+	queryLogRetention time.Duration
+	jwtSecret         string
+	jwtPrevious       []string
+	accessTokenTTL    time.Duration
+	refreshTokenTTL   time.Duration
+	// oidc is nil if login through the OpenID Connect provider is disabled.
+	oidc *oidcProvider
+}
+
+// newSettings return settings from the options. Limiter of the previous settings is kept
+// if limits are not changed, so limited clients are not released by reload.
+func newSettings(conf options, prev *settings) *settings {
+	set := &settings{
+		dnsAddrs:          conf.dnsAddrs,
+		httpAddrs:         conf.httpAddrs,
+		reusePort:         conf.reusePort,
+		socketActivation:  conf.socketActivation,
+		adminPassword:     conf.adminPassword,
+		clientSubnet:      conf.clientSubnet,
+		rateLimit:         conf.rateLimit,
+		viewLimits:        conf.viewLimits,
+		aclLimits:         conf.aclLimits,
+		statsRetention:    conf.statsRetention,
+		queryLogRetention: conf.queryLogRetention,
+		jwtSecret:         conf.jwtSecret,
+		jwtPrevious:       conf.jwtPrevious,
+		accessTokenTTL:    conf.accessTokenTTL,
+		refreshTokenTTL:   conf.refreshTokenTTL,
+	}
+	if conf.oidc != nil {
+		// Provider is kept if its settings are not changed, so discovery is not repeated.
+		set.oidc = newOIDCProvider(*conf.oidc)
+		if prev != nil && prev.oidc != nil && reflect.DeepEqual(prev.oidc.config, set.oidc.config) {
+			set.oidc = prev.oidc
+		}
+	}
+	switch {
+	case conf.rateLimit == nil && conf.viewLimits == nil && conf.aclLimits == nil:
+	case prev != nil && reflect.DeepEqual(prev.rateLimit, conf.rateLimit) &&
+		reflect.DeepEqual(prev.viewLimits, conf.viewLimits) && reflect.DeepEqual(prev.aclLimits, conf.aclLimits):
+		set.rrl = prev.rrl
+	default:
+		set.rrl = rrl.New(*cmp.Or(conf.rateLimit, &rrl.Config{}), conf.viewLimits, conf.aclLimits)
+	}
+	return set
+}
+
+// current return current settings of the server.
+func (s Server) current() *settings {
+	return s.settings.Load()
+}
+
+// reloader build options of the server from options of NewServer and the reload source.
+type reloader struct {
+	// mx serialize reloads.
+	mx     sync.Mutex
+	opts   []Option
+	source func() ([]Option, error)
+}
+
+// options return default options with options of NewServer and options from the source applied.
+func (r *reloader) options() (options, error) {
+	conf := options{
+		dnsAddrs:  []string{":53"},
+		httpAddrs: []string{":8083"},
+		logger:    slog.Default(),
+		logLevel:  slog.LevelInfo,
+
+		statsRetention:    7 * 24 * time.Hour,
+		queryLogRetention: 7 * 24 * time.Hour,
+		jwtSecret:         os.Getenv("JWT_SECRET"),
+		accessTokenTTL:    15 * time.Minute,
+		refreshTokenTTL:   14 * 24 * time.Hour,
+	}
+	for _, opt := range r.opts {
+		opt.apply(&conf)
+	}
+	if conf.reloadSource == nil {
+		return conf, nil
+	}
+
+	r.source = conf.reloadSource
+	opts, err := r.source()
+	if err != nil {
+		return conf, fmt.Errorf("can't read configuration: %w", err)
+	}
+	for _, opt := range opts {
+		opt.apply(&conf)
+	}
+	return conf, nil
+}
+
+// validateOptions check options that can be changed by Reload.
+func validateOptions(conf options) error {
+	var errs []error
+	if err := validateAddrs("DNS", conf.dnsAddrs, false, conf.socketActivation); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateAddrs("HTTP", conf.httpAddrs, true, conf.socketActivation); err != nil {
+		errs = append(errs, err)
+	}
+	if conf.reusePort > 1 && !reusePortSupported {
+		errs = append(errs, errors.New("SO_REUSEPORT is not supported on this platform"))
+	}
+	if conf.rateLimit != nil {
+		if err := conf.rateLimit.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("incorrect rate limit: %w", err))
+		}
+	}
+	for view, limit := range conf.viewLimits {
+		if err := limit.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("incorrect rate limit of the view %s: %w", view, err))
+		}
+	}
+	for network, limit := range conf.aclLimits {
+		if !network.IsValid() {
+			errs = append(errs, errors.New("network of the ACL rate limit is required"))
+			continue
+		}
+		if err := limit.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("incorrect rate limit of the ACL network %s: %w", network, err))
+		}
+	}
+	for _, rule := range conf.aclRules {
+		if !rule.Network.IsValid() {
+			errs = append(errs, errors.New("ACL rule without network"))
+		}
+	}
+	if conf.statsRetention <= 0 || conf.queryLogRetention <= 0 {
+		errs = append(errs, errors.New("retention must be positive"))
+	}
+	if conf.oidc != nil {
+		if conf.oidc.Issuer == "" || conf.oidc.ClientID == "" || conf.oidc.RedirectURL == "" {
+			errs = append(errs, errors.New("OIDC issuer, client ID and redirect URL are required"))
+		}
+		for _, role := range conf.oidc.Roles {
+			if role.Value == "" || role.Role == "" {
+				errs = append(errs, errors.New("OIDC role mapping without value or role"))
+			}
+		}
+	}
+	if conf.accessTokenTTL <= 0 || conf.refreshTokenTTL < conf.accessTokenTTL {
+		errs = append(errs, errors.New("lifetime of the access token must be positive and not longer than of the refresh token"))
+	}
+	return errors.Join(errs...)
+}
+
+// Reload read configuration from the reload source and apply it without restart.
+// New configuration is validated and new listeners are bound first, so nothing is changed
+// if any of it fail. Listeners with unchanged addresses, the index and the rate limiter
+// state are kept. ACL rules are also reloaded from the database.
+func (s Server) Reload(ctx context.Context) error {
+	s.reloader.mx.Lock()
+	defer s.reloader.mx.Unlock()
+
+	conf, err := s.reloader.options()
+	if err != nil {
+		return err
+	}
+	if err := validateOptions(conf); err != nil {
+		return fmt.Errorf("incorrect configuration: %w", err)
+	}
+	if err := s.rebind(conf); err != nil {
+		return err
+	}
+
+	// Nothing fail after this point, so configuration is applied completely.
+	s.settings.Store(newSettings(conf, s.current()))
+	s.acl.SetStatic(conf.aclRules)
+	if conf.levelVar != nil {
+		conf.levelVar.Set(conf.logLevel)
+	}
+	if err := s.loadACL(ctx); err != nil {
+		s.logger.Error(err.Error())
+	}
+	s.logger.Info("configuration reloaded")
+	return nil
+}
+
+// rebind bind listeners on the added addresses and stop listeners of the removed ones,
+// listeners of the kept addresses are carried over. When the number of UDP sockets is changed,
+// only UDP sockets are rebound on the same ports, TCP ones are kept. New listeners are started
+// before old ones are stopped, so queries are answered all the time. Sockets passed by systemd are kept.
+func (s Server) rebind(conf options) error {
+	// Reloads are serialized, so listeners may be changed meanwhile only by Shutdown.
+	old := s.run.current()
+	if old == nil {
+		return nil
+	}
+
+	l := *old
+	started := &listeners{httpServer: old.httpServer}
+	stopped := &listeners{}
+	fail := func(err error) error {
+		s.closeListeners(started)
+		return err
+	}
+	if !old.dnsActivated {
+		handler := s.dnsMux()
+		workersChanged := max(old.dnsWorkers, 1) != max(conf.reusePort, 1)
+		l.dnsWorkers, l.dnsBound = conf.reusePort, nil
+		for _, addr := range conf.dnsAddrs {
+			i := slices.IndexFunc(old.dnsBound, func(b dnsBinding) bool { return b.addr == addr })
+			switch {
+			case i < 0:
+				b, err := bindDNS(addr, conf.reusePort, handler)
+				if err != nil {
+					return fail(err)
+				}
+				started.dnsServers = append(started.dnsServers, b.servers...)
+				l.dnsBound = append(l.dnsBound, b)
+			case workersChanged:
+				b, udpServers, err := rebindUDP(old.dnsBound[i], conf.reusePort, handler)
+				if err != nil {
+					return fail(err)
+				}
+				started.dnsServers = append(started.dnsServers, udpServers...)
+				for _, dnsServer := range old.dnsBound[i].servers {
+					if dnsServer.Net == "udp" {
+						stopped.dnsServers = append(stopped.dnsServers, dnsServer)
+					}
+				}
+				l.dnsBound = append(l.dnsBound, b)
+			default:
+				l.dnsBound = append(l.dnsBound, old.dnsBound[i])
+			}
+		}
+		for _, b := range old.dnsBound {
+			if !slices.Contains(conf.dnsAddrs, b.addr) {
+				stopped.dnsServers = append(stopped.dnsServers, b.servers...)
+			}
+		}
+	}
+	if !old.httpActivated {
+		l.httpBound = nil
+		for _, addr := range conf.httpAddrs {
+			i := slices.IndexFunc(old.httpBound, func(b httpBinding) bool { return b.addr == addr })
+			if i >= 0 {
+				l.httpBound = append(l.httpBound, old.httpBound[i])
+				continue
+			}
+			b, err := bindHTTP(addr)
+			if err != nil {
+				return fail(err)
+			}
+			started.httpListeners = append(started.httpListeners, b.listeners...)
+			l.httpBound = append(l.httpBound, b)
+		}
+		for _, b := range old.httpBound {
+			if !slices.Contains(conf.httpAddrs, b.addr) {
+				stopped.httpListeners = append(stopped.httpListeners, b.listeners...)
+			}
+		}
+	}
+	l.collect()
+	if len(started.dnsServers) == 0 && len(started.httpListeners) == 0 &&
+		len(stopped.dnsServers) == 0 && len(stopped.httpListeners) == 0 {
+		return nil
+	}
+
+	if err := s.serve(started); err != nil {
+		return err
+	}
+	s.run.mx.Lock()
+	if s.run.listeners != old {
+		s.run.mx.Unlock()
+		s.stopListeners(context.Background(), &listeners{dnsServers: started.dnsServers})
+		closeHTTPListeners(started.httpListeners)
+		return errors.New("server is shut down")
+	}
+	s.run.listeners = &l
+	s.run.mx.Unlock()
+
+	// Reload may be requested through the removed HTTP listener, its connections
+	// are served until they are closed, so old listeners are stopped in the background.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), reloadDrainTimeout)
+		defer cancel()
+		for _, err := range s.stopListeners(ctx, stopped) {
+			s.logger.Error(err.Error())
+		}
+		closeHTTPListeners(stopped.httpListeners)
+		// New listeners may have the same names as the stopped ones.
+		for _, dnsServer := range l.dnsServers {
+			s.health.setListener(listenerName(dnsServer), nil)
+		}
+	}()
+	return nil
+}
+
+// reloadDrainTimeout limit waiting for in-flight requests of the replaced listeners.
+const reloadDrainTimeout = 10 * time.Second
+
+// reloadHandler reload configuration of the server.
+func (s Server) reloadHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.Reload(r.Context()); err != nil {
+		s.logger.Error("can't reload configuration: " + err.Error())
+		http.Error(w, "Can't reload configuration: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Configuration reloaded"))
+	s.logger.Info("POST reload configuration")
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/proto/crud/genproto/crudpb"
+)
+
+// toProtoRole convert role to the protobuf message.
+func toProtoRole(role database.Role) *crudpb.Role {
+	return &crudpb.Role{
+		Id:          role.ID,
+		Name:        role.Name,
+		Permissions: role.Permissions,
+	}
+}
+
+// writeRoleError write error of the role change to the response.
+// Invalid fields are returned in the ValidationErrors message.
+func (s Server) writeRoleError(w http.ResponseWriter, msg string, err error) {
+	s.logger.Error(msg + err.Error())
+
+	var verr *database.ValidationError
+	if errors.As(err, &verr) {
+		writeValidationErrors(w, verr)
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Role not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, database.ErrRoleInUse) {
+		http.Error(w, "Role is assigned to users or API keys", http.StatusConflict)
+		return
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+		http.Error(w, "Role with this name already exist", http.StatusConflict)
+		return
+	}
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
+
+// getRolesHandler handle requests for all roles with their permissions.
+func (s Server) getRolesHandler(w http.ResponseWriter, r *http.Request) {
+	roles, err := s.db.GetRoles(r.Context())
+	if err != nil {
+		s.logger.Error("can't get roles: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	collection := &crudpb.RoleCollection{}
+	for _, role := range roles {
+		collection.Roles = append(collection.Roles, toProtoRole(role))
+	}
+
+	resp, err := proto.Marshal(collection)
+	if err != nil {
+		s.logger.Error("can't marshal roles: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("GET roles, returned %d roles", len(roles)))
+}
+
+// postRoleHandler handle requests for creating of the role.
+func (s Server) postRoleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/protobuf" {
+		s.logger.Error("Content-Type header is set to " + r.Header.Get("Content-Type"))
+		http.Error(w, "Accept only application/protobuf Content-Type", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Error("can't read request body from " + r.RemoteAddr)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	msg := &crudpb.Role{}
+	if err := proto.Unmarshal(body, msg); err != nil {
+		s.logger.Error("can't unmarshal body from " + r.RemoteAddr)
+		http.Error(w, "Incorrect message format", http.StatusBadRequest)
+		return
+	}
+
+	role, err := database.NormalizeRole(database.Role{Name: msg.GetName(), Permissions: msg.GetPermissions()})
+	if err != nil {
+		s.writeRoleError(w, "can't add role: ", err)
+		return
+	}
+	role.ID, err = s.db.AddRole(r.Context(), role)
+	if err != nil {
+		s.writeRoleError(w, "can't add role: ", err)
+		return
+	}
+
+	resp, err := proto.Marshal(toProtoRole(role))
+	if err != nil {
+		s.logger.Error("can't marshal role: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("POST role %s with permissions %v", role.Name, role.Permissions))
+}
+
+// patchRoleHandler handle requests for replacing name and permissions of the role.
+// Role can't lose users:admin permission while it is the role of the user that make the request,
+// so admins don't lock themselves out.
+func (s Server) patchRoleHandler(w http.ResponseWriter, r *http.Request) {
+	pathID := r.PathValue("id")
+	id, err := strconv.ParseInt(pathID, 10, 32)
+	if err != nil {
+		s.logger.Error("can't parse id(" + pathID + "): " + err.Error())
+		http.Error(w, "Incorrect id", http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/protobuf" {
+		s.logger.Error("Content-Type header is set to " + r.Header.Get("Content-Type"))
+		http.Error(w, "Accept only application/protobuf Content-Type", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Error("can't read request body from " + r.RemoteAddr)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	msg := &crudpb.Role{}
+	if err := proto.Unmarshal(body, msg); err != nil {
+		s.logger.Error("can't unmarshal body from " + r.RemoteAddr)
+		http.Error(w, "Incorrect message format", http.StatusBadRequest)
+		return
+	}
+
+	role, err := database.NormalizeRole(database.Role{
+		ID:          int32(id),
+		Name:        msg.GetName(),
+		Permissions: msg.GetPermissions(),
+	})
+	if err != nil {
+		s.writeRoleError(w, "can't update role: ", err)
+		return
+	}
+
+	old, err := s.db.GetRole(r.Context(), role.ID)
+	if err != nil {
+		s.writeRoleError(w, "can't update role: ", err)
+		return
+	}
+	user, _ := r.Context().Value("user").(database.User)
+	if old.Name == user.Role && !slices.Contains(role.Permissions, database.PermUsersAdmin) {
+		s.logger.Error("user " + user.Login + " tried to remove users:admin from own role")
+		http.Error(w, "Can't remove users:admin permission from own role", http.StatusConflict)
+		return
+	}
+
+	if err := s.db.UpdateRole(r.Context(), role); err != nil {
+		s.writeRoleError(w, "can't update role: ", err)
+		return
+	}
+
+	resp, err := proto.Marshal(toProtoRole(role))
+	if err != nil {
+		s.logger.Error("can't marshal role: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+	s.logger.Info(fmt.Sprintf("PATCH role %d, now %s with permissions %v", role.ID, role.Name, role.Permissions))
+}
+
+// deleteRoleHandler handle requests for deleting of the role which is not assigned to anyone.
+func (s Server) deleteRoleHandler(w http.ResponseWriter, r *http.Request) {
+	pathID := r.PathValue("id")
+	id, err := strconv.ParseInt(pathID, 10, 32)
+	if err != nil {
+		s.logger.Error("can't parse id(" + pathID + "): " + err.Error())
+		http.Error(w, "Incorrect id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.DeleteRole(r.Context(), int32(id)); err != nil {
+		s.writeRoleError(w, "can't delete role: ", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	s.logger.Info("DELETE role " + pathID)
+}
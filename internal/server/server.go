@@ -2,138 +2,540 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
+	"io"
+	"net"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/miekg/dns"
 
+	"github.com/prionis/dns-server/internal/acl"
 	"github.com/prionis/dns-server/internal/database"
-)
-
-var (
-	adminRights = []string{"admin"}
-	userRights  = []string{"user", "admin"}
+	"github.com/prionis/dns-server/internal/index"
+	"github.com/prionis/dns-server/internal/metrics"
+	"github.com/prionis/dns-server/internal/stats"
 )
 
 type Server struct {
-	dnsPort  string
-	httpPort string
-	logger   Logger
-	db       database.Repository
+	logger Logger
+	db     database.Repository
+	// index contain all resource records from the database for answering DNS questions.
+	index *index.Index
+	// acl decide which requests of the DNS clients are allowed.
+	acl *acl.List
+	// settings contain options that are changed by Reload.
+	settings *atomic.Pointer[settings]
+	reloader *reloader
+	// stats count answered queries until they are saved to the database.
+	stats *stats.Collector
+	// queryLog queue answered queries until they are saved to the database.
+	queryLog chan database.QueryLogEntry
+	// queryStream send answered queries to the live stream subscribers.
+	queryStream *queryStream
+	metrics     *metrics.Metrics
+	health      *health
+	// logStream send the application log to the WebSocket clients, may be nil.
+	logStream *WebSocket
+	// run contain listeners and workers after Start.
+	run *runtime
+	// stderr receive the generated password of the admin, it must not be shared with the log.
+	stderr io.Writer
 }
 
 func NewServer(opts ...Option) (Server, error) {
-	s := Server{}
-
-	conf := options{
-		dnsPort:  ":53",
-		httpPort: ":8083",
-		logger:   slog.Default(),
+	r := &reloader{opts: opts}
+	conf, err := r.options()
+	if err != nil {
+		return Server{}, err
 	}
-	for _, opt := range opts {
-		opt.apply(&conf)
+	if err := validateOptions(conf); err != nil {
+		return Server{}, err
 	}
 
-	s = Server{
-		dnsPort:  conf.dnsPort,
-		httpPort: ":8083",
-		db:       conf.db,
-		logger:   conf.logger,
+	s := Server{
+		db:     conf.db,
+		logger: conf.logger,
+		index:  index.New(),
+		acl:    acl.New(conf.aclRules),
+
+		settings: &atomic.Pointer[settings]{},
+		reloader: r,
+
+		stats:       stats.New(),
+		queryLog:    make(chan database.QueryLogEntry, queryLogBuffer),
+		queryStream: newQueryStream(),
+		metrics:     conf.metrics,
+		health:      newHealth(),
+		logStream:   conf.logStream,
+		run:         &runtime{},
+		stderr:      os.Stderr,
+	}
+	s.settings.Store(newSettings(conf, nil))
+	if conf.levelVar != nil {
+		conf.levelVar.Set(conf.logLevel)
 	}
+	if s.metrics == nil {
+		s.metrics = metrics.New()
+	}
+	s.registerMetrics()
 	return s, nil
 }
 
-func (s Server) Start(ws *WebSocket) error {
-	_, err := s.db.GetUser(context.Background(), "admin")
-	if err != nil {
-		user, err := s.db.AddUser(context.Background(), database.User{
-			Login:     "admin",
-			FirstName: "John",
-			LastName:  "Doe",
-			Role:      "admin",
-		}, "admin")
+// Start bind DNS and HTTP listeners and start serving in the background.
+// Error is returned if any listener can't be bound, listeners that were bound are closed then.
+// Listener failures after the start are sent to Errors. ctx is used only for the start,
+// the server is stopped by Shutdown, which stop background workers after the listeners
+// are drained, so the queries answered during the drain are saved.
+func (s Server) Start(ctx context.Context) error {
+	if err := s.bootstrap(ctx); err != nil {
+		return err
+	}
+
+	workerCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	s.run.cancel = cancel
+	stopWorkers := func() {
+		cancel()
+		s.run.workers.Wait()
+	}
+
+	// Index and ACL are loaded by watchChanges once the changes are listened.
+	loaded := make(chan error, 1)
+	s.run.workers.Add(1)
+	go func() {
+		defer s.run.workers.Done()
+		s.watchChanges(workerCtx, loaded)
+	}()
+	select {
+	case err := <-loaded:
 		if err != nil {
-			s.logger.Error(fmt.Sprintf("Can't create new user: %s", err.Error()))
-		} else {
-			s.logger.Info(fmt.Sprintf("New admin was created: %v", user))
+			stopWorkers()
+			return err
 		}
-	} else {
-		s.logger.Info("admin user exists")
+	case <-ctx.Done():
+		stopWorkers()
+		return ctx.Err()
+	}
+	s.health.indexLoaded.Store(true)
+
+	conf := s.current()
+	l := &listeners{}
+	if conf.socketActivation {
+		if err := s.listenActivated(l); err != nil {
+			stopWorkers()
+			return err
+		}
+	}
+	if !l.dnsActivated {
+		if err := s.listenDNS(l, conf.dnsAddrs, conf.reusePort); err != nil {
+			s.closeListeners(l)
+			stopWorkers()
+			return err
+		}
+	}
+	if !l.httpActivated {
+		if err := s.listenHTTP(l, conf.httpAddrs); err != nil {
+			s.closeListeners(l)
+			stopWorkers()
+			return err
+		}
+	}
+
+	s.run.mx.Lock()
+	s.run.errors = make(chan error, len(l.dnsServers)+len(l.httpListeners))
+	s.run.mx.Unlock()
+
+	for _, worker := range []func(context.Context){s.saveStats, s.saveQueryLog, s.cleanupTokens} {
+		s.run.workers.Add(1)
+		go func() {
+			defer s.run.workers.Done()
+			worker(workerCtx)
+		}()
+	}
+
+	if err := s.serve(l); err != nil {
+		stopWorkers()
+		return err
+	}
+	s.run.mx.Lock()
+	s.run.listeners = l
+	s.run.mx.Unlock()
+	return nil
+}
+
+// Errors return channel of the listener failures after the start.
+// Server should be shut down when the error is received.
+func (s Server) Errors() <-chan error {
+	s.run.mx.Lock()
+	defer s.run.mx.Unlock()
+	return s.run.errors
+}
+
+// Shutdown stop the server: stop accepting new requests and wait for in-flight DNS
+// and HTTP requests, close WebSocket clients, stop background workers with the final flush
+// of the statistics and the query log, and close the database.
+// If ctx is done before, the remaining requests are interrupted and ctx error is returned.
+func (s Server) Shutdown(ctx context.Context) error {
+	s.run.mx.Lock()
+	l := s.run.listeners
+	s.run.listeners = nil
+	s.run.mx.Unlock()
+
+	var errs []error
+	if l != nil {
+		errs = s.stopListeners(ctx, l)
+	}
+	// Hijacked WebSocket connections are not tracked by the HTTP server.
+	s.queryStream.close()
+	if s.logStream != nil {
+		s.logStream.Close()
+	}
+
+	if s.run.cancel != nil {
+		s.run.cancel()
+	}
+	workersDone := make(chan struct{})
+	go func() {
+		s.run.workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("background workers are not stopped: %w", ctx.Err()))
+	}
+
+	s.db.Close()
+	s.logger.Info("server is stopped")
+	return errors.Join(errs...)
+}
+
+// runtime contain listeners and workers of the started server.
+type runtime struct {
+	mx        sync.Mutex
+	listeners *listeners
+	// cancel stop background workers.
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+	// errors receive listener failures, it is sized by the number of listeners bound
+	// by Start. Listeners never block on it, failures of the listeners bound on reload
+	// are dropped when it is full, the first failure is enough to shut down.
+	errors chan error
+}
+
+// listeners is the set of bound DNS and HTTP listeners.
+type listeners struct {
+	// dnsWorkers is the number of UDP sockets of every DNS address.
+	dnsWorkers int
+	// dnsActivated is set when DNS sockets are passed by systemd, they are kept on reload.
+	dnsActivated bool
+	// dnsBound contain DNS servers of every configured address, they are kept
+	// on reload while the address is configured.
+	dnsBound   []dnsBinding
+	dnsServers []*dns.Server
+	// httpActivated is set when HTTP sockets are passed by systemd, they are kept on reload.
+	httpActivated bool
+	// httpServer serve all HTTP listeners, it is kept on reload.
+	httpServer    *http.Server
+	httpBound     []httpBinding
+	httpListeners []net.Listener
+}
+
+// dnsBinding is the DNS servers bound on the configured address.
+type dnsBinding struct {
+	addr    string
+	servers []*dns.Server
+}
+
+// httpBinding is the HTTP listeners bound on the configured address.
+type httpBinding struct {
+	addr      string
+	listeners []net.Listener
+}
+
+// collect set servers and listeners of the bound addresses, sockets passed by systemd are kept.
+func (l *listeners) collect() {
+	if !l.dnsActivated {
+		l.dnsServers = nil
+		for _, b := range l.dnsBound {
+			l.dnsServers = append(l.dnsServers, b.servers...)
+		}
+	}
+	if !l.httpActivated {
+		l.httpListeners = nil
+		for _, b := range l.httpBound {
+			l.httpListeners = append(l.httpListeners, b.listeners...)
+		}
+	}
+}
+
+// fail report listener failure. Failure is dropped if previous ones are not received yet,
+// they are logged anyway.
+func (r *runtime) fail(err error) {
+	select {
+	case r.errors <- err:
+	default:
 	}
+}
 
-	dns.HandleFunc(".", s.dnsHandler)
+// current return listeners of the started server, nil if the server is not started.
+func (r *runtime) current() *listeners {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.listeners
+}
 
-	go s.serveDNS("udp")
-	go s.serveDNS("tcp")
+// serve start serving on the bound listeners and wait until DNS servers are started,
+// because shutdown of the DNS server fail if it is not started yet.
+// Listeners are stopped if any of them fail to start, HTTP server is not shut down,
+// because it may serve listeners of the running server on reload.
+func (s Server) serve(l *listeners) error {
+	started := make(chan struct{}, len(l.dnsServers))
+	names := make([]string, 0, len(l.dnsServers))
+	for _, dnsServer := range l.dnsServers {
+		name := listenerName(dnsServer)
+		names = append(names, name)
+		dnsServer.NotifyStartedFunc = func() {
+			s.health.setListener(name, nil)
+			started <- struct{}{}
+		}
+		go s.serveDNS(dnsServer, name)
+	}
+	for _, ln := range l.httpListeners {
+		go s.serveHTTP(l.httpServer, ln)
+	}
 
-	go s.serveHTTP(ws)
+	for range l.dnsServers {
+		select {
+		case <-started:
+		case err := <-s.run.errors:
+			s.stopListeners(context.Background(), &listeners{dnsServers: l.dnsServers})
+			s.closeListeners(l)
+			return err
+		}
+	}
+	if len(l.dnsServers) != 0 {
+		s.logger.Info("server listen DNS requests on " + strings.Join(names, ", "))
+	}
+	return nil
+}
 
-	s.logger.Info("server listen DNS requests on " + s.dnsPort)
-	signals := make(chan os.Signal)
-	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
-	sig := <-signals
-	s.logger.Info("Signal(" + sig.String() + ") recived, terminating")
+// stopListeners stop accepting requests and wait for in-flight requests.
+func (s Server) stopListeners(ctx context.Context, l *listeners) []error {
+	var errs []error
+	for _, dnsServer := range l.dnsServers {
+		name := listenerName(dnsServer)
+		if err := dnsServer.ShutdownContext(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("can't shut down DNS listener on %s: %w", name, err))
+		}
+		s.health.removeListener(name)
+	}
+	if l.httpServer != nil {
+		if err := l.httpServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("can't shut down HTTP listener: %w", err))
+		}
+	}
+	return errs
+}
+
+// closeListeners close sockets of the listeners that are not started.
+func (s Server) closeListeners(l *listeners) {
+	closeDNSServers(l.dnsServers)
+	closeHTTPListeners(l.httpListeners)
+}
+
+// bootstrap create the admin on the first start, when there are no users. Password is taken
+// from the options or generated, generated password must be changed on the first login.
+func (s Server) bootstrap(ctx context.Context) error {
+	count, err := s.db.CountUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("can't count users: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	password := s.current().adminPassword
+	generated := password == ""
+	if generated {
+		if password, err = randomToken(); err != nil {
+			return err
+		}
+	}
+	_, err = s.db.AddUser(ctx, database.User{
+		Login:              "admin",
+		FirstName:          "John",
+		LastName:           "Doe",
+		Role:               "admin",
+		MustChangePassword: generated,
+	}, password)
+	if err != nil {
+		return fmt.Errorf("can't create admin: %w", err)
+	}
+
+	if generated {
+		// Password is written only once and never to the log, which is readable by the API users.
+		fmt.Fprintf(s.stderr, "Password of the admin is %s, change it on the first login\n", password)
+		s.logger.Info("First start, admin is created with the generated password written to stderr")
+	} else {
+		s.logger.Info("First start, admin is created with the configured password")
+	}
 	return nil
 }
 
-func (s Server) serveHTTP(ws *WebSocket) {
+// router return handler of the HTTP API.
+func (s Server) router() http.Handler {
 	router := chi.NewRouter()
 	router.Use(s.loggerMiddleware())
+	router.Use(s.metricsMiddleware)
+	router.Use(s.tracingMiddleware)
+
+	router.Handle("/metrics", s.metrics.Handler())
+	router.Get("/healthz", s.healthzHandler)
+	router.Get("/readyz", s.readyzHandler)
 
 	router.Route("/auth", func(r chi.Router) {
 		r.Use(s.timeoutMiddleware(10 * time.Second))
 		r.Post("/login", s.loginHandler)
+		r.Post("/refresh", s.refreshHandler)
+		r.Post("/logout", s.logoutHandler)
 		r.Post("/register", s.registerHandler)
+		r.Get("/oidc/login", s.oidcLoginHandler)
+		r.Get("/oidc/callback", s.oidcCallbackHandler)
 	})
 
 	router.Route("/api", func(r chi.Router) {
 		r.Use(s.authenticationMiddleware)
+		// Password is changed without permissions, it is the only request allowed
+		// to users with temporary password.
+		r.Post("/password", s.changePasswordHandler)
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(s.authorizationMiddleware(database.PermSettingsAdmin))
+			r.Post("/reload", s.reloadHandler)
+		})
 
 		r.Route("/users", func(r chi.Router) {
-			r.Use(s.authorizationMiddleware(adminRights))
+			r.Use(s.authorizationMiddleware(database.PermUsersAdmin))
 			r.Get("/all", s.getAllUsersHandler)
+			r.Post("/", s.postUserHandler)
 			r.Get("/{id}", s.getUserHandler)
 			r.Delete("/", s.deleteUserHandler)
 			r.Patch("/", s.patchUserHandler)
 		})
 
+		r.Route("/roles", func(r chi.Router) {
+			r.Use(s.authorizationMiddleware(database.PermUsersAdmin))
+			r.Get("/", s.getRolesHandler)
+			r.Post("/", s.postRoleHandler)
+			r.Patch("/{id}", s.patchRoleHandler)
+			r.Delete("/{id}", s.deleteRoleHandler)
+		})
+
+		r.Route("/invitations", func(r chi.Router) {
+			r.Use(s.authorizationMiddleware(database.PermUsersAdmin))
+			r.Get("/", s.getInvitationsHandler)
+			r.Post("/", s.postInvitationHandler)
+			r.Delete("/{id}", s.deleteInvitationHandler)
+		})
+
+		r.Route("/keys", func(r chi.Router) {
+			r.Use(s.authorizationMiddleware(database.PermUsersAdmin))
+			r.Get("/", s.getAPIKeysHandler)
+			r.Post("/", s.postAPIKeyHandler)
+			r.Delete("/{id}", s.deleteAPIKeyHandler)
+		})
+
 		r.Route("/rrs", func(r chi.Router) {
-			r.Use(s.authorizationMiddleware(userRights))
-			r.Get("/all", s.getAllRecordsHandler)
-			r.Get("/{id}", s.getRecordHandler)
-			r.Delete("/{id}", s.deleteRRHandler)
-			r.Post("/", s.postRRHandler)
-			r.Patch("/{id}", s.patchRRHandler)
+			r.With(s.authorizationMiddleware(database.PermRecordsRead)).Group(func(r chi.Router) {
+				r.Get("/", s.searchRecordsHandler)
+				r.Get("/all", s.getAllRecordsHandler)
+				r.Get("/{id}", s.getRecordHandler)
+				r.Get("/{id}/history", s.getRecordHistoryHandler)
+			})
+			r.With(s.authorizationMiddleware(database.PermRecordsWrite)).Group(func(r chi.Router) {
+				r.Delete("/{id}", s.deleteRRHandler)
+				r.Post("/", s.postRRHandler)
+				r.Post("/batch", s.batchRRHandler)
+				r.Patch("/{id}", s.patchRRHandler)
+				r.Post("/history/{changeID}/revert", s.revertChangeHandler)
+			})
+		})
+
+		r.Route("/zones", func(r chi.Router) {
+			r.With(s.authorizationMiddleware(database.PermRecordsRead)).
+				Get("/{zone}/changelog", s.getZoneChangelogHandler)
+			r.With(s.authorizationMiddleware(database.PermZonesAdmin)).
+				Post("/{zone}/rollback", s.rollbackZoneHandler)
+		})
+
+		r.Route("/views", func(r chi.Router) {
+			r.Use(s.authorizationMiddleware(database.PermZonesAdmin))
+			r.Get("/", s.getAllViewsHandler)
+			r.Get("/{id}", s.getViewHandler)
+			r.Post("/", s.postViewHandler)
+			r.Patch("/{id}", s.patchViewHandler)
+			r.Delete("/{id}", s.deleteViewHandler)
+		})
+
+		r.Route("/acl", func(r chi.Router) {
+			r.Use(s.authorizationMiddleware(database.PermSettingsAdmin))
+			r.Get("/", s.getACLRulesHandler)
+			r.Post("/", s.postACLRuleHandler)
+			r.Patch("/{id}", s.patchACLRuleHandler)
+			r.Delete("/{id}", s.deleteACLRuleHandler)
+		})
+
+		r.Route("/stats", func(r chi.Router) {
+			r.Use(s.authorizationMiddleware(database.PermLogsRead))
+			r.Get("/top-clients", s.statTopHandler("clients", s.db.GetTopClients))
+			r.Get("/top-domains", s.statTopHandler("domains", s.db.GetTopDomains))
+			r.Get("/top-blocked", s.statTopHandler("blocked domains", s.db.GetTopBlocked))
+			r.Get("/volume", s.queryVolumeHandler)
+		})
+
+		r.Route("/querylog", func(r chi.Router) {
+			r.Use(s.authorizationMiddleware(database.PermLogsRead))
+			r.Get("/", s.searchQueryLogHandler)
+			r.HandleFunc("/ws", s.queryStreamHandler)
 		})
 
 		r.Route("/logs", func(r chi.Router) {
-			r.Use(s.authorizationMiddleware(userRights))
+			r.Use(s.authorizationMiddleware(database.PermLogsRead))
 			r.HandleFunc("/all", s.getAllLogsHandler)
-			r.HandleFunc("/ws", s.websocketHandler(ws))
+			if s.logStream != nil {
+				r.HandleFunc("/ws", s.websocketHandler(s.logStream))
+			}
 		})
 	})
 
-	server := http.Server{
-		Addr:         s.httpPort,
-		Handler:      router,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-	}
+	return router
+}
 
-	s.logger.Info("server listen HTTP requests on " + s.httpPort)
-	server.ListenAndServe()
+// serveHTTP serve HTTP API on the listener until the server is shut down.
+func (s Server) serveHTTP(httpServer *http.Server, ln net.Listener) {
+	s.logger.Info("server listen HTTP requests on " + ln.Addr().String())
+	err := httpServer.Serve(ln)
+	// Listener is closed when its address is removed on reload.
+	if errors.Is(err, http.ErrServerClosed) || errors.Is(err, net.ErrClosed) {
+		return
+	}
+	s.logger.Error("HTTP listener failed: " + err.Error())
+	s.run.fail(fmt.Errorf("HTTP listener on %s failed: %w", ln.Addr().String(), err))
 }
 
-func (s Server) serveDNS(net string) {
-	dnsServer := dns.Server{
-		Net:  net,
-		Addr: s.dnsPort,
+// serveDNS serve DNS queries until the server is shut down.
+func (s Server) serveDNS(dnsServer *dns.Server, name string) {
+	// Error is not returned when the listener is stopped by Shutdown or Reload.
+	if err := dnsServer.ActivateAndServe(); err != nil {
+		s.health.setListener(name, err)
+		s.logger.Error(fmt.Sprintf("DNS listener on %s failed: %s", name, err.Error()))
+		s.run.fail(fmt.Errorf("DNS listener on %s failed: %w", name, err))
 	}
-	dnsServer.ListenAndServe()
 }
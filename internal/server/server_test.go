@@ -0,0 +1,353 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/internal/rrl"
+)
+
+// lifecycleRepository is the repository with methods used by Start and Shutdown.
+type lifecycleRepository struct {
+	database.Repository
+	mx       sync.Mutex
+	queryLog []database.QueryLogEntry
+	closed   bool
+}
+
+func (r *lifecycleRepository) CountUsers(context.Context) (int64, error) {
+	return 1, nil
+}
+
+func (r *lifecycleRepository) GetAllRecords(context.Context) ([]database.ResourceRecord, error) {
+	return []database.ResourceRecord{
+		{ID: 1, Domain: "example.com.", Type: "A", Class: "IN", Data: "10.0.0.1", TTL: 60},
+	}, nil
+}
+
+func (r *lifecycleRepository) GetAllViews(context.Context) ([]database.View, error) {
+	return nil, nil
+}
+
+func (r *lifecycleRepository) GetACLRules(context.Context) ([]database.ACLRule, error) {
+	return nil, nil
+}
+
+func (r *lifecycleRepository) Listen(ctx context.Context, ready func() error, _ func(database.Change)) error {
+	if err := ready(); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (r *lifecycleRepository) AddQueryStats(context.Context, []database.QueryStat) error {
+	return nil
+}
+
+func (r *lifecycleRepository) AddQueryLog(_ context.Context, entries []database.QueryLogEntry) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.queryLog = append(r.queryLog, entries...)
+	return nil
+}
+
+func (r *lifecycleRepository) Close() {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.closed = true
+}
+
+func TestStartShutdown(t *testing.T) {
+	db := &lifecycleRepository{}
+	s := newTestServer(t, nil, WithDB(db), SetDNSPort("127.0.0.1:0"), SetHTTPPort("127.0.0.1:0"))
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	addr := s.run.current().dnsServers[0].PacketConn.LocalAddr().String()
+	m, err := dns.Exchange(new(dns.Msg).SetQuestion("example.com.", dns.TypeA), addr)
+	if err != nil || len(m.Answer) != 1 {
+		t.Fatalf("expected answer from the started server, got %v, %v", m, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	db.mx.Lock()
+	defer db.mx.Unlock()
+	if len(db.queryLog) != 1 || !db.closed {
+		t.Fatalf("expected query log to be flushed and database to be closed, got %d entries, closed %v",
+			len(db.queryLog), db.closed)
+	}
+	if _, err := dns.Exchange(new(dns.Msg).SetQuestion("example.com.", dns.TypeA), addr); err == nil {
+		t.Fatal("expected no answer after shutdown")
+	}
+}
+
+func TestStartContextDone(t *testing.T) {
+	db := &lifecycleRepository{}
+	s := newTestServer(t, nil, WithDB(db), SetDNSPort("127.0.0.1:0"), SetHTTPPort("127.0.0.1:0"))
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := s.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Signal cancel the context of the start, queries are answered until the shutdown.
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	addr := s.run.current().dnsServers[0].PacketConn.LocalAddr().String()
+	m, err := dns.Exchange(new(dns.Msg).SetQuestion("example.com.", dns.TypeA), addr)
+	if err != nil || len(m.Answer) != 1 {
+		t.Fatalf("expected answer until the shutdown, got %v, %v", m, err)
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	db.mx.Lock()
+	defer db.mx.Unlock()
+	if len(db.queryLog) != 1 {
+		t.Fatalf("expected query answered after the context is done to be saved, got %d entries", len(db.queryLog))
+	}
+}
+
+// changesRepository is the lifecycle repository where a record is written by another
+// instance right before every LISTEN, so notification of it is never received.
+// The first connection is lost after the start.
+type changesRepository struct {
+	*lifecycleRepository
+	records []database.ResourceRecord
+	listens int
+}
+
+func (r *changesRepository) GetAllRecords(context.Context) ([]database.ResourceRecord, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return slices.Clone(r.records), nil
+}
+
+func (r *changesRepository) Listen(ctx context.Context, ready func() error, _ func(database.Change)) error {
+	r.mx.Lock()
+	r.listens++
+	n := r.listens
+	r.records = append(r.records, database.ResourceRecord{
+		ID: int32(n), Domain: fmt.Sprintf("host%d.example.com.", n), Type: "A", Class: "IN", Data: "10.0.0.1", TTL: 60,
+	})
+	r.mx.Unlock()
+
+	if err := ready(); err != nil {
+		return err
+	}
+	if n == 1 {
+		return errors.New("connection lost")
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestStartLoadAfterListen(t *testing.T) {
+	db := &changesRepository{lifecycleRepository: &lifecycleRepository{}}
+	s := newTestServer(t, nil, WithDB(db), SetDNSPort("127.0.0.1:0"), SetHTTPPort("127.0.0.1:0"))
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+
+	if len(s.index.Find("host1.example.com.", "A", 0)) != 1 {
+		t.Fatal("expected record written before the changes are listened to be loaded")
+	}
+	// Index is loaded again after reconnect, once the changes are listened.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.index.Find("host2.example.com.", "A", 0)) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected record written while the connection is lost to be loaded after reconnect")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestStartListenError(t *testing.T) {
+	conn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	s := newTestServer(t, nil, WithDB(&lifecycleRepository{}),
+		SetDNSPort("127.0.0.1:0"), SetHTTPPort(conn.Addr().String()))
+	if err := s.Start(context.Background()); err == nil {
+		t.Fatal("expected error when HTTP port is busy")
+	}
+}
+
+func TestReload(t *testing.T) {
+	config := rrl.DefaultConfig
+	config.ResponsesPerSecond = 5
+	dnsAddrs := []string{"127.0.0.1:0"}
+	var sourceErr error
+	source := func() ([]Option, error) {
+		return []Option{WithDNSAddrs(dnsAddrs...), WithRateLimit(config)}, sourceErr
+	}
+
+	db := &lifecycleRepository{}
+	s := newTestServer(t, nil, WithDB(db), SetHTTPPort("127.0.0.1:0"), WithReloadSource(source))
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+	oldAddr := s.run.current().dnsServers[0].PacketConn.LocalAddr().String()
+	limiter := s.current().rrl
+
+	// Unchanged limits keep the limiter state, unchanged listeners are not rebound.
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s.current().rrl != limiter || s.run.current().dnsServers[0].PacketConn.LocalAddr().String() != oldAddr {
+		t.Fatal("expected limiter and listeners to be kept")
+	}
+
+	// Invalid configuration is rejected and nothing is changed.
+	config.Slip = -1
+	if err := s.Reload(context.Background()); err == nil {
+		t.Fatal("expected error for invalid configuration")
+	}
+	sourceErr = errors.New("can't read file")
+	if err := s.Reload(context.Background()); err == nil {
+		t.Fatal("expected error when configuration can't be read")
+	}
+	if s.current().rrl != limiter {
+		t.Fatal("expected configuration to be kept after failed reload")
+	}
+
+	sourceErr = nil
+	config.Slip = 0
+	busy, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	dnsAddrs = []string{busy.LocalAddr().String()}
+	if err := s.Reload(context.Background()); err == nil {
+		t.Fatal("expected error when new address is busy")
+	}
+	if s.current().rrl != limiter {
+		t.Fatal("expected configuration to be kept when listener can't be bound")
+	}
+
+	// Address is changed, so the listener is rebound.
+	dnsAddrs = []string{"127.0.0.2:0"}
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	newAddr := s.run.current().dnsServers[0].PacketConn.LocalAddr().String()
+	if s.current().rrl == limiter || newAddr == oldAddr {
+		t.Fatal("expected limiter and DNS listener to be replaced")
+	}
+	if _, err := dns.Exchange(new(dns.Msg).SetQuestion("example.com.", dns.TypeA), newAddr); err != nil {
+		t.Fatalf("expected answer from the new listener, got %v", err)
+	}
+
+	// Kept address is carried over, only the added one is bound.
+	kept := s.run.current().dnsServers
+	dnsAddrs = []string{"127.0.0.2:0", "127.0.0.3:0"}
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	l := s.run.current()
+	if len(l.dnsServers) != 4 || l.dnsServers[0] != kept[0] || l.dnsServers[1] != kept[1] {
+		t.Fatalf("expected listeners of the kept address to be carried over, got %d listeners", len(l.dnsServers))
+	}
+	for _, dnsServer := range l.dnsServers {
+		addr := strings.TrimSuffix(listenerName(dnsServer), "/"+dnsServer.Net)
+		client := &dns.Client{Net: dnsServer.Net}
+		if _, _, err := client.Exchange(new(dns.Msg).SetQuestion("example.com.", dns.TypeA), addr); err != nil {
+			t.Errorf("expected answer from %s, got %v", listenerName(dnsServer), err)
+		}
+	}
+}
+
+func TestStartMultipleListeners(t *testing.T) {
+	if !reusePortSupported {
+		t.Skip("SO_REUSEPORT is not supported")
+	}
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	dnsAddrs, workers := []string{"127.0.0.1:0", "127.0.0.2:0"}, 2
+	source := func() ([]Option, error) {
+		return []Option{WithDNSAddrs(dnsAddrs...), WithReusePort(workers)}, nil
+	}
+	s := newTestServer(t, nil, WithDB(&lifecycleRepository{}),
+		WithHTTPAddrs("127.0.0.1:0", "unix:"+socket), WithReloadSource(source))
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+
+	// Every address has two UDP sockets on the same port and one TCP socket.
+	l := s.run.current()
+	if len(l.dnsServers) != 6 || len(l.httpListeners) != 2 {
+		t.Fatalf("expected 6 DNS and 2 HTTP listeners, got %d and %d", len(l.dnsServers), len(l.httpListeners))
+	}
+	for _, dnsServer := range l.dnsServers {
+		addr := strings.TrimSuffix(listenerName(dnsServer), "/"+dnsServer.Net)
+		client := &dns.Client{Net: dnsServer.Net}
+		if _, _, err := client.Exchange(new(dns.Msg).SetQuestion("example.com.", dns.TypeA), addr); err != nil {
+			t.Errorf("expected answer from %s, got %v", listenerName(dnsServer), err)
+		}
+	}
+	if l.dnsServers[0].PacketConn.LocalAddr().String() != l.dnsServers[1].PacketConn.LocalAddr().String() {
+		t.Error("expected UDP workers to share the address")
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	resp, err := client.Get("http://unix/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected healthy server through the Unix socket, got %d", resp.StatusCode)
+	}
+
+	// Address is added and the number of workers is changed, UDP sockets of the kept
+	// addresses are rebound on the same ports and TCP listeners are kept.
+	dnsAddrs, workers = append(dnsAddrs, "127.0.0.3:0"), 3
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := s.run.current()
+	if len(reloaded.dnsServers) != 12 || reloaded.httpServer != l.httpServer {
+		t.Fatalf("expected 12 DNS listeners and the same HTTP server, got %d", len(reloaded.dnsServers))
+	}
+	if reloaded.dnsServers[3] != l.dnsServers[2] || listenerName(reloaded.dnsServers[0]) != listenerName(l.dnsServers[0]) {
+		t.Fatal("expected TCP listener and UDP port of the kept address to be kept")
+	}
+	// Replaced UDP sockets share the ports until they are stopped in the background.
+	time.Sleep(100 * time.Millisecond)
+	for _, dnsServer := range reloaded.dnsServers {
+		addr := strings.TrimSuffix(listenerName(dnsServer), "/"+dnsServer.Net)
+		client := &dns.Client{Net: dnsServer.Net}
+		if _, _, err := client.Exchange(new(dns.Msg).SetQuestion("example.com.", dns.TypeA), addr); err != nil {
+			t.Errorf("expected answer from %s after reload, got %v", listenerName(dnsServer), err)
+		}
+	}
+}
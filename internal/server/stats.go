@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// statsSaveInterval is how often collected query statistics are saved to the database.
+	statsSaveInterval = 10 * time.Second
+	// flushTimeout limit the final saving of the statistics and the query log when the server is stopped.
+	flushTimeout = 5 * time.Second
+)
+
+// saveStats periodically save collected query statistics to the database
+// and delete statistics that are older than retention. Remaining statistics are saved
+// when ctx is done.
+func (s Server) saveStats(ctx context.Context) {
+	save := time.NewTicker(statsSaveInterval)
+	defer save.Stop()
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flushTimeout)
+			if err := s.db.AddQueryStats(flushCtx, s.stats.Take()); err != nil {
+				s.logger.Error("can't save query statistics: " + err.Error())
+			}
+			cancel()
+			return
+
+		case <-save.C:
+			if err := s.db.AddQueryStats(ctx, s.stats.Take()); err != nil {
+				s.logger.Error("can't save query statistics: " + err.Error())
+			}
+
+		case <-cleanup.C:
+			deleted, err := s.db.DeleteQueryStatsBefore(ctx, time.Now().Add(-s.current().statsRetention))
+			if err != nil {
+				s.logger.Error("can't delete old query statistics: " + err.Error())
+				continue
+			}
+			s.logger.Info(fmt.Sprintf("%d old query statistics deleted", deleted))
+		}
+	}
+}
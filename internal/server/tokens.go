@@ -0,0 +1,294 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/proto/crud/genproto/crudpb"
+)
+
+const (
+	// accessCookie contain short-lived JWT access token.
+	accessCookie = "jwt"
+	// refreshCookie contain refresh token, it is sent only to /auth endpoints.
+	refreshCookie = "refresh"
+	// tokenCleanupInterval is the period of deleting expired refresh tokens and revoked tokens.
+	tokenCleanupInterval = time.Hour
+)
+
+// errNoJWTSecret is returned when the key for signing of the tokens is not set.
+var errNoJWTSecret = errors.New("JWT secret is not set")
+
+// randomToken return random string with 256 bits of entropy.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("can't generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// jwtKeyID return ID of the signing key for the kid header. It is derived from the key,
+// so keys don't need names in the configuration and the key itself is not revealed.
+func jwtKeyID(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:8])
+}
+
+// signAccessToken return new access token of the user and its ID.
+func (s Server) signAccessToken(user database.User, now time.Time) (string, error) {
+	conf := s.current()
+	if conf.jwtSecret == "" {
+		return "", errNoJWTSecret
+	}
+	jti, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":         user.ID,
+		"login":      user.Login,
+		"role":       user.Role,
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+		"jti":        jti,
+		"iat":        now.Unix(),
+		"exp":        now.Add(conf.accessTokenTTL).Unix(),
+	})
+	token.Header["kid"] = jwtKeyID(conf.jwtSecret)
+	return token.SignedString([]byte(conf.jwtSecret))
+}
+
+// parseAccessToken verify access token and return its claims. Token must be signed
+// with the current or one of the previous keys, chosen by the kid header, and not be expired.
+func (s Server) parseAccessToken(tokenString string, opts ...jwt.ParserOption) (jwt.MapClaims, error) {
+	conf := s.current()
+	if conf.jwtSecret == "" {
+		return nil, errNoJWTSecret
+	}
+
+	opts = append(opts,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		for _, secret := range append([]string{conf.jwtSecret}, conf.jwtPrevious...) {
+			if kid == jwtKeyID(secret) {
+				return []byte(secret), nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("unexpected type of claims")
+	}
+	if _, ok := claims["jti"].(string); !ok {
+		return nil, errors.New("token has no jti claim")
+	}
+	if _, ok := claims["login"].(string); !ok {
+		return nil, errors.New("token has no login claim")
+	}
+	return claims, nil
+}
+
+// issueTokens set cookies with new access and refresh tokens of the user.
+func (s Server) issueTokens(ctx context.Context, w http.ResponseWriter, user database.User) error {
+	conf := s.current()
+	now := time.Now()
+	access, err := s.signAccessToken(user, now)
+	if err != nil {
+		return fmt.Errorf("can't sign access token: %w", err)
+	}
+
+	refresh, err := randomToken()
+	if err != nil {
+		return err
+	}
+	err = s.db.AddRefreshToken(ctx, user.ID, hashToken(refresh), now.Add(conf.refreshTokenTTL))
+	if err != nil {
+		return fmt.Errorf("can't save refresh token: %w", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     accessCookie,
+		Value:    access,
+		Path:     "/",
+		HttpOnly: true,
+		MaxAge:   int(conf.accessTokenTTL.Seconds()),
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookie,
+		Value:    refresh,
+		Path:     "/auth",
+		HttpOnly: true,
+		MaxAge:   int(conf.refreshTokenTTL.Seconds()),
+		SameSite: http.SameSiteStrictMode,
+	})
+	return nil
+}
+
+// clearTokens remove cookies with the tokens.
+func clearTokens(w http.ResponseWriter) {
+	for name, path := range map[string]string{accessCookie: "/", refreshCookie: "/auth"} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Path:     path,
+			HttpOnly: true,
+			MaxAge:   -1,
+			SameSite: http.SameSiteStrictMode,
+		})
+	}
+}
+
+// writeUser write user in the User message to the response.
+func (s Server) writeUser(w http.ResponseWriter, user database.User) {
+	b, err := proto.Marshal(&crudpb.User{
+		Id:                 user.ID,
+		Login:              user.Login,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		Role:               user.Role,
+		Zones:              user.Zones,
+		Permissions:        user.Permissions,
+		MustChangePassword: user.MustChangePassword,
+	})
+	if err != nil {
+		s.logger.Error("can't marshal user: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Content-Type", "application/protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// refreshHandler handle requests for new access token. Refresh token is rotated: the used one
+// is revoked and the new one is returned. Reuse of the revoked refresh token mean that it was
+// stolen, so all refresh tokens of the user are revoked.
+func (s Server) refreshHandler(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(refreshCookie)
+	if err != nil {
+		s.logger.Error("getting refresh token cookie from request: " + err.Error())
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := s.db.GetRefreshToken(r.Context(), hashToken(cookie.Value))
+	if err != nil {
+		s.logger.Error("can't get refresh token: " + err.Error())
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !token.ExpiresAt.After(time.Now()) {
+		s.logger.Error("refresh token of " + token.Login + " is expired")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err = s.db.RevokeRefreshToken(r.Context(), token.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		s.logger.Error("reuse of revoked refresh token of " + token.Login + ", all its refresh tokens are revoked")
+		if err := s.db.RevokeUserRefreshTokens(r.Context(), token.UserID); err != nil {
+			s.logger.Error("can't revoke refresh tokens of " + token.Login + ": " + err.Error())
+		}
+		clearTokens(w)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		s.logger.Error("can't revoke refresh token: " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := s.db.GetUser(r.Context(), token.Login)
+	if err != nil {
+		s.logger.Error("can't retrive user from database: " + err.Error())
+		http.Error(w, "Internal error, try later", http.StatusInternalServerError)
+		return
+	}
+	if err := s.issueTokens(r.Context(), w, user); err != nil {
+		s.logger.Error("can't issue tokens for user " + user.Login + ": " + err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.writeUser(w, user)
+	s.logger.Info("Refresh of tokens for " + user.Login + " handled")
+}
+
+// logoutHandler handle logout requests. Access token is added to the revocation list,
+// refresh token is revoked and cookies are removed. Missing or invalid tokens are ignored.
+func (s Server) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(accessCookie); err == nil {
+		claims, err := s.parseAccessToken(cookie.Value)
+		if err == nil {
+			exp, _ := claims.GetExpirationTime()
+			if err := s.db.RevokeToken(r.Context(), claims["jti"].(string), exp.Time); err != nil {
+				s.logger.Error("can't revoke access token: " + err.Error())
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	if cookie, err := r.Cookie(refreshCookie); err == nil {
+		token, err := s.db.GetRefreshToken(r.Context(), hashToken(cookie.Value))
+		if err == nil {
+			err = s.db.RevokeRefreshToken(r.Context(), token.ID)
+		}
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Error("can't revoke refresh token: " + err.Error())
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	clearTokens(w)
+	w.WriteHeader(http.StatusOK)
+	s.logger.Info("Logout handled")
+}
+
+// cleanupTokens periodically delete expired refresh tokens and entries of the revocation list.
+func (s Server) cleanupTokens(ctx context.Context) {
+	ticker := time.NewTicker(tokenCleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.db.DeleteExpiredTokens(ctx, time.Now())
+			if err != nil {
+				s.logger.Error("can't delete expired tokens: " + err.Error())
+				continue
+			}
+			s.logger.Info(fmt.Sprintf("%d expired tokens deleted", deleted))
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/prionis/dns-server/internal/database"
+)
+
+// sessionRepository is the repository which store users and tokens in memory.
+type sessionRepository struct {
+	database.Repository
+	mx      sync.Mutex
+	users   map[string]database.User
+	refresh map[string]database.RefreshToken
+	revoked map[string]bool
+}
+
+func newSessionRepository(users ...database.User) *sessionRepository {
+	repo := &sessionRepository{
+		users:   make(map[string]database.User),
+		refresh: make(map[string]database.RefreshToken),
+		revoked: make(map[string]bool),
+	}
+	for _, user := range users {
+		repo.users[user.Login] = user
+	}
+	return repo
+}
+
+func (r *sessionRepository) GetUser(_ context.Context, login string) (database.User, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	user, ok := r.users[login]
+	if !ok {
+		return database.User{}, pgx.ErrNoRows
+	}
+	return user, nil
+}
+
+func (r *sessionRepository) AddRefreshToken(_ context.Context, userID int32, hash []byte, expiresAt time.Time) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	for _, user := range r.users {
+		if user.ID == userID {
+			r.refresh[string(hash)] = database.RefreshToken{
+				ID: int32(len(r.refresh) + 1), UserID: userID, Login: user.Login, ExpiresAt: expiresAt,
+			}
+		}
+	}
+	return nil
+}
+
+func (r *sessionRepository) GetRefreshToken(_ context.Context, hash []byte) (database.RefreshToken, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	token, ok := r.refresh[string(hash)]
+	if !ok {
+		return database.RefreshToken{}, pgx.ErrNoRows
+	}
+	return token, nil
+}
+
+func (r *sessionRepository) RevokeRefreshToken(_ context.Context, id int32) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	for hash, token := range r.refresh {
+		if token.ID == id && token.RevokedAt.IsZero() {
+			token.RevokedAt = time.Now()
+			r.refresh[hash] = token
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (r *sessionRepository) RevokeUserRefreshTokens(_ context.Context, userID int32) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	for hash, token := range r.refresh {
+		if token.UserID == userID && token.RevokedAt.IsZero() {
+			token.RevokedAt = time.Now()
+			r.refresh[hash] = token
+		}
+	}
+	return nil
+}
+
+func (r *sessionRepository) RevokeToken(_ context.Context, jti string, _ time.Time) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.revoked[jti] = true
+	return nil
+}
+
+func (r *sessionRepository) IsTokenRevoked(_ context.Context, jti string) (bool, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.revoked[jti], nil
+}
+
+// authenticate return status of the request to the API with provided cookies.
+func authenticate(s Server, cookies ...*http.Cookie) int {
+	req := httptest.NewRequest(http.MethodGet, "/api/rrs/all", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	s.authenticationMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(rec, req)
+	return rec.Code
+}
+
+// cookie return cookie with provided name from the response.
+func cookie(t *testing.T, rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	t.Helper()
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("response has no %s cookie", name)
+	return nil
+}
+
+func TestAccessToken(t *testing.T) {
+	user := database.User{ID: 1, Login: "alice", Role: "admin"}
+	repo := newSessionRepository(user)
+	s := newTestServer(t, nil, WithDB(repo), WithJWTSecret("old"))
+
+	token, err := s.signAccessToken(user, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := authenticate(s, &http.Cookie{Name: accessCookie, Value: token}); code != http.StatusOK {
+		t.Fatalf("expected valid token to be accepted, got %d", code)
+	}
+
+	expired, err := s.signAccessToken(user, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := authenticate(s, &http.Cookie{Name: accessCookie, Value: expired}); code != http.StatusUnauthorized {
+		t.Fatalf("expected expired token to be rejected, got %d", code)
+	}
+
+	rotated := newTestServer(t, nil, WithDB(repo), WithJWTSecret("new"), WithPreviousJWTSecrets("old"))
+	if code := authenticate(rotated, &http.Cookie{Name: accessCookie, Value: token}); code != http.StatusOK {
+		t.Fatalf("expected token signed with previous key to be accepted, got %d", code)
+	}
+	removed := newTestServer(t, nil, WithDB(repo), WithJWTSecret("new"))
+	if code := authenticate(removed, &http.Cookie{Name: accessCookie, Value: token}); code != http.StatusUnauthorized {
+		t.Fatalf("expected token signed with removed key to be rejected, got %d", code)
+	}
+	unset := newTestServer(t, nil, WithDB(repo), WithJWTSecret(""))
+	if code := authenticate(unset, &http.Cookie{Name: accessCookie, Value: token}); code != http.StatusInternalServerError {
+		t.Fatalf("expected request to fail without secret, got %d", code)
+	}
+}
+
+func TestRefreshAndLogout(t *testing.T) {
+	user := database.User{ID: 1, Login: "alice", Role: "admin"}
+	repo := newSessionRepository(user)
+	s := newTestServer(t, nil, WithDB(repo), WithJWTSecret("secret"))
+
+	login := httptest.NewRecorder()
+	if err := s.issueTokens(context.Background(), login, user); err != nil {
+		t.Fatal(err)
+	}
+	refresh := cookie(t, login, refreshCookie)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/refresh", nil)
+	req.AddCookie(refresh)
+	rec := httptest.NewRecorder()
+	s.refreshHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected refresh to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	access, rotated := cookie(t, rec, accessCookie), cookie(t, rec, refreshCookie)
+
+	// Used refresh token is revoked, its reuse revoke the rotated one too.
+	rec = httptest.NewRecorder()
+	s.refreshHandler(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected reuse of refresh token to be rejected, got %d", rec.Code)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/auth/refresh", nil)
+	req.AddCookie(rotated)
+	rec = httptest.NewRecorder()
+	s.refreshHandler(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected rotated token to be revoked after reuse, got %d", rec.Code)
+	}
+
+	if code := authenticate(s, access); code != http.StatusOK {
+		t.Fatalf("expected access token to be valid before logout, got %d", code)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	req.AddCookie(access)
+	rec = httptest.NewRecorder()
+	s.logoutHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected logout to succeed, got %d", rec.Code)
+	}
+	if code := authenticate(s, access); code != http.StatusUnauthorized {
+		t.Fatalf("expected access token to be revoked by logout, got %d", code)
+	}
+}
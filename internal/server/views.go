@@ -0,0 +1,125 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/miekg/dns"
+
+	"github.com/prionis/dns-server/internal/database"
+	"github.com/prionis/dns-server/proto/crud/genproto/crudpb"
+)
+
+// viewFromProto convert protobuf message to the normalized view.
+// *database.ValidationError is returned if the view is invalid.
+func viewFromProto(msg *crudpb.View) (database.View, error) {
+	view := database.View{
+		ID:      msg.GetId(),
+		Name:    msg.GetName(),
+		Zones:   msg.GetZones(),
+		Records: msg.GetRecordIds(),
+	}
+
+	verr := &database.ValidationError{}
+	for _, network := range msg.GetNetworks() {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			verr.Add("networks", fmt.Sprintf("%q is not a valid network", network))
+			continue
+		}
+		view.Networks = append(view.Networks, prefix)
+	}
+	if len(verr.Fields) != 0 {
+		return view, verr
+	}
+
+	return database.NormalizeView(view)
+}
+
+// toProtoView convert view to the protobuf message.
+func toProtoView(view database.View) *crudpb.View {
+	msg := &crudpb.View{
+		Id:        view.ID,
+		Name:      view.Name,
+		Zones:     view.Zones,
+		RecordIds: view.Records,
+	}
+	for _, network := range view.Networks {
+		msg.Networks = append(msg.Networks, network.String())
+	}
+	return msg
+}
+
+// writeViewError write error of the view change to the response.
+// Invalid fields are returned in the ValidationErrors message.
+func (s Server) writeViewError(w http.ResponseWriter, msg string, err error) {
+	s.logger.Error(msg + err.Error())
+
+	var verr *database.ValidationError
+	if errors.As(err, &verr) {
+		writeValidationErrors(w, verr)
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "View not found", http.StatusNotFound)
+		return
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23503": // foreign_key_violation
+			http.Error(w, "Unknown resource record", http.StatusBadRequest)
+			return
+		case "23505": // unique_violation
+			http.Error(w, "View with this name already exist", http.StatusConflict)
+			return
+		}
+	}
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
+
+// clientAddr return address of the client used for selection of the view.
+// If query is sent by the trusted resolver and contain EDNS Client Subnet option,
+// address from the option is returned with the option for the answer.
+func (s Server) clientAddr(w dns.ResponseWriter, msg *dns.Msg) (netip.Addr, *dns.EDNS0_SUBNET) {
+	resolver := remoteAddr(w)
+	trusted := slices.ContainsFunc(s.current().clientSubnet, func(p netip.Prefix) bool {
+		return p.Contains(resolver)
+	})
+	if trusted {
+		if opt := msg.IsEdns0(); opt != nil {
+			for _, option := range opt.Option {
+				subnet, ok := option.(*dns.EDNS0_SUBNET)
+				if !ok {
+					continue
+				}
+				addr, ok := netip.AddrFromSlice(subnet.Address)
+				if !ok {
+					break
+				}
+				// Answer is valid for all clients of the network that select the same view,
+				// so the resolver may cache it for them.
+				answer := *subnet
+				answer.SourceScope = uint8(s.index.Scope(addr.Unmap()))
+				return addr.Unmap(), &answer
+			}
+		}
+	}
+
+	return resolver, nil
+}
+
+// remoteAddr return address of the client that sent the query.
+func remoteAddr(w dns.ResponseWriter) netip.Addr {
+	addrPort, err := netip.ParseAddrPort(w.RemoteAddr().String())
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addrPort.Addr().Unmap()
+}
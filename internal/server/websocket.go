@@ -2,10 +2,14 @@ package server
 
 import (
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// writeWait is how long one log message may be written to the connection.
+const writeWait = time.Second
+
 type WebSocket struct {
 	mx    sync.Mutex
 	conns map[*websocket.Conn]struct{}
@@ -17,14 +21,19 @@ func NewWSWriter() *WebSocket {
 	}
 }
 
+// Write send message to all connections. Connections that can't be written are closed and removed,
+// so error of one connection doesn't stop writing of the log.
 func (w *WebSocket) Write(msg []byte) (n int, err error) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
 	for conn := range w.conns {
-		err := conn.WriteMessage(websocket.BinaryMessage, msg)
-		if err != nil {
-			return n, err
+		conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := conn.WriteMessage(websocket.BinaryMessage, msg); err != nil {
+			conn.Close()
+			delete(w.conns, conn)
 		}
 	}
-	return n, err
+	return len(msg), nil
 }
 
 func (w *WebSocket) AddConn(conn *websocket.Conn) {
@@ -38,3 +47,16 @@ func (w *WebSocket) DeleteConn(conn *websocket.Conn) {
 	defer w.mx.Unlock()
 	delete(w.conns, conn)
 }
+
+// Close send close message to all connections and close them.
+func (w *WebSocket) Close() {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	for conn := range w.conns {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"),
+			time.Now().Add(writeWait))
+		conn.Close()
+		delete(w.conns, conn)
+	}
+}
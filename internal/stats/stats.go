@@ -0,0 +1,85 @@
+// Package stats aggregate DNS queries for the statistics.
+package stats
+
+import (
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prionis/dns-server/internal/database"
+)
+
+// Query is one answered DNS query.
+type Query struct {
+	// Time when the query was received.
+	Time time.Time
+	// Client is address of the client that sent the query.
+	Client netip.Addr
+	// Domain from the question.
+	Domain string
+	// Type from the question.
+	Type string
+	// Rcode of the response.
+	Rcode string
+	// Blocked is set when query was refused by the server policy.
+	Blocked bool
+}
+
+// Collector count queries by minutes until they are taken for saving.
+type Collector struct {
+	mx     sync.Mutex
+	counts map[key]int64
+}
+
+type key struct {
+	minute  time.Time
+	client  netip.Addr
+	domain  string
+	rrType  string
+	rcode   string
+	blocked bool
+}
+
+// New create empty collector.
+func New() *Collector {
+	return &Collector{counts: make(map[key]int64)}
+}
+
+// Add count the query.
+func (c *Collector) Add(q Query) {
+	k := key{
+		minute:  q.Time.Truncate(time.Minute),
+		client:  q.Client.Unmap(),
+		domain:  strings.ToLower(q.Domain),
+		rrType:  q.Type,
+		rcode:   q.Rcode,
+		blocked: q.Blocked,
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.counts[k]++
+}
+
+// Take return counted queries and reset the collector.
+func (c *Collector) Take() []database.QueryStat {
+	c.mx.Lock()
+	counts := c.counts
+	c.counts = make(map[key]int64)
+	c.mx.Unlock()
+
+	stats := make([]database.QueryStat, 0, len(counts))
+	for k, count := range counts {
+		stats = append(stats, database.QueryStat{
+			Time:    k.minute,
+			Client:  k.client,
+			Domain:  k.domain,
+			Type:    k.rrType,
+			Rcode:   k.rcode,
+			Blocked: k.blocked,
+			Count:   count,
+		})
+	}
+	return stats
+}
@@ -0,0 +1,35 @@
+package stats
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestCollector(t *testing.T) {
+	c := New()
+	now := time.Date(2024, 1, 1, 10, 0, 30, 0, time.UTC)
+	client := netip.MustParseAddr("192.0.2.1")
+	c.Add(Query{Time: now, Client: client, Domain: "Example.com.", Type: "A", Rcode: "NOERROR"})
+	c.Add(Query{Time: now.Add(10 * time.Second), Client: client, Domain: "example.com.", Type: "A", Rcode: "NOERROR"})
+	c.Add(Query{Time: now.Add(time.Minute), Client: client, Domain: "example.com.", Type: "A", Rcode: "NOERROR"})
+	c.Add(Query{Time: now, Client: client, Domain: "example.com.", Type: "A", Rcode: "REFUSED", Blocked: true})
+
+	stats := c.Take()
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 aggregates, got %v", stats)
+	}
+	var total int64
+	for _, stat := range stats {
+		total += stat.Count
+		if stat.Time.Second() != 0 {
+			t.Fatalf("expected time to be truncated to the minute, got %s", stat.Time)
+		}
+	}
+	if total != 4 {
+		t.Fatalf("expected 4 queries, got %d", total)
+	}
+	if stats := c.Take(); len(stats) != 0 {
+		t.Fatalf("expected collector to be reset, got %v", stats)
+	}
+}
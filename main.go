@@ -4,6 +4,7 @@ import (
 	"flag"
 
 	"github.com/prionis/dns-server/cmd/cli"
+	"github.com/prionis/dns-server/internal/config"
 )
 
 func main() {
@@ -15,16 +16,45 @@ func main() {
 	flagPort := flag.String("port", ":8080", "set specific port of the server. Default is \":8080\"")
 	flagLogPath := flag.String("logfile", "DNSServer.log", "set specific name(or path) of log file")
 	flagDelRR := flag.Int64("del", -1, "delete resource record. Accept ID of resource record to delete")
+	flagConfig := flag.String("config", "", "set path of the configuration file (.yaml, .yml or .toml)")
+	flagCheckConfig := flag.Bool("check-config", false, "validate configuration and exit")
+	flagDNSAddr := flag.String("dns-addr", "", "set comma-separated addresses of the DNS listeners, overrides configuration")
+	flagHTTPAddr := flag.String("http-addr", "", "set comma-separated addresses of the HTTP API, overrides configuration")
+	flagLogLevel := flag.String("log-level", "", "set log level (debug, info, warn, error), overrides configuration")
 
 	flag.Parse()
 
+	// Flags override configuration only when they are set explicitly,
+	// so defaults of the flags don't hide values from the file and environment.
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	loader := config.Loader{
+		Path: *flagConfig,
+		Flags: func(c *config.Config) {
+			if set["dns-addr"] {
+				c.Listen.DNS = config.ParseAddresses(*flagDNSAddr)
+			}
+			if set["http-addr"] {
+				c.Listen.HTTP = config.ParseAddresses(*flagHTTPAddr)
+			}
+			if set["log-level"] {
+				c.Log.Level = *flagLogLevel
+			}
+			if set["logfile"] {
+				c.Log.File = *flagLogPath
+			}
+		},
+	}
+
 	switch {
+	case *flagCheckConfig:
+		cli.CheckConfig(loader)
 	case *flagAddRR != "":
 		cli.AddRR(*flagAddRR, *flagAddr, *flagPort)
 	case *flagDelRR != -1:
 		cli.DelRR(*flagDelRR, *flagAddr, *flagPort)
 	case *flagServer:
-		cli.StartServer(*flagLogPath)
+		cli.StartServer(loader)
 	case *flagListLog:
 		cli.PrintLogList(*flagLogPath)
 	case *flagListRR:
@@ -22,33 +22,2398 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type RecordOperation_Action int32
+
+const (
+	RecordOperation_ACTION_UNSPECIFIED RecordOperation_Action = 0
+	RecordOperation_ACTION_CREATE      RecordOperation_Action = 1
+	RecordOperation_ACTION_UPDATE      RecordOperation_Action = 2
+	RecordOperation_ACTION_DELETE      RecordOperation_Action = 3
+)
+
+// Enum value maps for RecordOperation_Action.
+var (
+	RecordOperation_Action_name = map[int32]string{
+		0: "ACTION_UNSPECIFIED",
+		1: "ACTION_CREATE",
+		2: "ACTION_UPDATE",
+		3: "ACTION_DELETE",
+	}
+	RecordOperation_Action_value = map[string]int32{
+		"ACTION_UNSPECIFIED": 0,
+		"ACTION_CREATE":      1,
+		"ACTION_UPDATE":      2,
+		"ACTION_DELETE":      3,
+	}
+)
+
+func (x RecordOperation_Action) Enum() *RecordOperation_Action {
+	p := new(RecordOperation_Action)
+	*p = x
+	return p
+}
+
+func (x RecordOperation_Action) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RecordOperation_Action) Descriptor() protoreflect.EnumDescriptor {
+	return file_crud_proto_enumTypes[0].Descriptor()
+}
+
+func (RecordOperation_Action) Type() protoreflect.EnumType {
+	return &file_crud_proto_enumTypes[0]
+}
+
+func (x RecordOperation_Action) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RecordOperation_Action.Descriptor instead.
+func (RecordOperation_Action) EnumDescriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{16, 0}
+}
+
 type User struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Login     string                 `protobuf:"bytes,2,opt,name=login,proto3" json:"login,omitempty"`
+	FirstName string                 `protobuf:"bytes,3,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
+	LastName  string                 `protobuf:"bytes,4,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
+	Password  string                 `protobuf:"bytes,5,opt,name=password,proto3" json:"password,omitempty"`
+	Role      string                 `protobuf:"bytes,6,opt,name=role,proto3" json:"role,omitempty"`
+	// zones restrict changes of the resource records to these zones, empty list allow all zones.
+	Zones []string `protobuf:"bytes,7,rep,name=zones,proto3" json:"zones,omitempty"`
+	// permissions of the role, they are returned on login and not changed by updates.
+	Permissions []string `protobuf:"bytes,8,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	// must_change_password is set for temporary passwords, other requests are
+	// forbidden until the password is changed.
+	MustChangePassword bool `protobuf:"varint,9,opt,name=must_change_password,json=mustChangePassword,proto3" json:"must_change_password,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_crud_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *User) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *User) GetLogin() string {
+	if x != nil {
+		return x.Login
+	}
+	return ""
+}
+
+func (x *User) GetFirstName() string {
+	if x != nil {
+		return x.FirstName
+	}
+	return ""
+}
+
+func (x *User) GetLastName() string {
+	if x != nil {
+		return x.LastName
+	}
+	return ""
+}
+
+func (x *User) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *User) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *User) GetZones() []string {
+	if x != nil {
+		return x.Zones
+	}
+	return nil
+}
+
+func (x *User) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *User) GetMustChangePassword() bool {
+	if x != nil {
+		return x.MustChangePassword
+	}
+	return false
+}
+
+type UserCollection struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserCollection) Reset() {
+	*x = UserCollection{}
+	mi := &file_crud_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserCollection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserCollection) ProtoMessage() {}
+
+func (x *UserCollection) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserCollection.ProtoReflect.Descriptor instead.
+func (*UserCollection) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *UserCollection) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+type ResourceRecord struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Domain string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	// data in the presentation format, ignored if payload is set.
+	Data       string `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Type       string `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Class      string `protobuf:"bytes,5,opt,name=class,proto3" json:"class,omitempty"`
+	TimeToLive int32  `protobuf:"varint,6,opt,name=time_to_live,json=timeToLive,proto3" json:"time_to_live,omitempty"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ResourceRecord_A
+	//	*ResourceRecord_Aaaa
+	//	*ResourceRecord_Mx
+	//	*ResourceRecord_Srv
+	//	*ResourceRecord_Txt
+	//	*ResourceRecord_Caa
+	//	*ResourceRecord_Cname
+	//	*ResourceRecord_Ns
+	//	*ResourceRecord_Ptr
+	Payload       isResourceRecord_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResourceRecord) Reset() {
+	*x = ResourceRecord{}
+	mi := &file_crud_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceRecord) ProtoMessage() {}
+
+func (x *ResourceRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceRecord.ProtoReflect.Descriptor instead.
+func (*ResourceRecord) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ResourceRecord) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ResourceRecord) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *ResourceRecord) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *ResourceRecord) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ResourceRecord) GetClass() string {
+	if x != nil {
+		return x.Class
+	}
+	return ""
+}
+
+func (x *ResourceRecord) GetTimeToLive() int32 {
+	if x != nil {
+		return x.TimeToLive
+	}
+	return 0
+}
+
+func (x *ResourceRecord) GetPayload() isResourceRecord_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ResourceRecord) GetA() *AData {
+	if x != nil {
+		if x, ok := x.Payload.(*ResourceRecord_A); ok {
+			return x.A
+		}
+	}
+	return nil
+}
+
+func (x *ResourceRecord) GetAaaa() *AAAAData {
+	if x != nil {
+		if x, ok := x.Payload.(*ResourceRecord_Aaaa); ok {
+			return x.Aaaa
+		}
+	}
+	return nil
+}
+
+func (x *ResourceRecord) GetMx() *MXData {
+	if x != nil {
+		if x, ok := x.Payload.(*ResourceRecord_Mx); ok {
+			return x.Mx
+		}
+	}
+	return nil
+}
+
+func (x *ResourceRecord) GetSrv() *SRVData {
+	if x != nil {
+		if x, ok := x.Payload.(*ResourceRecord_Srv); ok {
+			return x.Srv
+		}
+	}
+	return nil
+}
+
+func (x *ResourceRecord) GetTxt() *TXTData {
+	if x != nil {
+		if x, ok := x.Payload.(*ResourceRecord_Txt); ok {
+			return x.Txt
+		}
+	}
+	return nil
+}
+
+func (x *ResourceRecord) GetCaa() *CAAData {
+	if x != nil {
+		if x, ok := x.Payload.(*ResourceRecord_Caa); ok {
+			return x.Caa
+		}
+	}
+	return nil
+}
+
+func (x *ResourceRecord) GetCname() *CNAMEData {
+	if x != nil {
+		if x, ok := x.Payload.(*ResourceRecord_Cname); ok {
+			return x.Cname
+		}
+	}
+	return nil
+}
+
+func (x *ResourceRecord) GetNs() *NSData {
+	if x != nil {
+		if x, ok := x.Payload.(*ResourceRecord_Ns); ok {
+			return x.Ns
+		}
+	}
+	return nil
+}
+
+func (x *ResourceRecord) GetPtr() *PTRData {
+	if x != nil {
+		if x, ok := x.Payload.(*ResourceRecord_Ptr); ok {
+			return x.Ptr
+		}
+	}
+	return nil
+}
+
+type isResourceRecord_Payload interface {
+	isResourceRecord_Payload()
+}
+
+type ResourceRecord_A struct {
+	A *AData `protobuf:"bytes,7,opt,name=a,proto3,oneof"`
+}
+
+type ResourceRecord_Aaaa struct {
+	Aaaa *AAAAData `protobuf:"bytes,8,opt,name=aaaa,proto3,oneof"`
+}
+
+type ResourceRecord_Mx struct {
+	Mx *MXData `protobuf:"bytes,9,opt,name=mx,proto3,oneof"`
+}
+
+type ResourceRecord_Srv struct {
+	Srv *SRVData `protobuf:"bytes,10,opt,name=srv,proto3,oneof"`
+}
+
+type ResourceRecord_Txt struct {
+	Txt *TXTData `protobuf:"bytes,11,opt,name=txt,proto3,oneof"`
+}
+
+type ResourceRecord_Caa struct {
+	Caa *CAAData `protobuf:"bytes,12,opt,name=caa,proto3,oneof"`
+}
+
+type ResourceRecord_Cname struct {
+	Cname *CNAMEData `protobuf:"bytes,13,opt,name=cname,proto3,oneof"`
+}
+
+type ResourceRecord_Ns struct {
+	Ns *NSData `protobuf:"bytes,14,opt,name=ns,proto3,oneof"`
+}
+
+type ResourceRecord_Ptr struct {
+	Ptr *PTRData `protobuf:"bytes,15,opt,name=ptr,proto3,oneof"`
+}
+
+func (*ResourceRecord_A) isResourceRecord_Payload() {}
+
+func (*ResourceRecord_Aaaa) isResourceRecord_Payload() {}
+
+func (*ResourceRecord_Mx) isResourceRecord_Payload() {}
+
+func (*ResourceRecord_Srv) isResourceRecord_Payload() {}
+
+func (*ResourceRecord_Txt) isResourceRecord_Payload() {}
+
+func (*ResourceRecord_Caa) isResourceRecord_Payload() {}
+
+func (*ResourceRecord_Cname) isResourceRecord_Payload() {}
+
+func (*ResourceRecord_Ns) isResourceRecord_Payload() {}
+
+func (*ResourceRecord_Ptr) isResourceRecord_Payload() {}
+
+type AData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AData) Reset() {
+	*x = AData{}
+	mi := &file_crud_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AData) ProtoMessage() {}
+
+func (x *AData) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AData.ProtoReflect.Descriptor instead.
+func (*AData) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AData) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type AAAAData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AAAAData) Reset() {
+	*x = AAAAData{}
+	mi := &file_crud_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AAAAData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AAAAData) ProtoMessage() {}
+
+func (x *AAAAData) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AAAAData.ProtoReflect.Descriptor instead.
+func (*AAAAData) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AAAAData) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type MXData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Preference    uint32                 `protobuf:"varint,1,opt,name=preference,proto3" json:"preference,omitempty"`
+	Exchange      string                 `protobuf:"bytes,2,opt,name=exchange,proto3" json:"exchange,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MXData) Reset() {
+	*x = MXData{}
+	mi := &file_crud_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MXData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MXData) ProtoMessage() {}
+
+func (x *MXData) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MXData.ProtoReflect.Descriptor instead.
+func (*MXData) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MXData) GetPreference() uint32 {
+	if x != nil {
+		return x.Preference
+	}
+	return 0
+}
+
+func (x *MXData) GetExchange() string {
+	if x != nil {
+		return x.Exchange
+	}
+	return ""
+}
+
+type SRVData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Priority      uint32                 `protobuf:"varint,1,opt,name=priority,proto3" json:"priority,omitempty"`
+	Weight        uint32                 `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	Port          uint32                 `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
+	Target        string                 `protobuf:"bytes,4,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SRVData) Reset() {
+	*x = SRVData{}
+	mi := &file_crud_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SRVData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SRVData) ProtoMessage() {}
+
+func (x *SRVData) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SRVData.ProtoReflect.Descriptor instead.
+func (*SRVData) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SRVData) GetPriority() uint32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *SRVData) GetWeight() uint32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *SRVData) GetPort() uint32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *SRVData) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type TXTData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          []string               `protobuf:"bytes,1,rep,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TXTData) Reset() {
+	*x = TXTData{}
+	mi := &file_crud_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TXTData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TXTData) ProtoMessage() {}
+
+func (x *TXTData) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TXTData.ProtoReflect.Descriptor instead.
+func (*TXTData) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TXTData) GetText() []string {
+	if x != nil {
+		return x.Text
+	}
+	return nil
+}
+
+type CAAData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Flag          uint32                 `protobuf:"varint,1,opt,name=flag,proto3" json:"flag,omitempty"`
+	Tag           string                 `protobuf:"bytes,2,opt,name=tag,proto3" json:"tag,omitempty"`
+	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CAAData) Reset() {
+	*x = CAAData{}
+	mi := &file_crud_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CAAData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CAAData) ProtoMessage() {}
+
+func (x *CAAData) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CAAData.ProtoReflect.Descriptor instead.
+func (*CAAData) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CAAData) GetFlag() uint32 {
+	if x != nil {
+		return x.Flag
+	}
+	return 0
+}
+
+func (x *CAAData) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *CAAData) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type CNAMEData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        string                 `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CNAMEData) Reset() {
+	*x = CNAMEData{}
+	mi := &file_crud_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CNAMEData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CNAMEData) ProtoMessage() {}
+
+func (x *CNAMEData) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CNAMEData.ProtoReflect.Descriptor instead.
+func (*CNAMEData) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CNAMEData) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type NSData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NSData) Reset() {
+	*x = NSData{}
+	mi := &file_crud_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NSData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NSData) ProtoMessage() {}
+
+func (x *NSData) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NSData.ProtoReflect.Descriptor instead.
+func (*NSData) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *NSData) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+type PTRData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Domain        string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PTRData) Reset() {
+	*x = PTRData{}
+	mi := &file_crud_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PTRData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PTRData) ProtoMessage() {}
+
+func (x *PTRData) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PTRData.ProtoReflect.Descriptor instead.
+func (*PTRData) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PTRData) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+type FieldError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldError) Reset() {
+	*x = FieldError{}
+	mi := &file_crud_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldError) ProtoMessage() {}
+
+func (x *FieldError) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldError.ProtoReflect.Descriptor instead.
+func (*FieldError) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *FieldError) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *FieldError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ValidationErrors struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Errors        []*FieldError          `protobuf:"bytes,1,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidationErrors) Reset() {
+	*x = ValidationErrors{}
+	mi := &file_crud_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidationErrors) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidationErrors) ProtoMessage() {}
+
+func (x *ValidationErrors) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidationErrors.ProtoReflect.Descriptor instead.
+func (*ValidationErrors) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ValidationErrors) GetErrors() []*FieldError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+type ResourceRecordCollection struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       []*ResourceRecord      `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResourceRecordCollection) Reset() {
+	*x = ResourceRecordCollection{}
+	mi := &file_crud_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceRecordCollection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceRecordCollection) ProtoMessage() {}
+
+func (x *ResourceRecordCollection) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceRecordCollection.ProtoReflect.Descriptor instead.
+func (*ResourceRecordCollection) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ResourceRecordCollection) GetRecords() []*ResourceRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type ResourceRecordPage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       []*ResourceRecord      `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResourceRecordPage) Reset() {
+	*x = ResourceRecordPage{}
+	mi := &file_crud_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceRecordPage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceRecordPage) ProtoMessage() {}
+
+func (x *ResourceRecordPage) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceRecordPage.ProtoReflect.Descriptor instead.
+func (*ResourceRecordPage) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ResourceRecordPage) GetRecords() []*ResourceRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+func (x *ResourceRecordPage) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type RecordOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Action        RecordOperation_Action `protobuf:"varint,1,opt,name=action,proto3,enum=crud.v1.RecordOperation_Action" json:"action,omitempty"`
+	Record        *ResourceRecord        `protobuf:"bytes,2,opt,name=record,proto3" json:"record,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordOperation) Reset() {
+	*x = RecordOperation{}
+	mi := &file_crud_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordOperation) ProtoMessage() {}
+
+func (x *RecordOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordOperation.ProtoReflect.Descriptor instead.
+func (*RecordOperation) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RecordOperation) GetAction() RecordOperation_Action {
+	if x != nil {
+		return x.Action
+	}
+	return RecordOperation_ACTION_UNSPECIFIED
+}
+
+func (x *RecordOperation) GetRecord() *ResourceRecord {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+type RecordBatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Operations    []*RecordOperation     `protobuf:"bytes,1,rep,name=operations,proto3" json:"operations,omitempty"`
+	DryRun        bool                   `protobuf:"varint,2,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordBatch) Reset() {
+	*x = RecordBatch{}
+	mi := &file_crud_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordBatch) ProtoMessage() {}
+
+func (x *RecordBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordBatch.ProtoReflect.Descriptor instead.
+func (*RecordBatch) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *RecordBatch) GetOperations() []*RecordOperation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+func (x *RecordBatch) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type RecordOperationResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordOperationResult) Reset() {
+	*x = RecordOperationResult{}
+	mi := &file_crud_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordOperationResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordOperationResult) ProtoMessage() {}
+
+func (x *RecordOperationResult) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordOperationResult.ProtoReflect.Descriptor instead.
+func (*RecordOperationResult) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RecordOperationResult) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *RecordOperationResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type RecordBatchResult struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	Results       []*RecordOperationResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Applied       bool                     `protobuf:"varint,2,opt,name=applied,proto3" json:"applied,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordBatchResult) Reset() {
+	*x = RecordBatchResult{}
+	mi := &file_crud_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordBatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordBatchResult) ProtoMessage() {}
+
+func (x *RecordBatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordBatchResult.ProtoReflect.Descriptor instead.
+func (*RecordBatchResult) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RecordBatchResult) GetResults() []*RecordOperationResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *RecordBatchResult) GetApplied() bool {
+	if x != nil {
+		return x.Applied
+	}
+	return false
+}
+
+type RecordChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	RecordId      int32                  `protobuf:"varint,2,opt,name=record_id,json=recordId,proto3" json:"record_id,omitempty"`
+	Operation     string                 `protobuf:"bytes,3,opt,name=operation,proto3" json:"operation,omitempty"`
+	Actor         string                 `protobuf:"bytes,4,opt,name=actor,proto3" json:"actor,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=time,proto3" json:"time,omitempty"`
+	Before        *ResourceRecord        `protobuf:"bytes,6,opt,name=before,proto3" json:"before,omitempty"`
+	After         *ResourceRecord        `protobuf:"bytes,7,opt,name=after,proto3" json:"after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordChange) Reset() {
+	*x = RecordChange{}
+	mi := &file_crud_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordChange) ProtoMessage() {}
+
+func (x *RecordChange) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordChange.ProtoReflect.Descriptor instead.
+func (*RecordChange) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RecordChange) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *RecordChange) GetRecordId() int32 {
+	if x != nil {
+		return x.RecordId
+	}
+	return 0
+}
+
+func (x *RecordChange) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *RecordChange) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *RecordChange) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *RecordChange) GetBefore() *ResourceRecord {
+	if x != nil {
+		return x.Before
+	}
+	return nil
+}
+
+func (x *RecordChange) GetAfter() *ResourceRecord {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+type RecordChangeCollection struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Changes       []*RecordChange        `protobuf:"bytes,1,rep,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordChangeCollection) Reset() {
+	*x = RecordChangeCollection{}
+	mi := &file_crud_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordChangeCollection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordChangeCollection) ProtoMessage() {}
+
+func (x *RecordChangeCollection) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordChangeCollection.ProtoReflect.Descriptor instead.
+func (*RecordChangeCollection) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *RecordChangeCollection) GetChanges() []*RecordChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+type ZoneRollback struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ZoneRollback) Reset() {
+	*x = ZoneRollback{}
+	mi := &file_crud_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ZoneRollback) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ZoneRollback) ProtoMessage() {}
+
+func (x *ZoneRollback) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ZoneRollback.ProtoReflect.Descriptor instead.
+func (*ZoneRollback) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ZoneRollback) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+type ZoneRollbackResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Restored      int32                  `protobuf:"varint,1,opt,name=restored,proto3" json:"restored,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ZoneRollbackResult) Reset() {
+	*x = ZoneRollbackResult{}
+	mi := &file_crud_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ZoneRollbackResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ZoneRollbackResult) ProtoMessage() {}
+
+func (x *ZoneRollbackResult) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ZoneRollbackResult.ProtoReflect.Descriptor instead.
+func (*ZoneRollbackResult) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ZoneRollbackResult) GetRestored() int32 {
+	if x != nil {
+		return x.Restored
+	}
+	return 0
+}
+
+// View is a named set of answers for clients from its networks.
+type View struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// networks of the clients in CIDR notation.
+	Networks []string `protobuf:"bytes,3,rep,name=networks,proto3" json:"networks,omitempty"`
+	// zones which records are answered only in this view.
+	Zones []string `protobuf:"bytes,4,rep,name=zones,proto3" json:"zones,omitempty"`
+	// record_ids of the records that are answered only in this view.
+	RecordIds     []int32 `protobuf:"varint,5,rep,packed,name=record_ids,json=recordIds,proto3" json:"record_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *View) Reset() {
+	*x = View{}
+	mi := &file_crud_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *View) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*View) ProtoMessage() {}
+
+func (x *View) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use View.ProtoReflect.Descriptor instead.
+func (*View) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *View) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *View) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *View) GetNetworks() []string {
+	if x != nil {
+		return x.Networks
+	}
+	return nil
+}
+
+func (x *View) GetZones() []string {
+	if x != nil {
+		return x.Zones
+	}
+	return nil
+}
+
+func (x *View) GetRecordIds() []int32 {
+	if x != nil {
+		return x.RecordIds
+	}
+	return nil
+}
+
+type ViewCollection struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Views         []*View                `protobuf:"bytes,1,rep,name=views,proto3" json:"views,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ViewCollection) Reset() {
+	*x = ViewCollection{}
+	mi := &file_crud_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ViewCollection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ViewCollection) ProtoMessage() {}
+
+func (x *ViewCollection) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ViewCollection.ProtoReflect.Descriptor instead.
+func (*ViewCollection) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ViewCollection) GetViews() []*View {
+	if x != nil {
+		return x.Views
+	}
+	return nil
+}
+
+// ACLRule allow actions for the clients from the network.
+// Rule with the most specific network containing the client is used.
+type ACLRule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// network of the clients in CIDR notation.
+	Network        string `protobuf:"bytes,2,opt,name=network,proto3" json:"network,omitempty"`
+	AllowQuery     bool   `protobuf:"varint,3,opt,name=allow_query,json=allowQuery,proto3" json:"allow_query,omitempty"`
+	AllowRecursion bool   `protobuf:"varint,4,opt,name=allow_recursion,json=allowRecursion,proto3" json:"allow_recursion,omitempty"`
+	AllowTransfer  bool   `protobuf:"varint,5,opt,name=allow_transfer,json=allowTransfer,proto3" json:"allow_transfer,omitempty"`
+	AllowUpdate    bool   `protobuf:"varint,6,opt,name=allow_update,json=allowUpdate,proto3" json:"allow_update,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ACLRule) Reset() {
+	*x = ACLRule{}
+	mi := &file_crud_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ACLRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ACLRule) ProtoMessage() {}
+
+func (x *ACLRule) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ACLRule.ProtoReflect.Descriptor instead.
+func (*ACLRule) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ACLRule) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ACLRule) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *ACLRule) GetAllowQuery() bool {
+	if x != nil {
+		return x.AllowQuery
+	}
+	return false
+}
+
+func (x *ACLRule) GetAllowRecursion() bool {
+	if x != nil {
+		return x.AllowRecursion
+	}
+	return false
+}
+
+func (x *ACLRule) GetAllowTransfer() bool {
+	if x != nil {
+		return x.AllowTransfer
+	}
+	return false
+}
+
+func (x *ACLRule) GetAllowUpdate() bool {
+	if x != nil {
+		return x.AllowUpdate
+	}
+	return false
+}
+
+type ACLRuleCollection struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rules         []*ACLRule             `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ACLRuleCollection) Reset() {
+	*x = ACLRuleCollection{}
+	mi := &file_crud_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ACLRuleCollection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ACLRuleCollection) ProtoMessage() {}
+
+func (x *ACLRuleCollection) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ACLRuleCollection.ProtoReflect.Descriptor instead.
+func (*ACLRuleCollection) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ACLRuleCollection) GetRules() []*ACLRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+// StatItem is the client or the domain with count of its queries.
+type StatItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatItem) Reset() {
+	*x = StatItem{}
+	mi := &file_crud_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatItem) ProtoMessage() {}
+
+func (x *StatItem) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatItem.ProtoReflect.Descriptor instead.
+func (*StatItem) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *StatItem) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *StatItem) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type StatTop struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*StatItem            `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatTop) Reset() {
+	*x = StatTop{}
+	mi := &file_crud_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatTop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatTop) ProtoMessage() {}
+
+func (x *StatTop) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatTop.ProtoReflect.Descriptor instead.
+func (*StatTop) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *StatTop) GetItems() []*StatItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type VolumePoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Blocked       int64                  `protobuf:"varint,3,opt,name=blocked,proto3" json:"blocked,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VolumePoint) Reset() {
+	*x = VolumePoint{}
+	mi := &file_crud_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VolumePoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VolumePoint) ProtoMessage() {}
+
+func (x *VolumePoint) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VolumePoint.ProtoReflect.Descriptor instead.
+func (*VolumePoint) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *VolumePoint) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *VolumePoint) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *VolumePoint) GetBlocked() int64 {
+	if x != nil {
+		return x.Blocked
+	}
+	return 0
+}
+
+type QueryVolume struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Points        []*VolumePoint         `protobuf:"bytes,1,rep,name=points,proto3" json:"points,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryVolume) Reset() {
+	*x = QueryVolume{}
+	mi := &file_crud_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryVolume) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryVolume) ProtoMessage() {}
+
+func (x *QueryVolume) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryVolume.ProtoReflect.Descriptor instead.
+func (*QueryVolume) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *QueryVolume) GetPoints() []*VolumePoint {
+	if x != nil {
+		return x.Points
+	}
+	return nil
+}
+
+// QueryLogEntry is one answered DNS query from the query log.
+type QueryLogEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	Client        string                 `protobuf:"bytes,3,opt,name=client,proto3" json:"client,omitempty"`
+	Protocol      string                 `protobuf:"bytes,4,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Name          string                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,6,opt,name=type,proto3" json:"type,omitempty"`
+	Rcode         string                 `protobuf:"bytes,7,opt,name=rcode,proto3" json:"rcode,omitempty"`
+	Answer        string                 `protobuf:"bytes,8,opt,name=answer,proto3" json:"answer,omitempty"`
+	Upstream      string                 `protobuf:"bytes,9,opt,name=upstream,proto3" json:"upstream,omitempty"`
+	LatencyUs     int64                  `protobuf:"varint,10,opt,name=latency_us,json=latencyUs,proto3" json:"latency_us,omitempty"`
+	CacheHit      bool                   `protobuf:"varint,11,opt,name=cache_hit,json=cacheHit,proto3" json:"cache_hit,omitempty"`
+	BlockReason   string                 `protobuf:"bytes,12,opt,name=block_reason,json=blockReason,proto3" json:"block_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryLogEntry) Reset() {
+	*x = QueryLogEntry{}
+	mi := &file_crud_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryLogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryLogEntry) ProtoMessage() {}
+
+func (x *QueryLogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryLogEntry.ProtoReflect.Descriptor instead.
+func (*QueryLogEntry) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *QueryLogEntry) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *QueryLogEntry) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *QueryLogEntry) GetClient() string {
+	if x != nil {
+		return x.Client
+	}
+	return ""
+}
+
+func (x *QueryLogEntry) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *QueryLogEntry) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *QueryLogEntry) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *QueryLogEntry) GetRcode() string {
+	if x != nil {
+		return x.Rcode
+	}
+	return ""
+}
+
+func (x *QueryLogEntry) GetAnswer() string {
+	if x != nil {
+		return x.Answer
+	}
+	return ""
+}
+
+func (x *QueryLogEntry) GetUpstream() string {
+	if x != nil {
+		return x.Upstream
+	}
+	return ""
+}
+
+func (x *QueryLogEntry) GetLatencyUs() int64 {
+	if x != nil {
+		return x.LatencyUs
+	}
+	return 0
+}
+
+func (x *QueryLogEntry) GetCacheHit() bool {
+	if x != nil {
+		return x.CacheHit
+	}
+	return false
+}
+
+func (x *QueryLogEntry) GetBlockReason() string {
+	if x != nil {
+		return x.BlockReason
+	}
+	return ""
+}
+
+type QueryLogPage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*QueryLogEntry       `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryLogPage) Reset() {
+	*x = QueryLogPage{}
+	mi := &file_crud_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryLogPage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryLogPage) ProtoMessage() {}
+
+func (x *QueryLogPage) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryLogPage.ProtoReflect.Descriptor instead.
+func (*QueryLogPage) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *QueryLogPage) GetEntries() []*QueryLogEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *QueryLogPage) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+// QueryLogSubscription is the filter sent by the client of the live query stream.
+// Empty fields match all queries.
+type QueryLogSubscription struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Client        string                 `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+	Domain        string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	BlockedOnly   bool                   `protobuf:"varint,4,opt,name=blocked_only,json=blockedOnly,proto3" json:"blocked_only,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryLogSubscription) Reset() {
+	*x = QueryLogSubscription{}
+	mi := &file_crud_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryLogSubscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryLogSubscription) ProtoMessage() {}
+
+func (x *QueryLogSubscription) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryLogSubscription.ProtoReflect.Descriptor instead.
+func (*QueryLogSubscription) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *QueryLogSubscription) GetClient() string {
+	if x != nil {
+		return x.Client
+	}
+	return ""
+}
+
+func (x *QueryLogSubscription) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *QueryLogSubscription) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *QueryLogSubscription) GetBlockedOnly() bool {
+	if x != nil {
+		return x.BlockedOnly
+	}
+	return false
+}
+
+type Login struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Login) Reset() {
+	*x = Login{}
+	mi := &file_crud_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Login) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Login) ProtoMessage() {}
+
+func (x *Login) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Login.ProtoReflect.Descriptor instead.
+func (*Login) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *Login) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Login) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type Register struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Login     string                 `protobuf:"bytes,1,opt,name=login,proto3" json:"login,omitempty"`
+	FirstName string                 `protobuf:"bytes,2,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
+	LastName  string                 `protobuf:"bytes,3,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
+	Password  string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
+	// role is used only when the user is created by the admin.
+	Role string `protobuf:"bytes,5,opt,name=role,proto3" json:"role,omitempty"`
+	// invitation is the token required for self-registration, role and zones
+	// of the user are taken from the invitation.
+	Invitation    string `protobuf:"bytes,6,opt,name=invitation,proto3" json:"invitation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Register) Reset() {
+	*x = Register{}
+	mi := &file_crud_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Register) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Register) ProtoMessage() {}
+
+func (x *Register) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Register.ProtoReflect.Descriptor instead.
+func (*Register) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *Register) GetLogin() string {
+	if x != nil {
+		return x.Login
+	}
+	return ""
+}
+
+func (x *Register) GetFirstName() string {
+	if x != nil {
+		return x.FirstName
+	}
+	return ""
+}
+
+func (x *Register) GetLastName() string {
+	if x != nil {
+		return x.LastName
+	}
+	return ""
+}
+
+func (x *Register) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *Register) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Register) GetInvitation() string {
+	if x != nil {
+		return x.Invitation
+	}
+	return ""
+}
+
+type ChangePassword struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Login         string                 `protobuf:"bytes,2,opt,name=login,proto3" json:"login,omitempty"`
-	FirstName     string                 `protobuf:"bytes,3,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
-	LastName      string                 `protobuf:"bytes,4,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
-	Password      string                 `protobuf:"bytes,5,opt,name=password,proto3" json:"password,omitempty"`
-	Role          string                 `protobuf:"bytes,6,opt,name=role,proto3" json:"role,omitempty"`
+	OldPassword   string                 `protobuf:"bytes,1,opt,name=old_password,json=oldPassword,proto3" json:"old_password,omitempty"`
+	NewPassword   string                 `protobuf:"bytes,2,opt,name=new_password,json=newPassword,proto3" json:"new_password,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *User) Reset() {
-	*x = User{}
-	mi := &file_crud_proto_msgTypes[0]
+func (x *ChangePassword) Reset() {
+	*x = ChangePassword{}
+	mi := &file_crud_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *User) String() string {
+func (x *ChangePassword) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*User) ProtoMessage() {}
+func (*ChangePassword) ProtoMessage() {}
 
-func (x *User) ProtoReflect() protoreflect.Message {
-	mi := &file_crud_proto_msgTypes[0]
+func (x *ChangePassword) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -59,75 +2424,159 @@ func (x *User) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use User.ProtoReflect.Descriptor instead.
-func (*User) Descriptor() ([]byte, []int) {
-	return file_crud_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use ChangePassword.ProtoReflect.Descriptor instead.
+func (*ChangePassword) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{37}
 }
 
-func (x *User) GetId() int32 {
+func (x *ChangePassword) GetOldPassword() string {
+	if x != nil {
+		return x.OldPassword
+	}
+	return ""
+}
+
+func (x *ChangePassword) GetNewPassword() string {
+	if x != nil {
+		return x.NewPassword
+	}
+	return ""
+}
+
+// Invitation allow self-registration of one user with the preset role.
+type Invitation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Role  string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
+	// zones restrict changes of the resource records to these zones, empty list allow all zones.
+	Zones     []string               `protobuf:"bytes,3,rep,name=zones,proto3" json:"zones,omitempty"`
+	CreatedBy string                 `protobuf:"bytes,4,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// used_by is the login of the registered user.
+	UsedBy string                 `protobuf:"bytes,7,opt,name=used_by,json=usedBy,proto3" json:"used_by,omitempty"`
+	UsedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=used_at,json=usedAt,proto3" json:"used_at,omitempty"`
+	// token is returned only once, when the invitation is created.
+	Token         string `protobuf:"bytes,9,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Invitation) Reset() {
+	*x = Invitation{}
+	mi := &file_crud_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Invitation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Invitation) ProtoMessage() {}
+
+func (x *Invitation) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Invitation.ProtoReflect.Descriptor instead.
+func (*Invitation) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *Invitation) GetId() int32 {
 	if x != nil {
 		return x.Id
 	}
 	return 0
 }
 
-func (x *User) GetLogin() string {
+func (x *Invitation) GetRole() string {
 	if x != nil {
-		return x.Login
+		return x.Role
 	}
 	return ""
 }
 
-func (x *User) GetFirstName() string {
+func (x *Invitation) GetZones() []string {
 	if x != nil {
-		return x.FirstName
+		return x.Zones
 	}
-	return ""
+	return nil
 }
 
-func (x *User) GetLastName() string {
+func (x *Invitation) GetCreatedBy() string {
 	if x != nil {
-		return x.LastName
+		return x.CreatedBy
 	}
 	return ""
 }
 
-func (x *User) GetPassword() string {
+func (x *Invitation) GetCreatedAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Password
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Invitation) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Invitation) GetUsedBy() string {
+	if x != nil {
+		return x.UsedBy
 	}
 	return ""
 }
 
-func (x *User) GetRole() string {
+func (x *Invitation) GetUsedAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Role
+		return x.UsedAt
+	}
+	return nil
+}
+
+func (x *Invitation) GetToken() string {
+	if x != nil {
+		return x.Token
 	}
 	return ""
 }
 
-type UserCollection struct {
+type InvitationCollection struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	Invitations   []*Invitation          `protobuf:"bytes,1,rep,name=invitations,proto3" json:"invitations,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UserCollection) Reset() {
-	*x = UserCollection{}
-	mi := &file_crud_proto_msgTypes[1]
+func (x *InvitationCollection) Reset() {
+	*x = InvitationCollection{}
+	mi := &file_crud_proto_msgTypes[39]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UserCollection) String() string {
+func (x *InvitationCollection) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UserCollection) ProtoMessage() {}
+func (*InvitationCollection) ProtoMessage() {}
 
-func (x *UserCollection) ProtoReflect() protoreflect.Message {
-	mi := &file_crud_proto_msgTypes[1]
+func (x *InvitationCollection) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[39]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -138,45 +2587,56 @@ func (x *UserCollection) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UserCollection.ProtoReflect.Descriptor instead.
-func (*UserCollection) Descriptor() ([]byte, []int) {
-	return file_crud_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use InvitationCollection.ProtoReflect.Descriptor instead.
+func (*InvitationCollection) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{39}
 }
 
-func (x *UserCollection) GetUsers() []*User {
+func (x *InvitationCollection) GetInvitations() []*Invitation {
 	if x != nil {
-		return x.Users
+		return x.Invitations
 	}
 	return nil
 }
 
-type ResourceRecord struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Domain        string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
-	Data          string                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
-	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
-	Class         string                 `protobuf:"bytes,5,opt,name=class,proto3" json:"class,omitempty"`
-	TimeToLive    int32                  `protobuf:"varint,6,opt,name=time_to_live,json=timeToLive,proto3" json:"time_to_live,omitempty"`
+// APIKey is the long-lived credential of the script or the service account,
+// it is sent in the Authorization: Bearer header.
+type APIKey struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// prefix is the beginning of the key, it identify the key in lists.
+	Prefix string `protobuf:"bytes,3,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Role   string `protobuf:"bytes,4,opt,name=role,proto3" json:"role,omitempty"`
+	// zones restrict changes of the resource records to these zones, empty list allow all zones.
+	Zones     []string               `protobuf:"bytes,5,rep,name=zones,proto3" json:"zones,omitempty"`
+	CreatedBy string                 `protobuf:"bytes,6,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// expires_at is not set for keys that don't expire.
+	ExpiresAt  *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	LastUsedAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=last_used_at,json=lastUsedAt,proto3" json:"last_used_at,omitempty"`
+	RevokedAt  *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=revoked_at,json=revokedAt,proto3" json:"revoked_at,omitempty"`
+	// key is returned only once, when the key is created.
+	Key           string `protobuf:"bytes,11,opt,name=key,proto3" json:"key,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ResourceRecord) Reset() {
-	*x = ResourceRecord{}
-	mi := &file_crud_proto_msgTypes[2]
+func (x *APIKey) Reset() {
+	*x = APIKey{}
+	mi := &file_crud_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ResourceRecord) String() string {
+func (x *APIKey) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResourceRecord) ProtoMessage() {}
+func (*APIKey) ProtoMessage() {}
 
-func (x *ResourceRecord) ProtoReflect() protoreflect.Message {
-	mi := &file_crud_proto_msgTypes[2]
+func (x *APIKey) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -187,75 +2647,110 @@ func (x *ResourceRecord) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResourceRecord.ProtoReflect.Descriptor instead.
-func (*ResourceRecord) Descriptor() ([]byte, []int) {
-	return file_crud_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use APIKey.ProtoReflect.Descriptor instead.
+func (*APIKey) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{40}
 }
 
-func (x *ResourceRecord) GetId() int32 {
+func (x *APIKey) GetId() int32 {
 	if x != nil {
 		return x.Id
 	}
 	return 0
 }
 
-func (x *ResourceRecord) GetDomain() string {
+func (x *APIKey) GetName() string {
 	if x != nil {
-		return x.Domain
+		return x.Name
 	}
 	return ""
 }
 
-func (x *ResourceRecord) GetData() string {
+func (x *APIKey) GetPrefix() string {
 	if x != nil {
-		return x.Data
+		return x.Prefix
 	}
 	return ""
 }
 
-func (x *ResourceRecord) GetType() string {
+func (x *APIKey) GetRole() string {
 	if x != nil {
-		return x.Type
+		return x.Role
 	}
 	return ""
 }
 
-func (x *ResourceRecord) GetClass() string {
+func (x *APIKey) GetZones() []string {
 	if x != nil {
-		return x.Class
+		return x.Zones
+	}
+	return nil
+}
+
+func (x *APIKey) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
 	}
 	return ""
 }
 
-func (x *ResourceRecord) GetTimeToLive() int32 {
+func (x *APIKey) GetCreatedAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.TimeToLive
+		return x.CreatedAt
 	}
-	return 0
+	return nil
 }
 
-type ResourceRecordCollection struct {
+func (x *APIKey) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *APIKey) GetLastUsedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastUsedAt
+	}
+	return nil
+}
+
+func (x *APIKey) GetRevokedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RevokedAt
+	}
+	return nil
+}
+
+func (x *APIKey) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type APIKeyCollection struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Records       []*ResourceRecord      `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	Keys          []*APIKey              `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ResourceRecordCollection) Reset() {
-	*x = ResourceRecordCollection{}
-	mi := &file_crud_proto_msgTypes[3]
+func (x *APIKeyCollection) Reset() {
+	*x = APIKeyCollection{}
+	mi := &file_crud_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ResourceRecordCollection) String() string {
+func (x *APIKeyCollection) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResourceRecordCollection) ProtoMessage() {}
+func (*APIKeyCollection) ProtoMessage() {}
 
-func (x *ResourceRecordCollection) ProtoReflect() protoreflect.Message {
-	mi := &file_crud_proto_msgTypes[3]
+func (x *APIKeyCollection) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -266,41 +2761,43 @@ func (x *ResourceRecordCollection) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResourceRecordCollection.ProtoReflect.Descriptor instead.
-func (*ResourceRecordCollection) Descriptor() ([]byte, []int) {
-	return file_crud_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use APIKeyCollection.ProtoReflect.Descriptor instead.
+func (*APIKeyCollection) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{41}
 }
 
-func (x *ResourceRecordCollection) GetRecords() []*ResourceRecord {
+func (x *APIKeyCollection) GetKeys() []*APIKey {
 	if x != nil {
-		return x.Records
+		return x.Keys
 	}
 	return nil
 }
 
-type Login struct {
+// Role is the named set of permissions, e.g. "records:write".
+type Role struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
-	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Permissions   []string               `protobuf:"bytes,3,rep,name=permissions,proto3" json:"permissions,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Login) Reset() {
-	*x = Login{}
-	mi := &file_crud_proto_msgTypes[4]
+func (x *Role) Reset() {
+	*x = Role{}
+	mi := &file_crud_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Login) String() string {
+func (x *Role) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Login) ProtoMessage() {}
+func (*Role) ProtoMessage() {}
 
-func (x *Login) ProtoReflect() protoreflect.Message {
-	mi := &file_crud_proto_msgTypes[4]
+func (x *Role) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -311,51 +2808,54 @@ func (x *Login) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Login.ProtoReflect.Descriptor instead.
-func (*Login) Descriptor() ([]byte, []int) {
-	return file_crud_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use Role.ProtoReflect.Descriptor instead.
+func (*Role) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{42}
 }
 
-func (x *Login) GetUsername() string {
+func (x *Role) GetId() int32 {
 	if x != nil {
-		return x.Username
+		return x.Id
 	}
-	return ""
+	return 0
 }
 
-func (x *Login) GetPassword() string {
+func (x *Role) GetName() string {
 	if x != nil {
-		return x.Password
+		return x.Name
 	}
 	return ""
 }
 
-type Register struct {
+func (x *Role) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+type RoleCollection struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Login         string                 `protobuf:"bytes,1,opt,name=login,proto3" json:"login,omitempty"`
-	FirstName     string                 `protobuf:"bytes,2,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
-	LastName      string                 `protobuf:"bytes,3,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
-	Password      string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
-	Role          string                 `protobuf:"bytes,5,opt,name=role,proto3" json:"role,omitempty"`
+	Roles         []*Role                `protobuf:"bytes,1,rep,name=roles,proto3" json:"roles,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Register) Reset() {
-	*x = Register{}
-	mi := &file_crud_proto_msgTypes[5]
+func (x *RoleCollection) Reset() {
+	*x = RoleCollection{}
+	mi := &file_crud_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Register) String() string {
+func (x *RoleCollection) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Register) ProtoMessage() {}
+func (*RoleCollection) ProtoMessage() {}
 
-func (x *Register) ProtoReflect() protoreflect.Message {
-	mi := &file_crud_proto_msgTypes[5]
+func (x *RoleCollection) ProtoReflect() protoreflect.Message {
+	mi := &file_crud_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -366,44 +2866,16 @@ func (x *Register) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Register.ProtoReflect.Descriptor instead.
-func (*Register) Descriptor() ([]byte, []int) {
-	return file_crud_proto_rawDescGZIP(), []int{5}
-}
-
-func (x *Register) GetLogin() string {
-	if x != nil {
-		return x.Login
-	}
-	return ""
-}
-
-func (x *Register) GetFirstName() string {
-	if x != nil {
-		return x.FirstName
-	}
-	return ""
-}
-
-func (x *Register) GetLastName() string {
-	if x != nil {
-		return x.LastName
-	}
-	return ""
-}
-
-func (x *Register) GetPassword() string {
-	if x != nil {
-		return x.Password
-	}
-	return ""
+// Deprecated: Use RoleCollection.ProtoReflect.Descriptor instead.
+func (*RoleCollection) Descriptor() ([]byte, []int) {
+	return file_crud_proto_rawDescGZIP(), []int{43}
 }
 
-func (x *Register) GetRole() string {
+func (x *RoleCollection) GetRoles() []*Role {
 	if x != nil {
-		return x.Role
+		return x.Roles
 	}
-	return ""
+	return nil
 }
 
 type Log struct {
@@ -417,7 +2889,7 @@ type Log struct {
 
 func (x *Log) Reset() {
 	*x = Log{}
-	mi := &file_crud_proto_msgTypes[6]
+	mi := &file_crud_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -429,7 +2901,7 @@ func (x *Log) String() string {
 func (*Log) ProtoMessage() {}
 
 func (x *Log) ProtoReflect() protoreflect.Message {
-	mi := &file_crud_proto_msgTypes[6]
+	mi := &file_crud_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -442,7 +2914,7 @@ func (x *Log) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Log.ProtoReflect.Descriptor instead.
 func (*Log) Descriptor() ([]byte, []int) {
-	return file_crud_proto_rawDescGZIP(), []int{6}
+	return file_crud_proto_rawDescGZIP(), []int{44}
 }
 
 func (x *Log) GetTime() *timestamppb.Timestamp {
@@ -475,7 +2947,7 @@ type LogCollection struct {
 
 func (x *LogCollection) Reset() {
 	*x = LogCollection{}
-	mi := &file_crud_proto_msgTypes[7]
+	mi := &file_crud_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -487,7 +2959,7 @@ func (x *LogCollection) String() string {
 func (*LogCollection) ProtoMessage() {}
 
 func (x *LogCollection) ProtoReflect() protoreflect.Message {
-	mi := &file_crud_proto_msgTypes[7]
+	mi := &file_crud_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -500,7 +2972,7 @@ func (x *LogCollection) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LogCollection.ProtoReflect.Descriptor instead.
 func (*LogCollection) Descriptor() ([]byte, []int) {
-	return file_crud_proto_rawDescGZIP(), []int{7}
+	return file_crud_proto_rawDescGZIP(), []int{45}
 }
 
 func (x *LogCollection) GetLogs() []*Log {
@@ -515,7 +2987,7 @@ var File_crud_proto protoreflect.FileDescriptor
 const file_crud_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"crud.proto\x12\acrud.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x98\x01\n" +
+	"crud.proto\x12\acrud.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x82\x02\n" +
 	"\x04User\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
 	"\x05login\x18\x02 \x01(\tR\x05login\x12\x1d\n" +
@@ -523,9 +2995,12 @@ const file_crud_proto_rawDesc = "" +
 	"first_name\x18\x03 \x01(\tR\tfirstName\x12\x1b\n" +
 	"\tlast_name\x18\x04 \x01(\tR\blastName\x12\x1a\n" +
 	"\bpassword\x18\x05 \x01(\tR\bpassword\x12\x12\n" +
-	"\x04role\x18\x06 \x01(\tR\x04role\"5\n" +
+	"\x04role\x18\x06 \x01(\tR\x04role\x12\x14\n" +
+	"\x05zones\x18\a \x03(\tR\x05zones\x12 \n" +
+	"\vpermissions\x18\b \x03(\tR\vpermissions\x120\n" +
+	"\x14must_change_password\x18\t \x01(\bR\x12mustChangePassword\"5\n" +
 	"\x0eUserCollection\x12#\n" +
-	"\x05users\x18\x01 \x03(\v2\r.crud.v1.UserR\x05users\"\x98\x01\n" +
+	"\x05users\x18\x01 \x03(\v2\r.crud.v1.UserR\x05users\"\xf6\x03\n" +
 	"\x0eResourceRecord\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x16\n" +
 	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x12\n" +
@@ -533,19 +3008,201 @@ const file_crud_proto_rawDesc = "" +
 	"\x04type\x18\x04 \x01(\tR\x04type\x12\x14\n" +
 	"\x05class\x18\x05 \x01(\tR\x05class\x12 \n" +
 	"\ftime_to_live\x18\x06 \x01(\x05R\n" +
-	"timeToLive\"M\n" +
+	"timeToLive\x12\x1e\n" +
+	"\x01a\x18\a \x01(\v2\x0e.crud.v1.ADataH\x00R\x01a\x12'\n" +
+	"\x04aaaa\x18\b \x01(\v2\x11.crud.v1.AAAADataH\x00R\x04aaaa\x12!\n" +
+	"\x02mx\x18\t \x01(\v2\x0f.crud.v1.MXDataH\x00R\x02mx\x12$\n" +
+	"\x03srv\x18\n" +
+	" \x01(\v2\x10.crud.v1.SRVDataH\x00R\x03srv\x12$\n" +
+	"\x03txt\x18\v \x01(\v2\x10.crud.v1.TXTDataH\x00R\x03txt\x12$\n" +
+	"\x03caa\x18\f \x01(\v2\x10.crud.v1.CAADataH\x00R\x03caa\x12*\n" +
+	"\x05cname\x18\r \x01(\v2\x12.crud.v1.CNAMEDataH\x00R\x05cname\x12!\n" +
+	"\x02ns\x18\x0e \x01(\v2\x0f.crud.v1.NSDataH\x00R\x02ns\x12$\n" +
+	"\x03ptr\x18\x0f \x01(\v2\x10.crud.v1.PTRDataH\x00R\x03ptrB\t\n" +
+	"\apayload\"!\n" +
+	"\x05AData\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\"$\n" +
+	"\bAAAAData\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\"D\n" +
+	"\x06MXData\x12\x1e\n" +
+	"\n" +
+	"preference\x18\x01 \x01(\rR\n" +
+	"preference\x12\x1a\n" +
+	"\bexchange\x18\x02 \x01(\tR\bexchange\"i\n" +
+	"\aSRVData\x12\x1a\n" +
+	"\bpriority\x18\x01 \x01(\rR\bpriority\x12\x16\n" +
+	"\x06weight\x18\x02 \x01(\rR\x06weight\x12\x12\n" +
+	"\x04port\x18\x03 \x01(\rR\x04port\x12\x16\n" +
+	"\x06target\x18\x04 \x01(\tR\x06target\"\x1d\n" +
+	"\aTXTData\x12\x12\n" +
+	"\x04text\x18\x01 \x03(\tR\x04text\"E\n" +
+	"\aCAAData\x12\x12\n" +
+	"\x04flag\x18\x01 \x01(\rR\x04flag\x12\x10\n" +
+	"\x03tag\x18\x02 \x01(\tR\x03tag\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\"#\n" +
+	"\tCNAMEData\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\"\x1c\n" +
+	"\x06NSData\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\"!\n" +
+	"\aPTRData\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\"<\n" +
+	"\n" +
+	"FieldError\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"?\n" +
+	"\x10ValidationErrors\x12+\n" +
+	"\x06errors\x18\x01 \x03(\v2\x13.crud.v1.FieldErrorR\x06errors\"M\n" +
 	"\x18ResourceRecordCollection\x121\n" +
-	"\arecords\x18\x01 \x03(\v2\x17.crud.v1.ResourceRecordR\arecords\"?\n" +
+	"\arecords\x18\x01 \x03(\v2\x17.crud.v1.ResourceRecordR\arecords\"h\n" +
+	"\x12ResourceRecordPage\x121\n" +
+	"\arecords\x18\x01 \x03(\v2\x17.crud.v1.ResourceRecordR\arecords\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"\xd6\x01\n" +
+	"\x0fRecordOperation\x127\n" +
+	"\x06action\x18\x01 \x01(\x0e2\x1f.crud.v1.RecordOperation.ActionR\x06action\x12/\n" +
+	"\x06record\x18\x02 \x01(\v2\x17.crud.v1.ResourceRecordR\x06record\"Y\n" +
+	"\x06Action\x12\x16\n" +
+	"\x12ACTION_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rACTION_CREATE\x10\x01\x12\x11\n" +
+	"\rACTION_UPDATE\x10\x02\x12\x11\n" +
+	"\rACTION_DELETE\x10\x03\"`\n" +
+	"\vRecordBatch\x128\n" +
+	"\n" +
+	"operations\x18\x01 \x03(\v2\x18.crud.v1.RecordOperationR\n" +
+	"operations\x12\x17\n" +
+	"\adry_run\x18\x02 \x01(\bR\x06dryRun\"=\n" +
+	"\x15RecordOperationResult\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"g\n" +
+	"\x11RecordBatchResult\x128\n" +
+	"\aresults\x18\x01 \x03(\v2\x1e.crud.v1.RecordOperationResultR\aresults\x12\x18\n" +
+	"\aapplied\x18\x02 \x01(\bR\aapplied\"\xff\x01\n" +
+	"\fRecordChange\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x1b\n" +
+	"\trecord_id\x18\x02 \x01(\x05R\brecordId\x12\x1c\n" +
+	"\toperation\x18\x03 \x01(\tR\toperation\x12\x14\n" +
+	"\x05actor\x18\x04 \x01(\tR\x05actor\x12.\n" +
+	"\x04time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12/\n" +
+	"\x06before\x18\x06 \x01(\v2\x17.crud.v1.ResourceRecordR\x06before\x12-\n" +
+	"\x05after\x18\a \x01(\v2\x17.crud.v1.ResourceRecordR\x05after\"I\n" +
+	"\x16RecordChangeCollection\x12/\n" +
+	"\achanges\x18\x01 \x03(\v2\x15.crud.v1.RecordChangeR\achanges\">\n" +
+	"\fZoneRollback\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\"0\n" +
+	"\x12ZoneRollbackResult\x12\x1a\n" +
+	"\brestored\x18\x01 \x01(\x05R\brestored\"{\n" +
+	"\x04View\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
+	"\bnetworks\x18\x03 \x03(\tR\bnetworks\x12\x14\n" +
+	"\x05zones\x18\x04 \x03(\tR\x05zones\x12\x1d\n" +
+	"\n" +
+	"record_ids\x18\x05 \x03(\x05R\trecordIds\"5\n" +
+	"\x0eViewCollection\x12#\n" +
+	"\x05views\x18\x01 \x03(\v2\r.crud.v1.ViewR\x05views\"\xc7\x01\n" +
+	"\aACLRule\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x18\n" +
+	"\anetwork\x18\x02 \x01(\tR\anetwork\x12\x1f\n" +
+	"\vallow_query\x18\x03 \x01(\bR\n" +
+	"allowQuery\x12'\n" +
+	"\x0fallow_recursion\x18\x04 \x01(\bR\x0eallowRecursion\x12%\n" +
+	"\x0eallow_transfer\x18\x05 \x01(\bR\rallowTransfer\x12!\n" +
+	"\fallow_update\x18\x06 \x01(\bR\vallowUpdate\";\n" +
+	"\x11ACLRuleCollection\x12&\n" +
+	"\x05rules\x18\x01 \x03(\v2\x10.crud.v1.ACLRuleR\x05rules\"2\n" +
+	"\bStatItem\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"2\n" +
+	"\aStatTop\x12'\n" +
+	"\x05items\x18\x01 \x03(\v2\x11.crud.v1.StatItemR\x05items\"m\n" +
+	"\vVolumePoint\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\x12\x18\n" +
+	"\ablocked\x18\x03 \x01(\x03R\ablocked\";\n" +
+	"\vQueryVolume\x12,\n" +
+	"\x06points\x18\x01 \x03(\v2\x14.crud.v1.VolumePointR\x06points\"\xd4\x02\n" +
+	"\rQueryLogEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x16\n" +
+	"\x06client\x18\x03 \x01(\tR\x06client\x12\x1a\n" +
+	"\bprotocol\x18\x04 \x01(\tR\bprotocol\x12\x12\n" +
+	"\x04name\x18\x05 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x06 \x01(\tR\x04type\x12\x14\n" +
+	"\x05rcode\x18\a \x01(\tR\x05rcode\x12\x16\n" +
+	"\x06answer\x18\b \x01(\tR\x06answer\x12\x1a\n" +
+	"\bupstream\x18\t \x01(\tR\bupstream\x12\x1d\n" +
+	"\n" +
+	"latency_us\x18\n" +
+	" \x01(\x03R\tlatencyUs\x12\x1b\n" +
+	"\tcache_hit\x18\v \x01(\bR\bcacheHit\x12!\n" +
+	"\fblock_reason\x18\f \x01(\tR\vblockReason\"a\n" +
+	"\fQueryLogPage\x120\n" +
+	"\aentries\x18\x01 \x03(\v2\x16.crud.v1.QueryLogEntryR\aentries\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"}\n" +
+	"\x14QueryLogSubscription\x12\x16\n" +
+	"\x06client\x18\x01 \x01(\tR\x06client\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12!\n" +
+	"\fblocked_only\x18\x04 \x01(\bR\vblockedOnly\"?\n" +
 	"\x05Login\x12\x1a\n" +
 	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
-	"\bpassword\x18\x02 \x01(\tR\bpassword\"\x8c\x01\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"\xac\x01\n" +
 	"\bRegister\x12\x14\n" +
 	"\x05login\x18\x01 \x01(\tR\x05login\x12\x1d\n" +
 	"\n" +
 	"first_name\x18\x02 \x01(\tR\tfirstName\x12\x1b\n" +
 	"\tlast_name\x18\x03 \x01(\tR\blastName\x12\x1a\n" +
 	"\bpassword\x18\x04 \x01(\tR\bpassword\x12\x12\n" +
-	"\x04role\x18\x05 \x01(\tR\x04role\"]\n" +
+	"\x04role\x18\x05 \x01(\tR\x04role\x12\x1e\n" +
+	"\n" +
+	"invitation\x18\x06 \x01(\tR\n" +
+	"invitation\"V\n" +
+	"\x0eChangePassword\x12!\n" +
+	"\fold_password\x18\x01 \x01(\tR\voldPassword\x12!\n" +
+	"\fnew_password\x18\x02 \x01(\tR\vnewPassword\"\xbf\x02\n" +
+	"\n" +
+	"Invitation\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04role\x18\x02 \x01(\tR\x04role\x12\x14\n" +
+	"\x05zones\x18\x03 \x03(\tR\x05zones\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x04 \x01(\tR\tcreatedBy\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x17\n" +
+	"\aused_by\x18\a \x01(\tR\x06usedBy\x123\n" +
+	"\aused_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x06usedAt\x12\x14\n" +
+	"\x05token\x18\t \x01(\tR\x05token\"M\n" +
+	"\x14InvitationCollection\x125\n" +
+	"\vinvitations\x18\x01 \x03(\v2\x13.crud.v1.InvitationR\vinvitations\"\x8e\x03\n" +
+	"\x06APIKey\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06prefix\x18\x03 \x01(\tR\x06prefix\x12\x12\n" +
+	"\x04role\x18\x04 \x01(\tR\x04role\x12\x14\n" +
+	"\x05zones\x18\x05 \x03(\tR\x05zones\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x06 \x01(\tR\tcreatedBy\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12<\n" +
+	"\flast_used_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"lastUsedAt\x129\n" +
+	"\n" +
+	"revoked_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\trevokedAt\x12\x10\n" +
+	"\x03key\x18\v \x01(\tR\x03key\"7\n" +
+	"\x10APIKeyCollection\x12#\n" +
+	"\x04keys\x18\x01 \x03(\v2\x0f.crud.v1.APIKeyR\x04keys\"L\n" +
+	"\x04Role\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vpermissions\x18\x03 \x03(\tR\vpermissions\"5\n" +
+	"\x0eRoleCollection\x12#\n" +
+	"\x05roles\x18\x01 \x03(\v2\r.crud.v1.RoleR\x05roles\"]\n" +
 	"\x03Log\x12.\n" +
 	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x14\n" +
 	"\x05level\x18\x02 \x01(\tR\x05level\x12\x10\n" +
@@ -566,28 +3223,105 @@ func file_crud_proto_rawDescGZIP() []byte {
 	return file_crud_proto_rawDescData
 }
 
-var file_crud_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_crud_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_crud_proto_msgTypes = make([]protoimpl.MessageInfo, 46)
 var file_crud_proto_goTypes = []any{
-	(*User)(nil),                     // 0: crud.v1.User
-	(*UserCollection)(nil),           // 1: crud.v1.UserCollection
-	(*ResourceRecord)(nil),           // 2: crud.v1.ResourceRecord
-	(*ResourceRecordCollection)(nil), // 3: crud.v1.ResourceRecordCollection
-	(*Login)(nil),                    // 4: crud.v1.Login
-	(*Register)(nil),                 // 5: crud.v1.Register
-	(*Log)(nil),                      // 6: crud.v1.Log
-	(*LogCollection)(nil),            // 7: crud.v1.LogCollection
-	(*timestamppb.Timestamp)(nil),    // 8: google.protobuf.Timestamp
+	(RecordOperation_Action)(0),      // 0: crud.v1.RecordOperation.Action
+	(*User)(nil),                     // 1: crud.v1.User
+	(*UserCollection)(nil),           // 2: crud.v1.UserCollection
+	(*ResourceRecord)(nil),           // 3: crud.v1.ResourceRecord
+	(*AData)(nil),                    // 4: crud.v1.AData
+	(*AAAAData)(nil),                 // 5: crud.v1.AAAAData
+	(*MXData)(nil),                   // 6: crud.v1.MXData
+	(*SRVData)(nil),                  // 7: crud.v1.SRVData
+	(*TXTData)(nil),                  // 8: crud.v1.TXTData
+	(*CAAData)(nil),                  // 9: crud.v1.CAAData
+	(*CNAMEData)(nil),                // 10: crud.v1.CNAMEData
+	(*NSData)(nil),                   // 11: crud.v1.NSData
+	(*PTRData)(nil),                  // 12: crud.v1.PTRData
+	(*FieldError)(nil),               // 13: crud.v1.FieldError
+	(*ValidationErrors)(nil),         // 14: crud.v1.ValidationErrors
+	(*ResourceRecordCollection)(nil), // 15: crud.v1.ResourceRecordCollection
+	(*ResourceRecordPage)(nil),       // 16: crud.v1.ResourceRecordPage
+	(*RecordOperation)(nil),          // 17: crud.v1.RecordOperation
+	(*RecordBatch)(nil),              // 18: crud.v1.RecordBatch
+	(*RecordOperationResult)(nil),    // 19: crud.v1.RecordOperationResult
+	(*RecordBatchResult)(nil),        // 20: crud.v1.RecordBatchResult
+	(*RecordChange)(nil),             // 21: crud.v1.RecordChange
+	(*RecordChangeCollection)(nil),   // 22: crud.v1.RecordChangeCollection
+	(*ZoneRollback)(nil),             // 23: crud.v1.ZoneRollback
+	(*ZoneRollbackResult)(nil),       // 24: crud.v1.ZoneRollbackResult
+	(*View)(nil),                     // 25: crud.v1.View
+	(*ViewCollection)(nil),           // 26: crud.v1.ViewCollection
+	(*ACLRule)(nil),                  // 27: crud.v1.ACLRule
+	(*ACLRuleCollection)(nil),        // 28: crud.v1.ACLRuleCollection
+	(*StatItem)(nil),                 // 29: crud.v1.StatItem
+	(*StatTop)(nil),                  // 30: crud.v1.StatTop
+	(*VolumePoint)(nil),              // 31: crud.v1.VolumePoint
+	(*QueryVolume)(nil),              // 32: crud.v1.QueryVolume
+	(*QueryLogEntry)(nil),            // 33: crud.v1.QueryLogEntry
+	(*QueryLogPage)(nil),             // 34: crud.v1.QueryLogPage
+	(*QueryLogSubscription)(nil),     // 35: crud.v1.QueryLogSubscription
+	(*Login)(nil),                    // 36: crud.v1.Login
+	(*Register)(nil),                 // 37: crud.v1.Register
+	(*ChangePassword)(nil),           // 38: crud.v1.ChangePassword
+	(*Invitation)(nil),               // 39: crud.v1.Invitation
+	(*InvitationCollection)(nil),     // 40: crud.v1.InvitationCollection
+	(*APIKey)(nil),                   // 41: crud.v1.APIKey
+	(*APIKeyCollection)(nil),         // 42: crud.v1.APIKeyCollection
+	(*Role)(nil),                     // 43: crud.v1.Role
+	(*RoleCollection)(nil),           // 44: crud.v1.RoleCollection
+	(*Log)(nil),                      // 45: crud.v1.Log
+	(*LogCollection)(nil),            // 46: crud.v1.LogCollection
+	(*timestamppb.Timestamp)(nil),    // 47: google.protobuf.Timestamp
 }
 var file_crud_proto_depIdxs = []int32{
-	0, // 0: crud.v1.UserCollection.users:type_name -> crud.v1.User
-	2, // 1: crud.v1.ResourceRecordCollection.records:type_name -> crud.v1.ResourceRecord
-	8, // 2: crud.v1.Log.time:type_name -> google.protobuf.Timestamp
-	6, // 3: crud.v1.LogCollection.logs:type_name -> crud.v1.Log
-	4, // [4:4] is the sub-list for method output_type
-	4, // [4:4] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	1,  // 0: crud.v1.UserCollection.users:type_name -> crud.v1.User
+	4,  // 1: crud.v1.ResourceRecord.a:type_name -> crud.v1.AData
+	5,  // 2: crud.v1.ResourceRecord.aaaa:type_name -> crud.v1.AAAAData
+	6,  // 3: crud.v1.ResourceRecord.mx:type_name -> crud.v1.MXData
+	7,  // 4: crud.v1.ResourceRecord.srv:type_name -> crud.v1.SRVData
+	8,  // 5: crud.v1.ResourceRecord.txt:type_name -> crud.v1.TXTData
+	9,  // 6: crud.v1.ResourceRecord.caa:type_name -> crud.v1.CAAData
+	10, // 7: crud.v1.ResourceRecord.cname:type_name -> crud.v1.CNAMEData
+	11, // 8: crud.v1.ResourceRecord.ns:type_name -> crud.v1.NSData
+	12, // 9: crud.v1.ResourceRecord.ptr:type_name -> crud.v1.PTRData
+	13, // 10: crud.v1.ValidationErrors.errors:type_name -> crud.v1.FieldError
+	3,  // 11: crud.v1.ResourceRecordCollection.records:type_name -> crud.v1.ResourceRecord
+	3,  // 12: crud.v1.ResourceRecordPage.records:type_name -> crud.v1.ResourceRecord
+	0,  // 13: crud.v1.RecordOperation.action:type_name -> crud.v1.RecordOperation.Action
+	3,  // 14: crud.v1.RecordOperation.record:type_name -> crud.v1.ResourceRecord
+	17, // 15: crud.v1.RecordBatch.operations:type_name -> crud.v1.RecordOperation
+	19, // 16: crud.v1.RecordBatchResult.results:type_name -> crud.v1.RecordOperationResult
+	47, // 17: crud.v1.RecordChange.time:type_name -> google.protobuf.Timestamp
+	3,  // 18: crud.v1.RecordChange.before:type_name -> crud.v1.ResourceRecord
+	3,  // 19: crud.v1.RecordChange.after:type_name -> crud.v1.ResourceRecord
+	21, // 20: crud.v1.RecordChangeCollection.changes:type_name -> crud.v1.RecordChange
+	47, // 21: crud.v1.ZoneRollback.time:type_name -> google.protobuf.Timestamp
+	25, // 22: crud.v1.ViewCollection.views:type_name -> crud.v1.View
+	27, // 23: crud.v1.ACLRuleCollection.rules:type_name -> crud.v1.ACLRule
+	29, // 24: crud.v1.StatTop.items:type_name -> crud.v1.StatItem
+	47, // 25: crud.v1.VolumePoint.time:type_name -> google.protobuf.Timestamp
+	31, // 26: crud.v1.QueryVolume.points:type_name -> crud.v1.VolumePoint
+	47, // 27: crud.v1.QueryLogEntry.time:type_name -> google.protobuf.Timestamp
+	33, // 28: crud.v1.QueryLogPage.entries:type_name -> crud.v1.QueryLogEntry
+	47, // 29: crud.v1.Invitation.created_at:type_name -> google.protobuf.Timestamp
+	47, // 30: crud.v1.Invitation.expires_at:type_name -> google.protobuf.Timestamp
+	47, // 31: crud.v1.Invitation.used_at:type_name -> google.protobuf.Timestamp
+	39, // 32: crud.v1.InvitationCollection.invitations:type_name -> crud.v1.Invitation
+	47, // 33: crud.v1.APIKey.created_at:type_name -> google.protobuf.Timestamp
+	47, // 34: crud.v1.APIKey.expires_at:type_name -> google.protobuf.Timestamp
+	47, // 35: crud.v1.APIKey.last_used_at:type_name -> google.protobuf.Timestamp
+	47, // 36: crud.v1.APIKey.revoked_at:type_name -> google.protobuf.Timestamp
+	41, // 37: crud.v1.APIKeyCollection.keys:type_name -> crud.v1.APIKey
+	43, // 38: crud.v1.RoleCollection.roles:type_name -> crud.v1.Role
+	47, // 39: crud.v1.Log.time:type_name -> google.protobuf.Timestamp
+	45, // 40: crud.v1.LogCollection.logs:type_name -> crud.v1.Log
+	41, // [41:41] is the sub-list for method output_type
+	41, // [41:41] is the sub-list for method input_type
+	41, // [41:41] is the sub-list for extension type_name
+	41, // [41:41] is the sub-list for extension extendee
+	0,  // [0:41] is the sub-list for field type_name
 }
 
 func init() { file_crud_proto_init() }
@@ -595,18 +3329,30 @@ func file_crud_proto_init() {
 	if File_crud_proto != nil {
 		return
 	}
+	file_crud_proto_msgTypes[2].OneofWrappers = []any{
+		(*ResourceRecord_A)(nil),
+		(*ResourceRecord_Aaaa)(nil),
+		(*ResourceRecord_Mx)(nil),
+		(*ResourceRecord_Srv)(nil),
+		(*ResourceRecord_Txt)(nil),
+		(*ResourceRecord_Caa)(nil),
+		(*ResourceRecord_Cname)(nil),
+		(*ResourceRecord_Ns)(nil),
+		(*ResourceRecord_Ptr)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_crud_proto_rawDesc), len(file_crud_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   8,
+			NumEnums:      1,
+			NumMessages:   46,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_crud_proto_goTypes,
 		DependencyIndexes: file_crud_proto_depIdxs,
+		EnumInfos:         file_crud_proto_enumTypes,
 		MessageInfos:      file_crud_proto_msgTypes,
 	}.Build()
 	File_crud_proto = out.File